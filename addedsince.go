@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// addedSinceBase describes the git revision -added-since compares against.
+type addedSinceBase struct {
+	ctx  context.Context
+	ref  string
+	root string // canonical repository toplevel
+}
+
+// addedSymbol is an exported top-level declaration missing at the base ref.
+type addedSymbol struct {
+	kind string // const, var, func or type
+	name string
+}
+
+// newAddedSinceBase checks that ref names a commit in the repository that
+// contains the working directory.
+func newAddedSinceBase(ctx context.Context, ref string) (*addedSinceBase, error) {
+	if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("-added-since %s: not a commit in this repository", ref)
+	}
+	top, err := gitOutput(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	return &addedSinceBase{ctx: ctx, ref: ref, root: canonicalPath(strings.TrimSpace(top))}, nil
+}
+
+// exportedNames parses the non-test .go files of package pkgName in dir as
+// they were at the base ref and returns their exported top-level names. A
+// directory absent at the base ref has none.
+func (b *addedSinceBase) exportedNames(dir, pkgName string) (map[string]bool, error) {
+	rel, err := filepath.Rel(b.root, canonicalPath(dir))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("-added-since: %s is outside the git repository %s", dir, b.root)
+	}
+	args := []string{"ls-tree", "--full-tree", "--name-only", b.ref}
+	if rel != "." {
+		args = append(args, "--", filepath.ToSlash(rel)+"/")
+	}
+	out, err := gitOutput(b.ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, file := range strings.Split(out, "\n") {
+		file = strings.TrimSpace(file)
+		if path.Ext(file) != ".go" || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := gitOutput(b.ctx, "show", b.ref+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		syntax, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+		if err != nil || syntax.Name.Name != pkgName {
+			continue
+		}
+		for _, decl := range syntax.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.IsExported() {
+					names[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.Name.IsExported() {
+							names[s.Name.Name] = true
+						}
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							if n.IsExported() {
+								names[n.Name] = true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// addedSymbols lists pkg's exported top-level symbols that old lacks, in the
+// order the document renders them.
+func addedSymbols(pkg *doc.Package, old map[string]bool) []addedSymbol {
+	var added []addedSymbol
+	add := func(kind, name string) {
+		if token.IsExported(name) && !old[name] {
+			added = append(added, addedSymbol{kind: kind, name: name})
+		}
+	}
+	values := func(kind string, vals []*doc.Value) {
+		for _, v := range vals {
+			for _, name := range v.Names {
+				add(kind, name)
+			}
+		}
+	}
+	values("const", pkg.Consts)
+	values("var", pkg.Vars)
+	for _, f := range pkg.Funcs {
+		add("func", f.Name)
+	}
+	for _, t := range pkg.Types {
+		add("type", t.Name)
+		values("const", t.Consts)
+		values("var", t.Vars)
+		for _, f := range t.Funcs {
+			add("func", f.Name)
+		}
+	}
+	return added
+}
+
+// renderAddedSince writes the -added-since section, linking each symbol to
+// its section when -all renders one.
+func (r *markdownRenderer) renderAddedSince(w io.Writer) {
+	if len(r.addedSince) == 0 {
+		return
+	}
+	r.heading(w, 2, "Added since %s", r.options.addedSince)
+	for _, sym := range r.addedSince {
+		label := sym.kind + " " + sym.name
+		if anchor, ok := r.symbolAnchor(sym.name); ok && r.options.all {
+			fmt.Fprintf(w, "- [`%s`](#%s)\n", label, anchor)
+		} else {
+			fmt.Fprintf(w, "- `%s`\n", label)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultAdmonitions are the paragraph keywords rendered as GitHub alerts
+// unless -admonitions says otherwise.
+const defaultAdmonitions = "Note,Warning,Caution"
+
+// alertTypes are the GitHub alert kinds an -admonitions keyword may name.
+var alertTypes = []string{"NOTE", "TIP", "IMPORTANT", "WARNING", "CAUTION"}
+
+// parseAdmonitions maps each lower-cased -admonitions keyword to its GitHub
+// alert type.
+func parseAdmonitions(spec string) (map[string]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	kinds := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		keyword := strings.TrimSpace(part)
+		if keyword == "" {
+			continue
+		}
+		alert := strings.ToUpper(keyword)
+		if !contains(alertTypes, alert) {
+			return nil, fmt.Errorf("invalid -admonitions keyword %q (want note, tip, important, warning, or caution)", part)
+		}
+		kinds[strings.ToLower(keyword)] = alert
+	}
+	return kinds, nil
+}
+
+// renderAdmonitions turns each paragraph that opens with a configured
+// keyword and a colon, such as "Note: ...", into a GitHub alert blockquote.
+// Code blocks and other paragraphs are left alone.
+func (r *markdownRenderer) renderAdmonitions(md string) string {
+	if len(r.options.admonitionKinds) == 0 {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || (i > 0 && lines[i-1] != "") {
+			continue
+		}
+		keyword, rest, ok := strings.Cut(line, ":")
+		alert := r.options.admonitionKinds[strings.ToLower(keyword)]
+		if !ok || alert == "" {
+			continue
+		}
+		quoted := []string{"> [!" + alert + "]"}
+		if rest = strings.TrimSpace(rest); rest != "" {
+			quoted = append(quoted, "> "+rest)
+		}
+		end := i + 1
+		for ; end < len(lines) && lines[end] != ""; end++ {
+			quoted = append(quoted, "> "+lines[end])
+		}
+		lines = append(lines[:i], append(quoted, lines[end:]...)...)
+		i += len(quoted) - 1
+	}
+	return strings.Join(lines, "\n")
+}
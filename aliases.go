@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"sort"
+)
+
+// splitAliases removes the type aliases from pkg for -alias and returns
+// them. The functions, constants, and variables go/doc grouped under an
+// alias move to the package level so they keep their sections.
+func splitAliases(pkg *doc.Package) []*doc.Type {
+	var aliases []*doc.Type
+	kept := pkg.Types[:0]
+	for _, t := range pkg.Types {
+		spec := findTypeSpec(t.Decl, t.Name)
+		if spec == nil || !spec.Assign.IsValid() {
+			kept = append(kept, t)
+			continue
+		}
+		aliases = append(aliases, t)
+		pkg.Consts = append(pkg.Consts, t.Consts...)
+		pkg.Vars = append(pkg.Vars, t.Vars...)
+		pkg.Funcs = append(pkg.Funcs, t.Funcs...)
+	}
+	pkg.Types = kept
+	sort.Slice(pkg.Funcs, func(i, j int) bool { return pkg.Funcs[i].Name < pkg.Funcs[j].Name })
+	return aliases
+}
+
+// renderAliases writes the -alias table of the package's type aliases and
+// their targets.
+func (r *markdownRenderer) renderAliases(w io.Writer) {
+	if len(r.aliases) == 0 {
+		return
+	}
+	r.heading(w, 2, "Aliases")
+	fmt.Fprintln(w, "| Alias | Target | Description |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, t := range r.aliases {
+		spec := findTypeSpec(t.Decl, t.Name)
+		desc := t.Doc
+		switch {
+		case spec.Doc != nil:
+			desc = spec.Doc.Text()
+		case spec.Comment != nil:
+			desc = spec.Comment.Text()
+		}
+		fmt.Fprintf(w, "| `%s` | `%s` | %s |\n", t.Name, escapeTableCell(r.formatNode(spec.Type)), escapeTableCell(r.summaryText(desc)))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// isArchiveOutput reports whether -o names a tar or zip bundle rather than a
+// file or directory.
+func isArchiveOutput(path string) bool {
+	if isPipeOutput(path) {
+		return false
+	}
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// writePackageDocsToArchive lays the tree out exactly as directory mode would
+// and bundles it into an archive at path (see bundleDirectory).
+func writePackageDocsToArchive(fw docWriter, path string, docs []treeDoc, opts options) error {
+	staging, err := os.MkdirTemp("", "go-docmd-archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	if err := writePackageDocsToDir(diskWriter{}, staging, docs, opts); err != nil {
+		return err
+	}
+	if opts.apiSchema {
+		for _, doc := range docs {
+			if err := writeAPISchemas(diskWriter{}, filepath.Join(staging, filepath.FromSlash(doc.relDir)), doc.schemas); err != nil {
+				return err
+			}
+		}
+	}
+	return bundleDirectory(fw, path, staging)
+}
+
+// writeSymbolSetToArchive writes the files a symbol-set export would put in
+// a directory, one Markdown file per matching symbol plus index.md, into an
+// archive at opts.outputPath.
+func writeSymbolSetToArchive(ctx context.Context, pkgExpr, pattern string, opts options, fw docWriter) error {
+	staging, err := os.MkdirTemp("", "go-docmd-archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	staged := opts
+	staged.outputPath = staging
+	if err := documentSymbolSet(ctx, pkgExpr, pattern, staged, diskWriter{}); err != nil {
+		return err
+	}
+	return bundleDirectory(fw, opts.outputPath, staging)
+}
+
+// bundleDirectory archives the files under dir to path: a zip archive when
+// path ends in .zip, otherwise a tar archive, gzip-compressed unless path
+// ends in plain .tar.
+func bundleDirectory(fw docWriter, path, dir string) error {
+	var buf bytes.Buffer
+	if strings.HasSuffix(path, ".zip") {
+		if err := zipDirectory(&buf, dir); err != nil {
+			return err
+		}
+		return fw.writeFile(path, buf.Bytes())
+	}
+	var out io.Writer = &buf
+	var zw *gzip.Writer
+	if !strings.HasSuffix(path, ".tar") {
+		zw = gzip.NewWriter(&buf)
+		out = zw
+	}
+	if err := tarDirectory(out, dir); err != nil {
+		return err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	return fw.writeFile(path, buf.Bytes())
+}
+
+// zipDirectory writes every file under dir to w as a zip entry named by its
+// slash-separated path relative to dir. Like tarDirectory it fixes the
+// timestamps so identical trees produce identical archives.
+func zipDirectory(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     filepath.ToSlash(rel),
+			Method:   zip.Deflate,
+			Modified: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// tarDirectory writes every file under dir to w with slash-separated paths
+// relative to dir. Timestamps are fixed so identical trees produce identical
+// archives.
+func tarDirectory(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if entry.IsDir() {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     0o755,
+				ModTime:  time.Unix(0, 0),
+			})
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			ModTime:  time.Unix(0, 0),
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
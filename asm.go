@@ -0,0 +1,68 @@
+package main
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const assemblyNote = "*Implemented in assembly.*"
+
+// packageAssemblyFuncs returns, keyed as directiveKey gives them, the
+// package-level functions and methods declared without a body in a package
+// that has .s files. A bodyless declaration pulled in with //go:linkname is
+// implemented elsewhere in Go and is left out.
+func packageAssemblyFuncs(pkgInfo *packages.Package) map[string]bool {
+	if !hasAssemblyFiles(pkgInfo) {
+		return nil
+	}
+	var funcs map[string]bool
+	for _, file := range sourceFiles(pkgInfo) {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body != nil || hasLinkname(fn) {
+				continue
+			}
+			if funcs == nil {
+				funcs = make(map[string]bool)
+			}
+			funcs[directiveKey(pkgInfo.Fset, fn)] = true
+		}
+	}
+	return funcs
+}
+
+func hasAssemblyFiles(pkgInfo *packages.Package) bool {
+	for _, path := range pkgInfo.OtherFiles {
+		if filepath.Ext(path) == ".s" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLinkname(fn *ast.FuncDecl) bool {
+	if fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		if strings.HasPrefix(c.Text, "//go:linkname ") {
+			return true
+		}
+	}
+	return false
+}
+
+// assemblyNoteFor returns assemblyNote for a function packageAssemblyFuncs
+// found, and "" otherwise.
+func (r *markdownRenderer) assemblyNoteFor(decl *ast.FuncDecl) string {
+	if len(r.assembly) == 0 || decl == nil || r.fileset == nil {
+		return ""
+	}
+	if !r.assembly[directiveKey(r.fileset, decl)] {
+		return ""
+	}
+	return assemblyNote
+}
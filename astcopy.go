@@ -0,0 +1,64 @@
+package main
+
+import (
+	"go/ast"
+	"reflect"
+)
+
+// sharedASTNodes are the node types copyFiles leaves shared with the
+// original: identifiers, which type information is keyed by, and nodes
+// go/doc reads but never changes.
+var sharedASTNodes = map[reflect.Type]bool{
+	reflect.TypeOf((*ast.Ident)(nil)):        true,
+	reflect.TypeOf((*ast.Object)(nil)):       true,
+	reflect.TypeOf((*ast.Scope)(nil)):        true,
+	reflect.TypeOf((*ast.CommentGroup)(nil)): true,
+	reflect.TypeOf((*ast.BlockStmt)(nil)):    true,
+}
+
+// copyFiles returns copies of files for doc.NewFromFiles, which prunes the
+// declarations, bodies, and doc comments of the syntax it is given. The
+// loaded syntax is shared through the run's load cache, so every rendering
+// has to start from an untouched copy.
+func copyFiles(files []*ast.File) []*ast.File {
+	copies := make([]*ast.File, len(files))
+	for i, file := range files {
+		copies[i] = copyNode(reflect.ValueOf(file)).Interface().(*ast.File)
+	}
+	return copies
+}
+
+func copyNode(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || sharedASTNodes[v.Type()] {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyNode(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyNode(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(copyNode(v.Field(i)))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyNode(v.Index(i)))
+		}
+		return c
+	}
+	return v
+}
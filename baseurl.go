@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	linksRelative = "relative"
+	linksAbsolute = "absolute"
+)
+
+// absoluteLinks reports whether -relative-links=absolute asks for links to
+// other package documents as -base-url URLs.
+func absoluteLinks(opts options) bool {
+	return opts.relativeLinks == linksAbsolute
+}
+
+// docURL returns the hosted URL of the package document in relDir: -base-url
+// followed by the package's path in the docs tree, ending in a slash the way
+// site generators serve directory indexes.
+func docURL(opts options, relDir string) string {
+	base := strings.TrimSuffix(opts.baseURL, "/")
+	rel := strings.Trim(path.Clean("/"+strings.ReplaceAll(relDir, "\\", "/")), "/")
+	if rel == "" {
+		return base + "/"
+	}
+	return base + "/" + rel + "/"
+}
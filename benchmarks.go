@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// benchmarkFunc is a BenchmarkXxx function found in a package's test files.
+type benchmarkFunc struct {
+	name string
+	doc  string
+}
+
+// packageBenchmarks lists the benchmark functions declared in files, in
+// source order. Like go test, it accepts names of the form BenchmarkXxx where
+// Xxx does not start with a lower-case letter, taking a single *testing.B.
+func packageBenchmarks(files []*ast.File) []benchmarkFunc {
+	var benchmarks []benchmarkFunc
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isBenchmarkName(fn.Name.Name) || !takesTestingB(fn) {
+				continue
+			}
+			benchmarks = append(benchmarks, benchmarkFunc{name: fn.Name.Name, doc: fn.Doc.Text()})
+		}
+	}
+	return benchmarks
+}
+
+func isBenchmarkName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Benchmark")
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
+
+func takesTestingB(fn *ast.FuncDecl) bool {
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "B"
+}
+
+// renderBenchmarks writes the -benchmarks section: each benchmark's name
+// followed by its doc comment on one line.
+func (r *markdownRenderer) renderBenchmarks(w io.Writer) {
+	if len(r.benchmarks) == 0 {
+		return
+	}
+	r.heading(w, 2, "Benchmarks")
+	for _, b := range r.benchmarks {
+		fmt.Fprintln(w, bulletLine(b.name, strings.Join(strings.Fields(r.docText(b.doc)), " ")))
+	}
+	fmt.Fprintln(w)
+}
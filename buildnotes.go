@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch list the GOOS and GOARCH values the go command
+// recognizes in file name suffixes such as _linux.go or _windows_amd64.go.
+var (
+	knownOS = setOf("aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+		"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos")
+	knownArch = setOf("386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64", "mips",
+		"mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le", "riscv",
+		"riscv64", "s390", "s390x", "sparc", "sparc64", "wasm")
+)
+
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// renderBuildNote writes, for -build-notes, which builds include the file
+// that declares node: "*Available on: darwin*". Declarations from
+// unconstrained files render nothing.
+func (r *markdownRenderer) renderBuildNote(w io.Writer, node ast.Node) {
+	if !r.options.buildNotes || r.fileset == nil || node == nil {
+		return
+	}
+	file := r.fileset.File(node.Pos())
+	if file == nil {
+		return
+	}
+	if r.buildConstraints == nil {
+		r.buildConstraints = make(map[string]string)
+	}
+	note, ok := r.buildConstraints[file.Name()]
+	if !ok {
+		note = buildConstraintText(file.Name())
+		r.buildConstraints[file.Name()] = note
+	}
+	if note != "" {
+		fmt.Fprintf(w, "*Available on: %s*\n\n", note)
+	}
+}
+
+// buildConstraintText describes the builds that include the file at path,
+// combining its name suffix and its //go:build line. Alternatives of plain
+// tags read as a list ("darwin, linux"); anything else keeps the
+// expression syntax.
+func buildConstraintText(path string) string {
+	var exprs []constraint.Expr
+	if expr := fileNameConstraint(filepath.Base(path)); expr != nil {
+		exprs = append(exprs, expr)
+	}
+	if expr := fileBuildConstraint(path); expr != nil {
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 0 {
+		return ""
+	}
+	expr := exprs[0]
+	if len(exprs) == 2 {
+		expr = &constraint.AndExpr{X: exprs[0], Y: exprs[1]}
+	}
+	if tags, ok := orTags(expr); ok {
+		return strings.Join(tags, ", ")
+	}
+	return expr.String()
+}
+
+// fileNameConstraint returns the GOOS and GOARCH implied by a file name
+// suffix, following the go command's rules, or nil.
+func fileNameConstraint(name string) constraint.Expr {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	parts := strings.Split(name[i:], "_")
+	n := len(parts)
+	switch {
+	case n >= 3 && knownOS[parts[n-2]] && knownArch[parts[n-1]]:
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: parts[n-2]}, Y: &constraint.TagExpr{Tag: parts[n-1]}}
+	case knownOS[parts[n-1]] || knownArch[parts[n-1]]:
+		return &constraint.TagExpr{Tag: parts[n-1]}
+	}
+	return nil
+}
+
+// fileBuildConstraint parses the build constraint in the header of the file
+// at path, preferring //go:build over legacy // +build lines.
+func fileBuildConstraint(path string) constraint.Expr {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var plus []constraint.Expr
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "//") {
+			break
+		}
+		if constraint.IsGoBuild(line) {
+			if expr, err := constraint.Parse(line); err == nil {
+				return expr
+			}
+		}
+		if constraint.IsPlusBuild(line) {
+			if expr, err := constraint.Parse(line); err == nil {
+				plus = append(plus, expr)
+			}
+		}
+	}
+	if len(plus) == 0 {
+		return nil
+	}
+	expr := plus[0]
+	for _, next := range plus[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: next}
+	}
+	return expr
+}
+
+// orTags reports the tags of expr when it is a tag or an alternative of tags.
+func orTags(expr constraint.Expr) ([]string, bool) {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		return []string{e.Tag}, true
+	case *constraint.OrExpr:
+		x, ok := orTags(e.X)
+		if !ok {
+			return nil, false
+		}
+		y, ok := orTags(e.Y)
+		if !ok {
+			return nil, false
+		}
+		return append(x, y...), true
+	}
+	return nil, false
+}
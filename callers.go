@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// renderCallers lists, for -callers, the other functions and methods of the
+// package whose bodies refer to f. It only runs when a single symbol is
+// rendered with type information, so the walk stays one package wide.
+// Unexported callers, and methods of unexported types, are listed only
+// with -u.
+func (r *markdownRenderer) renderCallers(w io.Writer, f *doc.Func) {
+	if r.syntax == nil || r.typesInfo == nil || f.Decl == nil {
+		return
+	}
+	target, ok := r.typesInfo.Defs[f.Decl.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool)
+	var callers []string
+	for _, file := range r.syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Name == f.Decl.Name {
+				continue
+			}
+			if !refersTo(r.typesInfo, fn.Body, target) {
+				continue
+			}
+			obj, ok := r.typesInfo.Defs[fn.Name].(*types.Func)
+			if !ok || !r.options.unexported && !exportedCaller(obj) {
+				continue
+			}
+			if name := callerName(obj); !seen[name] {
+				seen[name] = true
+				callers = append(callers, name)
+			}
+		}
+	}
+	if len(callers) == 0 {
+		return
+	}
+	sort.Strings(callers)
+	r.heading(w, 5, "Used By")
+	for _, name := range callers {
+		fmt.Fprintf(w, "- `%s`\n", name)
+	}
+	fmt.Fprintln(w)
+}
+
+// refersTo reports whether body uses target, including through an
+// instantiation of a generic function or method.
+func refersTo(info *types.Info, body ast.Node, target *types.Func) bool {
+	var found bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok {
+			if fn, ok := info.Uses[id].(*types.Func); ok && fn.Origin() == target {
+				found = true
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// exportedCaller reports whether fn, and for a method its receiver type, is
+// exported.
+func exportedCaller(fn *types.Func) bool {
+	if !token.IsExported(fn.Name()) {
+		return false
+	}
+	name := callerName(fn)
+	typ, _, isMethod := strings.Cut(name, ".")
+	return !isMethod || token.IsExported(typ)
+}
+
+// callerName is how a caller is listed: Name, or Type.Name for methods.
+func callerName(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name() + "." + fn.Name()
+	}
+	return fn.Name()
+}
@@ -0,0 +1,18 @@
+//go:build cgo
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRunDocumentsCgoPackage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/cgo"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "`func Twice(v int) int` — Twice doubles v using a C helper.")
+	assertContains(t, out, "`type Buffer` — Buffer wraps memory allocated by C.")
+}
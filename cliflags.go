@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cliFlag is one command-line flag registration found by -cli-flags.
+type cliFlag struct {
+	name      string
+	shorthand string
+	value     ast.Expr
+	usage     ast.Expr
+}
+
+// flagValueKinds are the value kinds of the flag package and pflag whose
+// registration methods -cli-flags recognizes: Kind, KindVar, KindP, and
+// KindVarP.
+var flagValueKinds = map[string]bool{
+	"Bool": true, "Int": true, "Int8": true, "Int16": true, "Int32": true, "Int64": true,
+	"Uint": true, "Uint8": true, "Uint16": true, "Uint32": true, "Uint64": true,
+	"Float32": true, "Float64": true, "String": true, "Duration": true, "Text": true,
+	"Count": true, "IP": true, "IPMask": true, "IPNet": true, "BytesHex": true, "BytesBase64": true,
+	"StringSlice": true, "StringArray": true, "IntSlice": true, "Int64Slice": true, "UintSlice": true,
+	"BoolSlice": true, "Float64Slice": true, "DurationSlice": true, "StringToString": true, "StringToInt": true,
+}
+
+// packageCLIFlags scans a command's files for flag registrations such as
+// flag.String("name", "def", "usage") or flags.BoolVarP(&v, "name", "n",
+// false, "usage"). Without type information the receiver is not checked;
+// a call counts when its method name and argument shape match and the flag
+// name is a string literal. Flags are listed in registration order, first
+// registration winning.
+func packageCLIFlags(files []*ast.File) []cliFlag {
+	var flags []cliFlag
+	seen := make(map[string]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if f, ok := parseFlagCall(call); ok && !seen[f.name] {
+				seen[f.name] = true
+				flags = append(flags, f)
+			}
+			return true
+		})
+	}
+	return flags
+}
+
+func parseFlagCall(call *ast.CallExpr) (cliFlag, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return cliFlag{}, false
+	}
+	var (
+		nameArg, shortArg, valueArg, usageArg = -1, -1, -1, -1
+		want                                  int
+	)
+	switch method := sel.Sel.Name; method {
+	case "Func", "BoolFunc":
+		nameArg, usageArg, want = 0, 1, 3
+	case "Var":
+		nameArg, usageArg, want = 1, 2, 3
+	default:
+		kind, isVar, short := flagMethodKind(method)
+		if kind == "" {
+			return cliFlag{}, false
+		}
+		if isVar {
+			want++
+		}
+		nameArg = want
+		want++
+		if short {
+			shortArg = want
+			want++
+		}
+		if kind != "Count" {
+			valueArg = want
+			want++
+		}
+		usageArg = want
+		want++
+	}
+	if len(call.Args) != want {
+		return cliFlag{}, false
+	}
+	name, ok := stringLiteral(call.Args[nameArg])
+	if !ok || name == "" {
+		return cliFlag{}, false
+	}
+	f := cliFlag{name: name, usage: call.Args[usageArg]}
+	if shortArg >= 0 {
+		f.shorthand, _ = stringLiteral(call.Args[shortArg])
+	}
+	if valueArg >= 0 {
+		f.value = call.Args[valueArg]
+	}
+	return f, true
+}
+
+// flagMethodKind splits a registration method name such as StringVarP into
+// its value kind and whether it takes a pointer and a shorthand. It returns
+// an empty kind for other methods; IP and IPVar are not mistaken for a
+// shorthand form of I.
+func flagMethodKind(method string) (kind string, isVar, short bool) {
+	for _, cut := range []bool{true, false} {
+		kind, short = method, false
+		if cut {
+			kind, short = strings.CutSuffix(kind, "P")
+		}
+		kind, isVar = strings.CutSuffix(kind, "Var")
+		if flagValueKinds[kind] {
+			return kind, isVar, short
+		}
+	}
+	return "", false, false
+}
+
+// stringLiteral returns the value of a string literal or a concatenation of
+// string literals.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := stringLiteral(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := stringLiteral(e.Y)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return stringLiteral(e.X)
+	}
+	return "", false
+}
+
+// renderCLIFlags writes the -cli-flags table of a command's flags. Defaults
+// are shown as written in the source; usage strings that are not literals are
+// shown as code.
+func (r *markdownRenderer) renderCLIFlags(w io.Writer) {
+	if len(r.cliFlags) == 0 {
+		return
+	}
+	r.heading(w, 2, "Flags")
+	fmt.Fprintln(w, "| Name | Default | Usage |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, f := range r.cliFlags {
+		name := fmt.Sprintf("`-%s`", f.name)
+		if f.shorthand != "" {
+			name += fmt.Sprintf(", `-%s`", f.shorthand)
+		}
+		def := ""
+		if f.value != nil {
+			def = "`" + escapeTableCell(r.formatNode(f.value)) + "`"
+		}
+		usage, ok := stringLiteral(f.usage)
+		if ok {
+			usage = escapeTableCell(strings.Join(strings.Fields(usage), " "))
+		} else {
+			usage = "`" + escapeTableCell(r.formatNode(f.usage)) + "`"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", name, def, usage)
+	}
+	fmt.Fprintln(w)
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	cobradoc "github.com/spf13/cobra/doc"
+	"golang.org/x/tools/go/packages"
 )
 
 const rootLongDesc = `
@@ -24,8 +29,8 @@ Use go run ./go-docmd just like go doc, or install the binary and enjoy autocomp
 CLI docs generation in your release workflows.
 `
 
-func newRootCmd(stdout io.Writer) *cobra.Command {
-	app := &cliApp{stdout: stdout}
+func newRootCmd(stdout, stderr io.Writer) *cobra.Command {
+	app := &cliApp{stdout: stdout, stderr: stderr}
 	cmd := &cobra.Command{
 		Use:           "go-docmd [flags] [package|[package.]symbol[.method]]",
 		Short:         "Render Go documentation as Markdown",
@@ -35,11 +40,15 @@ func newRootCmd(stdout io.Writer) *cobra.Command {
 		SilenceErrors: true,
 	}
 	cmd.DisableAutoGenTag = true
-	cmd.Version = Version
+	cmd.Version = resolveVersion()
 	cmd.SetOut(stdout)
 	cmd.SetErr(io.Discard)
 	cmd.CompletionOptions.DisableDefaultCmd = true
 
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return withKind(kindUsage, "", err)
+	})
+
 	flags := cmd.Flags()
 	flags.BoolVar(&app.opts.all, "all", false, "show all documentation for package")
 	flags.BoolVarP(&app.opts.caseSensitive, "case-sensitive", "c", false, "symbol matching honors case (paths not affected)")
@@ -51,20 +60,169 @@ func newRootCmd(stdout io.Writer) *cobra.Command {
 	flags.BoolVar(&app.opts.inplace, "inplace", false, "write README.md directly into package directories (overwrites existing files)")
 	flags.BoolVar(&app.opts.includeMainVars, "mainvars", false, "include variable listings in package main output")
 	flags.BoolVar(&app.opts.includeMainFuncs, "mainfuncs", false, "include function listings in package main output")
+	flags.IntVar(&app.opts.headingOffset, "heading-offset", 0, "shift every emitted heading down by N levels (clamped at ######)")
+	flags.BoolVar(&app.opts.lintUndocumented, "lint-undocumented", false, "report exported symbols without doc comments and exit non-zero")
+	flags.BoolVar(&app.opts.moduleRoot, "module-root", false, "anchor tree output paths and TOC links at the module root instead of the package argument")
+	flags.BoolVar(&app.opts.timing, "timing", false, "print a load/render/write timing breakdown to stderr in directory and in-place modes")
+	flags.StringVar(&app.opts.fenceLang, "fence-lang", "", "fence language for doc comment code blocks (default: inferred; \"none\" omits it)")
+	flags.BoolVar(&app.opts.allMatches, "all-matches", false, "render every package that matches the argument instead of only the first")
+	flags.StringVar(&app.opts.maxGoVersion, "max-go-version", "", "omit symbols whose availableSince: directive is newer than this Go version (e.g. go1.18)")
+	flags.StringVar(&app.opts.anchorFormat, "anchor-format", anchorGitHub, "slug rules for in-document anchors: github, gitlab, or bitbucket")
+	flags.BoolVar(&app.opts.enumTable, "enum-table", false, "render typed iota constant blocks as Name/Value/Description tables")
+	flags.StringVar(&app.opts.indexName, "index-name", "README", "base name of the per-package file written in directory and in-place modes")
+	flags.StringVar(&app.opts.outExt, "out-ext", "md", "extension of the per-package file written in directory and in-place modes")
+	flags.BoolVar(&app.opts.excludeUnexportedFields, "exclude-unexported-fields", false, "hide unexported struct fields even with -u or -all")
+	flags.StringVar(&app.opts.only, "only", "", "comma-separated sections to render in package output: types,funcs,consts,vars")
+	flags.BoolVar(&app.opts.apiSchema, "apischema", false, "in tree modes, write JSON Schemas for +apischema types to a schemas/ directory per package")
+	flags.BoolVar(&app.opts.strictLinks, "strict-links", false, "fail when a [Symbol] doc link names a local symbol that does not exist")
+	flags.BoolVar(&app.opts.markGenerated, "mark-generated", false, "start every generated Markdown file with a DO NOT EDIT marker and refuse to overwrite files without it")
+	flags.BoolVar(&app.opts.force, "force", false, "with -mark-generated, overwrite files that lack the generated marker")
+	flags.BoolVar(&app.opts.navJSON, "nav-json", false, "in directory, archive, and in-place modes, also write a nav.json package tree for docs portals")
+	flags.StringVar(&app.opts.templateDir, "template-dir", "", "directory of optional package.tmpl, type.tmpl, func.tmpl, and value.tmpl overrides")
+	flags.BoolVar(&app.opts.numberHeadings, "number-headings", false, "prefix each heading below the title with a hierarchical section number (1., 1.1., 2., ...)")
+	flags.StringVar(&app.opts.summaryFormat, "summary-format", summaryBullets, "layout of the package symbol summary: bullets, or table for Symbol/Kind/Description columns")
+	flags.StringVar(&app.opts.errorFormat, "error-format", "text", "how a failure is reported on stderr: text, or json for one {\"error\",\"kind\",\"package\"} object")
+	flags.StringVar(&app.opts.readmeName, "readme-name", "", "file name, such as DOCS.md, of the per-package file written in directory and in-place modes; shorthand for -index-name and -out-ext")
+	flags.BoolVar(&app.opts.compact, "compact", false, "drop the blank lines Markdown does not need, between list items and around headings")
+	flags.BoolVar(&app.opts.allowNetwork, "allow-network", false, "fetch packages outside the current module's dependencies through the module proxy")
+	flags.IntVar(&app.opts.maxCodeLine, "max-code-line", 0, "warn when a rendered declaration has a code line wider than N columns (0 for no limit)")
+	flags.BoolVar(&app.opts.groupPackagesByDir, "group-packages-by-dir", false, "nest the generated package list by directory instead of listing it flat")
+	flags.BoolVar(&app.opts.hoistExample, "hoist-example", false, "repeat the first code block of the package doc in a Quick Start section after the title")
+	flags.BoolVar(&app.opts.excludeDeprecated, "exclude-deprecated", false, "leave out symbols whose doc comment has a Deprecated: paragraph")
+	flags.BoolVar(&app.opts.showDeprecatedList, "show-deprecated-list", false, "with -exclude-deprecated, list the left-out symbols in a collapsed block at the end")
+	flags.BoolVar(&app.opts.cliFlags, "cli-flags", false, "for package main, add a Flags table of the flag and pflag registrations found in the source")
+	flags.BoolVar(&app.opts.skipEmpty, "skip-empty", false, "in tree modes, leave out library packages that export no symbols")
+	flags.StringVar(&app.opts.sortTypes, "sort-types", "", "order type sections by name, source (declaration order), or methods (most first); default keeps go/doc's order")
+	flags.BoolVar(&app.opts.showProgress, "progress", false, "in tree modes, report rendered packages on stderr as [done/total] path")
+	flags.BoolVar(&app.opts.includeIgnored, "include-ignored", false, "document the package's //go:build ignore programs, such as generators, under a Tools section")
+	flags.BoolVar(&app.opts.validateLinks, "validate-links", false, "after writing a tree, fail on relative links in generated files whose targets do not exist")
+	flags.StringVar(&app.opts.sentenceTerminators, "sentence-terminators", defaultSentenceTerminators, "characters that end the first sentence used as a summary; ASCII ones need a space or a capitalized word around them")
+	flags.BoolVar(&app.opts.signatureLinks, "signature-links", false, "under each function signature, link the package's types and constants it mentions")
+	flags.BoolVar(&app.opts.noSignatures, "no-signatures", false, "render headings and doc prose without declaration and signature code blocks")
+	flags.BoolVar(&app.opts.useExportData, "use-export-data", false, "load only what the requested output needs, type checking against export data only when a feature uses types")
+	flags.BoolVar(&app.opts.coverageBadges, "coverage-badges", false, "show each package's share of documented exported symbols in generated package lists")
+	flags.IntVar(&app.opts.maxMethods, "max-methods", 0, "render at most N methods per type in full and list the rest by signature (0 for no limit)")
+	flags.BoolVar(&app.opts.callers, "callers", false, "when rendering a single function or method, list the package's functions that refer to it")
+	flags.BoolVar(&app.opts.refLinks, "ref-links", false, "write links as numbered reference links with their URLs collected at the end of each document")
+	flags.BoolVar(&app.opts.noTOC, "no-toc", false, "in directory and in-place modes, do not append the package list to the root README")
+	flags.BoolVar(&app.opts.flattenInterfaces, "flatten-interfaces", false, "list the full method set of interfaces that embed others, noting where each method comes from")
+	flags.IntVar(&app.opts.tocSummaryLen, "toc-summary-len", 0, "truncate package summaries in generated package lists to this many characters at a word boundary (0 keeps them whole)")
+	flags.BoolVar(&app.opts.noNetwork, "no-network", false, "never contact network services; disables -playground-links")
+	flags.BoolVar(&app.opts.playgroundLinks, "playground-links", false, "share runnable examples on the Go Playground and link each one")
+	flags.BoolVar(&app.opts.goVersionNote, "go-version-note", false, "note the module's go directive under the title of its root package")
+	flags.StringArrayVar(&app.opts.excludeSymbols, "exclude-symbol", nil, "leave out symbols matching this name or glob (Type.Method for methods); repeatable")
+	flags.BoolVar(&app.opts.platformMatrix, "platform-matrix", false, "with -goos, append a table marking which platforms declare each exported symbol")
+	flags.StringVar(&app.opts.defaultPrefix, "default-prefix", "default:", "struct field comment line that declares the field's default value (empty to disable)")
+	flags.BoolVar(&app.opts.flattenSinglePackage, "flatten-single-package", false, "when a tree holds one package, write its document at the output root without a package list")
+	flags.BoolVar(&app.opts.alias, "alias", false, "list type aliases in an Aliases table instead of giving each a type section")
+	flags.BoolVar(&app.opts.embedSource, "embed-source", false, "in tree modes, append each package's Go source in a collapsed Source block")
+	flags.StringVar(&app.opts.outputEncoding, "output-encoding", "UTF-8", "character encoding of the written Markdown, such as Shift_JIS or ISO-8859-1")
+	flags.StringVar(&app.opts.onUnmappable, "on-unmappable", unmappableError, "what to do with characters -output-encoding cannot represent: error or replace")
+	flags.BoolVar(&app.opts.sortImportsGrouped, "sort-imports-grouped", false, "with -deps, split imports into standard library, same-module, and third-party groups")
+	flags.BoolVar(&app.opts.buildNotes, "build-notes", false, "note the build constraints of the file declaring each symbol")
+	flags.DurationVar(&app.opts.timeout, "timeout", 0, "fail if loading packages takes longer than this duration (0 means no limit)")
+	flags.BoolVar(&app.opts.embedExamples, "embed-examples", false, "inline //go:embed files that a doc comment mentions by name as code blocks")
+	flags.StringVar(&app.opts.format, "format", formatMarkdown, "output format: markdown, or confluence for Confluence storage format (XHTML)")
+	flags.BoolVar(&app.opts.stripCopyright, "strip-copyright", false, "with -src, drop a leading copyright or license comment from declaration source")
+	flags.BoolVar(&app.opts.glossary, "glossary", false, "append an alphabetical Glossary of exported symbols and their summaries")
+	flags.StringVar(&app.opts.admonitions, "admonitions", defaultAdmonitions, "comma-separated paragraph keywords (note, tip, important, warning, caution) rendered as GitHub alerts; empty disables")
+	flags.BoolVar(&app.opts.failOnWarning, "fail-on-warning", false, "exit non-zero when the run reports any warning")
+	flags.BoolVar(&app.opts.benchmarks, "benchmarks", false, "add a Benchmarks section listing the benchmark functions in the package's test files")
+	flags.BoolVar(&app.opts.summaryOnly, "summary-only", false, "in directory and in-place modes, write only the root index of packages and their summaries")
+	flags.StringVar(&app.opts.pkgHeadingFormat, "pkg-heading-format", defaultPkgHeadingFormat, "format of the package title line; {name}, {import} and {module} are replaced")
+	flags.StringVar(&app.opts.addedSince, "added-since", "", "add an \"Added since TAG\" section listing exported top-level symbols missing at this git tag or ref")
+	flags.BoolVar(&app.opts.deps, "deps", false, "add an Imports section listing each package's direct imports, and an external dependency summary to tree roots")
+	flags.IntVar(&app.opts.wrap, "wrap", 0, "hard-wrap doc comment prose at this column (0 disables wrapping)")
+	flags.StringVar(&app.opts.relativeLinks, "relative-links", linksRelative, "how links between package documents are written: relative paths, or absolute URLs under -base-url")
+	flags.StringVar(&app.opts.baseURL, "base-url", "", "URL prefix for -relative-links=absolute, such as https://docs.example.com/api")
+	flags.BoolVar(&app.opts.methodIndex, "method-index", false, "list each type's method signatures in one code block before the detailed methods")
+	flags.BoolVar(&app.opts.index, "index", false, "replace the symbol summary with a pkg.go.dev-style Index grouped by kind")
+	flags.StringVar(&app.opts.rawDoc, "raw-doc", "", "comma-separated import paths (a trailing /... matches subpackages) whose doc comments are emitted verbatim as Markdown")
+	flags.BoolVar(&app.opts.listPackages, "list-packages", false, "print the import path, directory, and kind of each package a pattern expands to, then exit")
+	flags.BoolVar(&app.opts.varTable, "vartable", false, "render package-level variables as a Name/Type/Description table")
+	flags.BoolVar(&app.opts.dryRun, "dry-run", false, "print each file that would be created or overwritten instead of writing it")
+	flags.BoolVar(&app.opts.maxDepthHeadings, "max-depth-headings", false, "render headings nested deeper than ###### as bold lines instead of clamping them")
+	flags.StringVar(&app.opts.color, "color", colorAuto, "preview stdout as ANSI-colored terminal text: auto (when stdout is a terminal and NO_COLOR is unset), always, or never")
+	flags.Lookup("color").NoOptDefVal = colorAuto
+	flags.BoolVar(&app.opts.noColor, "no-color", false, "never colorize output, overriding -color")
+	flags.BoolVar(&app.opts.externalDocLinks, "external-doc-links", false, "render doc links into other packages, such as [io.Reader], as pkg.go.dev links")
+	flags.StringVar(&app.opts.stdlibVersion, "stdlib-version", stdlibLatest, "pin standard library doc link URLs to a Go version: latest, module, or e.g. go1.22")
+	flags.BoolVar(&app.opts.noEmptySections, "no-empty-sections", false, "omit headings left without content by filtering")
+	flags.StringVar(&app.opts.goos, "goos", "", "comma-separated GOOS values to document one package for, grouping platform-only symbols")
+	flags.StringVar(&app.opts.diffBase, "diff-base", "", "document only symbols declared in .go files changed relative to this git ref")
+	flags.BoolVar(&app.opts.noteInterfaces, "note-interfaces", false, "note when a method's signature satisfies a well-known standard interface such as fmt.Stringer")
+	flags.BoolVar(&app.opts.noImportLine, "no-import-line", false, "omit the import statement below the package title")
+	flags.BoolVar(&app.opts.paramDocs, "param-docs", false, "render \"name: description\" doc lines for function parameters and named results as tables")
+	flags.BoolVar(&app.opts.implements, "implements", false, "list implementing types under interfaces and satisfied interfaces under concrete types")
+	flags.StringVar(&app.opts.header, "header", "", "prepend this file's contents (or, if no such file exists, the text itself) to every generated document")
+	flags.BoolVar(&app.opts.noRecurse, "no-recurse", false, "in directory and in-place modes, document only the named package instead of its whole subtree")
+	flags.StringVar(&app.opts.stabilityStyle, "stability-style", stabilityText, "render a package's +stability: directive as text, badge, or none")
+	flags.BoolVar(&app.opts.examplesFile, "examples-file", false, "in directory and in-place modes, move examples into a sibling EXAMPLES.md linked from each README")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 		if ctx == nil {
 			ctx = context.Background()
 		}
+		app.opts.indexNameSet = cmd.Flags().Changed("index-name")
+		app.opts.outExtSet = cmd.Flags().Changed("out-ext")
+		app.opts.colorSet = cmd.Flags().Changed("color")
 		return app.execute(ctx, args)
 	}
+	cmd.ValidArgsFunction = completePackageArgs
 
 	cmd.AddCommand(newCompletionCmd(cmd))
 	cmd.AddCommand(newDocsCmd(cmd))
+	cmd.AddCommand(newVersionCmd(cmd))
+	cmd.AddCommand(newInspectCmd(cmd))
 	return cmd
 }
 
+const maxPackageCompletions = 50
+
+// packageCompleteTimeout bounds the package load behind completion; tests
+// replace it so they do not race the wall clock.
+var packageCompleteTimeout = 2 * time.Second
+
+// completePackageArgs suggests import paths for the package argument. Local
+// paths and slow or failed loads fall back to directory completion.
+func completePackageArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if strings.HasPrefix(toComplete, ".") || filepath.IsAbs(toComplete) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, packageCompleteTimeout)
+	defer cancel()
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName,
+	}
+	pkgs, err := packages.Load(cfg, "std", "./...")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	var matches []string
+	for _, pkg := range pkgs {
+		if strings.HasPrefix(pkg.PkgPath, toComplete) {
+			matches = append(matches, pkg.PkgPath)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	sort.Strings(matches)
+	if len(matches) > maxPackageCompletions {
+		matches = matches[:maxPackageCompletions]
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
 func newCompletionCmd(root *cobra.Command) *cobra.Command {
 	const (
 		longDesc = `Generate shell completion scripts for go-docmd.
@@ -138,3 +296,26 @@ Example:
 	}
 	return cmd
 }
+
+func newVersionCmd(root *cobra.Command) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:           "version",
+		Short:         "Print the go-docmd version",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print version details as JSON")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		info := currentVersionInfo()
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+		_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s version %s\n", root.Name(), info.Version)
+		return err
+	}
+	return cmd
+}
@@ -0,0 +1,37 @@
+package main
+
+import "strings"
+
+// codeTabWidth is the width a tab counts for in -max-code-line, gofmt's own.
+const codeTabWidth = 8
+
+// checkCodeLines warns, for -max-code-line, when a declaration block rendered
+// for symbol has a line wider than the limit. It reports the widest line once
+// per block.
+func (r *markdownRenderer) checkCodeLines(symbol, code string) {
+	limit := r.options.maxCodeLine
+	if limit <= 0 {
+		return
+	}
+	widest := 0
+	for _, line := range strings.Split(code, "\n") {
+		widest = max(widest, codeLineWidth(line))
+	}
+	if widest > limit {
+		r.options.warnings.warnf("%s: %s: code line is %d columns wide (-max-code-line %d)", r.pkg.ImportPath, symbol, widest, limit)
+	}
+}
+
+// codeLineWidth is the display width of line with tabs expanded to
+// codeTabWidth stops. Each rune counts as one column.
+func codeLineWidth(line string) int {
+	width := 0
+	for _, c := range line {
+		if c == '\t' {
+			width += codeTabWidth - width%codeTabWidth
+			continue
+		}
+		width++
+	}
+	return width
+}
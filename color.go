@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	colorNever  = "never"
+	colorAuto   = "auto"
+	colorAlways = "always"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiUnderline = "\x1b[4m"
+)
+
+// wantsColor reports whether -color applies to output written to w. -no-color
+// always wins; otherwise "always" forces color and "auto" colors only a
+// terminal, and only while the NO_COLOR environment variable is unset.
+func wantsColor(mode string, noColor bool, w io.Writer) bool {
+	if noColor {
+		return false
+	}
+	switch mode {
+	case colorAlways:
+		return true
+	case colorAuto:
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		f, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return false
+}
+
+// htmlTag matches an inline HTML tag or a one-line comment, such as the
+// anchors of -number-headings or the <details> wrapper of -source.
+var htmlTag = regexp.MustCompile(`^(?:<!--.*?-->|</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>)`)
+
+// colorizeMarkdown turns rendered Markdown into a terminal preview: heading
+// markers are dropped and headings bolded, code blocks and spans are dimmed,
+// and links show their underlined text in place of the URL. Backslash
+// escapes are undone and HTML is dropped, with lines that held nothing else.
+func colorizeMarkdown(md []byte) []byte {
+	lines := strings.Split(string(md), "\n")
+	out := make([]string, 0, len(lines))
+	inFence, inComment := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inComment:
+			inComment = !strings.Contains(line, "-->")
+			continue
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+			continue
+		case inFence:
+			out = append(out, "    "+ansiDim+line+ansiReset)
+			continue
+		case strings.HasPrefix(trimmed, "<!--") && !strings.Contains(trimmed, "-->"):
+			inComment = true
+			continue
+		}
+		var b strings.Builder
+		if level := headingLevel(line); level > 0 {
+			var title strings.Builder
+			terminalInline(&title, line[level:], []string{ansiBold})
+			b.WriteString(ansiBold + strings.TrimSpace(title.String()) + ansiReset)
+		} else {
+			terminalInline(&b, line, nil)
+		}
+		if trimmed != "" && strings.TrimSpace(b.String()) == "" {
+			continue
+		}
+		out = append(out, strings.TrimRight(b.String(), " "))
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// styled writes text, itself inline Markdown, under active plus style, then
+// resets and restores active, so closing a code span inside a link does not
+// end the link's underline.
+func styled(b *strings.Builder, active []string, style, text string) {
+	inner := append(active[:len(active):len(active)], style)
+	b.WriteString(style)
+	terminalInline(b, text, inner)
+	b.WriteString(ansiReset + strings.Join(active, ""))
+}
+
+// terminalInline writes one line of inline Markdown as terminal text: code
+// spans dimmed and kept verbatim, links reduced to their underlined text,
+// strong text bolded, escapes undone, and HTML tags dropped.
+func terminalInline(b *strings.Builder, line string, active []string) {
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case '\\':
+			if i+1 < len(line) && isASCIIPunct(line[i+1]) {
+				b.WriteByte(line[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			end := codeSpanEnd(line, i)
+			run := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+			if end == i+run {
+				b.WriteString(line[i:end])
+				i = end
+				continue
+			}
+			b.WriteString(ansiDim + line[i+run:end-run] + ansiReset + strings.Join(active, ""))
+			i = end
+			continue
+		case '*':
+			if strings.HasPrefix(line[i:], "**") {
+				if end := strings.Index(line[i+2:], "**"); end > 0 {
+					styled(b, active, ansiBold, line[i+2:i+2+end])
+					i += end + 4
+					continue
+				}
+			}
+		case '[':
+			textEnd := closingBracket(line, i)
+			if textEnd < 0 || textEnd+1 >= len(line) {
+				break
+			}
+			end := -1
+			switch line[textEnd+1] {
+			case '(':
+				end = closingParen(line, textEnd+1)
+			case '[':
+				end = closingBracket(line, textEnd+1)
+			}
+			if end < 0 {
+				break
+			}
+			styled(b, active, ansiUnderline, line[i+1:textEnd])
+			i = end + 1
+			continue
+		case '<':
+			if tag := htmlTag.FindString(line[i:]); tag != "" {
+				i += len(tag)
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+		i++
+	}
+}
+
+// isASCIIPunct reports whether c is ASCII punctuation, the characters a
+// Markdown backslash escape applies to.
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import "strings"
+
+// compactMarkdown tightens a rendered document for -compact. Runs of blank
+// lines collapse to one, and the blank lines between consecutive list items
+// and around ATX headings go, since neither needs one to stay separate. Every
+// other blank line stays: paragraphs, fences, tables, and HTML blocks rely on
+// them. Fenced code is copied untouched.
+func compactMarkdown(md []byte) []byte {
+	lines := strings.Split(string(md), "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, "`") == "" {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "`"))]
+			out = append(out, line)
+			continue
+		}
+		if trimmed != "" {
+			out = append(out, line)
+			continue
+		}
+		if len(out) == 0 || strings.TrimSpace(out[len(out)-1]) == "" {
+			continue
+		}
+		prev := out[len(out)-1]
+		next := ""
+		for _, l := range lines[i+1:] {
+			if strings.TrimSpace(l) != "" {
+				next = l
+				break
+			}
+		}
+		switch {
+		case next == "":
+			// Keep the document's final newline.
+		case isListItem(prev) && isListItem(next):
+			continue
+		case headingLevel(prev) > 0:
+			continue
+		case headingLevel(next) > 0 && !strings.HasPrefix(strings.TrimSpace(prev), "<"):
+			continue
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// isListItem reports whether line starts a bullet or numbered list item.
+func isListItem(line string) bool {
+	line = strings.TrimLeft(line, " ")
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") {
+		return true
+	}
+	digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+	return digits > 0 && digits < 10 && (strings.HasPrefix(line[digits:], ". ") || strings.HasPrefix(line[digits:], ") "))
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	formatMarkdown   = "markdown"
+	formatConfluence = "confluence"
+)
+
+// confluenceAlerts maps GitHub alert types (see -admonitions) to the
+// Confluence macros that render the same kind of call-out.
+var confluenceAlerts = map[string]string{
+	"NOTE":      "info",
+	"TIP":       "tip",
+	"IMPORTANT": "note",
+	"WARNING":   "warning",
+	"CAUTION":   "warning",
+}
+
+var (
+	cfHeading    = regexp.MustCompile(`^(#{1,6}) +(.*)$`)
+	cfListItem   = regexp.MustCompile(`^( *)([-*]|\d+\.) +(.*)$`)
+	cfTableRule  = regexp.MustCompile(`^\|?( *:?-+:? *\|)+ *:?-*:? *\|?$`)
+	cfAlert      = regexp.MustCompile(`^\[!([A-Z]+)\]$`)
+	cfCodeSpan   = regexp.MustCompile("`[^`]*`")
+	cfEscape     = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|<>])`)
+	cfAnchor     = regexp.MustCompile(`<a id="([^"]*)"></a>`)
+	cfLink       = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\[\]]*\])*)\]\(([^)\s]*)\)`)
+	cfStrong     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	cfEmphasis   = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	cfPlacemarks = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// markdownToConfluence converts the Markdown go-docmd renders into
+// Confluence storage format: headings become <hN>, fenced code becomes code
+// macros, GitHub alerts become info/tip/note/warning macros, and anchors
+// become anchor macros. HTML comments, such as the -mark-generated marker,
+// pass through unchanged.
+func markdownToConfluence(md []byte) []byte {
+	c := confluenceConverter{}
+	lines := strings.Split(strings.TrimRight(string(md), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			c.flush()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var body []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+				body = append(body, lines[i])
+			}
+			c.codeMacro(lang, strings.Join(body, "\n"))
+		case trimmed == "":
+			c.flush()
+		case strings.HasPrefix(trimmed, "<!--"):
+			c.flush()
+			c.out.WriteString(line + "\n")
+		case cfHeading.MatchString(line):
+			c.flush()
+			m := cfHeading.FindStringSubmatch(line)
+			fmt.Fprintf(&c.out, "<h%d>%s</h%d>\n", len(m[1]), confluenceInline(m[2]), len(m[1]))
+		case strings.HasPrefix(line, ">"):
+			if c.kind != "quote" {
+				c.flush()
+				c.kind = "quote"
+			}
+			c.lines = append(c.lines, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		case strings.HasPrefix(trimmed, "|"):
+			if c.kind != "table" {
+				c.flush()
+				c.kind = "table"
+			}
+			if !cfTableRule.MatchString(trimmed) {
+				c.lines = append(c.lines, trimmed)
+			}
+		case cfListItem.MatchString(line):
+			if c.kind != "list" {
+				c.flush()
+				c.kind = "list"
+			}
+			m := cfListItem.FindStringSubmatch(line)
+			c.listItem(len(m[1]), m[2] == "-" || m[2] == "*", m[3])
+		case c.kind == "list" && strings.HasPrefix(line, " "):
+			c.lines[len(c.lines)-1] += " " + trimmed
+		default:
+			if c.kind != "para" {
+				c.flush()
+				c.kind = "para"
+			}
+			c.lines = append(c.lines, line)
+		}
+	}
+	c.flush()
+	return []byte(c.out.String())
+}
+
+// confluenceConverter accumulates the block being converted: a paragraph,
+// blockquote, table, or list, named by kind.
+type confluenceConverter struct {
+	out   strings.Builder
+	kind  string
+	lines []string
+	// lists holds the element name (ul or ol) of each open list level; for a
+	// list, lines holds the pending text of each level's open item.
+	lists   []string
+	indents []int
+}
+
+// listItem opens an item indented by indent spaces: a sibling of the open
+// item at the same indentation, or the first item of a list nested in the
+// open item when indented further.
+func (c *confluenceConverter) listItem(indent int, bullet bool, text string) {
+	tag := "ol"
+	if bullet {
+		tag = "ul"
+	}
+	for len(c.indents) > 0 && c.indents[len(c.indents)-1] > indent {
+		c.closeListLevel()
+	}
+	if len(c.indents) > 0 && c.indents[len(c.indents)-1] == indent {
+		c.closeItem()
+	} else {
+		if len(c.lists) > 0 {
+			// The parent item's text precedes the nested list.
+			c.out.WriteString(confluenceInline(c.lines[len(c.lines)-1]))
+			c.lines[len(c.lines)-1] = ""
+		}
+		c.out.WriteString("<" + tag + ">")
+		c.lists = append(c.lists, tag)
+		c.indents = append(c.indents, indent)
+		c.lines = append(c.lines, "")
+	}
+	c.lines[len(c.lines)-1] = text
+	c.out.WriteString("<li>")
+}
+
+// closeItem writes the pending text of the innermost open item and ends it.
+func (c *confluenceConverter) closeItem() {
+	c.out.WriteString(confluenceInline(c.lines[len(c.lines)-1]) + "</li>")
+	c.lines[len(c.lines)-1] = ""
+}
+
+func (c *confluenceConverter) closeListLevel() {
+	c.closeItem()
+	c.out.WriteString("</" + c.lists[len(c.lists)-1] + ">")
+	c.lists = c.lists[:len(c.lists)-1]
+	c.indents = c.indents[:len(c.indents)-1]
+	c.lines = c.lines[:len(c.lines)-1]
+}
+
+func (c *confluenceConverter) flush() {
+	switch c.kind {
+	case "para":
+		c.out.WriteString("<p>" + confluenceLines(c.lines) + "</p>\n")
+	case "quote":
+		lines := c.lines
+		macro := ""
+		if m := cfAlert.FindStringSubmatch(lines[0]); m != nil {
+			macro = confluenceAlerts[m[1]]
+			lines = lines[1:]
+		}
+		body := "<p>" + confluenceLines(lines) + "</p>"
+		if macro != "" {
+			fmt.Fprintf(&c.out, "<ac:structured-macro ac:name=\"%s\"><ac:rich-text-body>%s</ac:rich-text-body></ac:structured-macro>\n", macro, body)
+		} else {
+			c.out.WriteString("<blockquote>" + body + "</blockquote>\n")
+		}
+	case "table":
+		c.out.WriteString("<table><tbody>")
+		for i, row := range c.lines {
+			cell := "td"
+			if i == 0 {
+				cell = "th"
+			}
+			c.out.WriteString("<tr>")
+			for _, text := range splitTableRow(row) {
+				fmt.Fprintf(&c.out, "<%s>%s</%s>", cell, confluenceInline(text), cell)
+			}
+			c.out.WriteString("</tr>")
+		}
+		c.out.WriteString("</tbody></table>\n")
+	case "list":
+		for len(c.lists) > 0 {
+			c.closeListLevel()
+		}
+		c.out.WriteString("\n")
+	}
+	c.kind = ""
+	c.lines = nil
+	c.lists = nil
+	c.indents = nil
+}
+
+func (c *confluenceConverter) codeMacro(lang, body string) {
+	c.out.WriteString(`<ac:structured-macro ac:name="code">`)
+	if lang != "" {
+		fmt.Fprintf(&c.out, `<ac:parameter ac:name="language">%s</ac:parameter>`, html.EscapeString(lang))
+	}
+	body = strings.ReplaceAll(body, "]]>", "]]]]><![CDATA[>")
+	c.out.WriteString("<ac:plain-text-body><![CDATA[" + body + "]]></ac:plain-text-body></ac:structured-macro>\n")
+}
+
+// confluenceLines joins the lines of a paragraph, turning a trailing
+// backslash into a line break.
+func confluenceLines(lines []string) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.HasSuffix(line, "\\") && i < len(lines)-1 {
+			parts[i] = confluenceInline(strings.TrimSuffix(line, "\\")) + "<br />"
+			continue
+		}
+		parts[i] = confluenceInline(line)
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitTableRow splits a Markdown table row on the pipes that are not
+// escaped, dropping the outer ones.
+func splitTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(row), "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// confluenceInline converts inline Markdown: code spans, backslash escapes,
+// anchors, links, and strong or emphasized text. Everything else is escaped
+// as XHTML text.
+func confluenceInline(text string) string {
+	var held []string
+	hold := func(s string) string {
+		held = append(held, s)
+		return fmt.Sprintf("\x00%d\x00", len(held)-1)
+	}
+	text = cfCodeSpan.ReplaceAllStringFunc(text, func(span string) string {
+		return hold("<code>" + html.EscapeString(strings.Trim(span, "`")) + "</code>")
+	})
+	text = cfEscape.ReplaceAllStringFunc(text, func(esc string) string {
+		return hold(html.EscapeString(esc[1:]))
+	})
+	text = cfAnchor.ReplaceAllStringFunc(text, func(a string) string {
+		id := cfAnchor.FindStringSubmatch(a)[1]
+		return hold(`<ac:structured-macro ac:name="anchor"><ac:parameter ac:name="">` + html.EscapeString(id) + `</ac:parameter></ac:structured-macro>`)
+	})
+	text = html.EscapeString(text)
+	text = cfLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = cfStrong.ReplaceAllString(text, `<strong>$1</strong>`)
+	text = cfEmphasis.ReplaceAllString(text, `<em>$1</em>`)
+	// Held fragments can nest, such as a code span inside link text, so
+	// restore until none remain.
+	for cfPlacemarks.MatchString(text) {
+		text = cfPlacemarks.ReplaceAllStringFunc(text, func(p string) string {
+			var n int
+			fmt.Sscanf(strings.Trim(p, "\x00"), "%d", &n)
+			return held[n]
+		})
+	}
+	return text
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// declSource formats decl for -src, dropping a leading copyright or license
+// comment when -strip-copyright is set.
+func (r *markdownRenderer) declSource(decl ast.Node) string {
+	src := r.formatNode(decl)
+	if r.options.stripCopyright {
+		src = stripCopyright(src)
+	}
+	return src
+}
+
+// stripCopyright removes the first comment paragraph of src when it mentions
+// a copyright or license: either a /* */ block at the very top, or the
+// leading // lines up to the first empty // line (or up to the code when no
+// empty line separates them). The rest of the comment and the code stay.
+func stripCopyright(src string) string {
+	lines := strings.Split(src, "\n")
+	end := 0
+	switch {
+	case strings.HasPrefix(lines[0], "/*"):
+		for end < len(lines) && !strings.Contains(lines[end], "*/") {
+			end++
+		}
+		if end == len(lines) {
+			return src
+		}
+		end++
+	case strings.HasPrefix(lines[0], "//"):
+		for end < len(lines) && strings.HasPrefix(lines[end], "//") && strings.TrimSpace(lines[end]) != "//" {
+			end++
+		}
+	default:
+		return src
+	}
+	if !mentionsCopyright(lines[:end]) {
+		return src
+	}
+	for end < len(lines) && (strings.TrimSpace(lines[end]) == "//" || strings.TrimSpace(lines[end]) == "") {
+		end++
+	}
+	return strings.Join(lines[end:], "\n")
+}
+
+func mentionsCopyright(lines []string) bool {
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "copyright") || strings.Contains(lower, "license") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"strings"
+)
+
+// isDeprecated reports whether a doc comment has a paragraph starting with
+// "Deprecated: ", the convention go/doc and gopls recognize.
+func isDeprecated(text string) bool {
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if strings.HasPrefix(strings.TrimLeft(para, "\n"), "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeDeprecated removes deprecated symbols from pkg for
+// -exclude-deprecated and returns their names. A deprecated type takes its
+// constructors and methods with it.
+func excludeDeprecated(pkg *doc.Package) []string {
+	var dropped []string
+	keep := func(name, text string) bool {
+		if isDeprecated(text) {
+			dropped = append(dropped, name)
+			return false
+		}
+		return true
+	}
+	pkg.Consts = filterValues(pkg.Consts, keep)
+	pkg.Vars = filterValues(pkg.Vars, keep)
+	pkg.Funcs = filterFuncs(pkg.Funcs, "", keep)
+	types := pkg.Types[:0]
+	for _, t := range pkg.Types {
+		if !keep(t.Name, t.Doc) {
+			continue
+		}
+		t.Consts = filterValues(t.Consts, keep)
+		t.Vars = filterValues(t.Vars, keep)
+		t.Funcs = filterFuncs(t.Funcs, "", keep)
+		t.Methods = filterFuncs(t.Methods, t.Name, keep)
+		types = append(types, t)
+	}
+	pkg.Types = types
+	return dropped
+}
+
+// renderDeprecatedList writes the -show-deprecated-list block naming the
+// symbols -exclude-deprecated left out, collapsed so it stays out of the way.
+func (r *markdownRenderer) renderDeprecatedList(w io.Writer) {
+	if !r.options.showDeprecatedList || len(r.deprecated) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "<details><summary>Deprecated (%d)</summary>\n\n", len(r.deprecated))
+	for _, name := range r.deprecated {
+		fmt.Fprintf(w, "- `%s`\n", name)
+	}
+	fmt.Fprint(w, "\n</details>\n\n")
+}
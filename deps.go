@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packageImports returns the sorted direct imports of pkgInfo, leaving out
+// the cgo pseudo-package "C".
+func packageImports(pkgInfo *packages.Package) []string {
+	imports := make([]string, 0, len(pkgInfo.Imports))
+	for path := range pkgInfo.Imports {
+		if path != "C" {
+			imports = append(imports, path)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// renderImports writes the package's direct imports, standard library first
+// and external packages after, each linked to pkg.go.dev. With
+// -sort-imports-grouped, imports from the package's own module form a
+// group of their own between the two, as goimports groups them.
+func (r *markdownRenderer) renderImports(w io.Writer) {
+	var std, module, external []string
+	for _, path := range r.imports {
+		switch {
+		case isStdImportPath(path):
+			std = append(std, path)
+		case r.options.sortImportsGrouped && r.modulePath != "" &&
+			(path == r.modulePath || strings.HasPrefix(path, r.modulePath+"/")):
+			module = append(module, path)
+		default:
+			external = append(external, path)
+		}
+	}
+	if len(std) == 0 && len(module) == 0 && len(external) == 0 {
+		return
+	}
+	r.heading(w, 2, "Imports")
+	type importGroup struct {
+		title string
+		paths []string
+	}
+	groups := []importGroup{{"Standard library", std}, {"External", external}}
+	if r.options.sortImportsGrouped {
+		groups = []importGroup{{"Standard library", std}, {"Same module", module}, {"Third party", external}}
+	}
+	for _, group := range groups {
+		if len(group.paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n\n", group.title)
+		for _, path := range group.paths {
+			fmt.Fprintf(w, "- [%s](%s)\n", path, r.pkgGoDevURL(path))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (r *markdownRenderer) pkgGoDevURL(path string) string {
+	url := "https://pkg.go.dev/" + path
+	if version := r.stdlibVersion(); version != "" && isStdImportPath(path) {
+		url += "@" + version
+	}
+	return url
+}
+
+// dependencySummary lists, for a tree's root README, every external package
+// imported from outside the tree along with how many of its packages use it.
+func dependencySummary(docs []treeDoc, opts options) []byte {
+	inTree := func(path string) bool {
+		for _, doc := range docs {
+			if path == doc.pkgPath || strings.HasPrefix(path, doc.pkgPath+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	users := make(map[string]int)
+	for _, doc := range docs {
+		for _, path := range doc.imports {
+			if !isStdImportPath(path) && !inTree(path) {
+				users[path]++
+			}
+		}
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(users))
+	for path := range users {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var buf bytes.Buffer
+	buf.WriteString(headingText(2, opts, "External Dependencies"))
+	for _, path := range paths {
+		noun := "packages"
+		if users[path] == 1 {
+			noun = "package"
+		}
+		fmt.Fprintf(&buf, "- [%s](https://pkg.go.dev/%s) — imported by %d %s\n", path, path, users[path], noun)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/doc"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// changedGoFiles returns the absolute paths of the .go files that differ
+// between the working tree and ref, as reported by git diff.
+func changedGoFiles(ctx context.Context, ref string) (map[string]bool, error) {
+	top, err := gitOutput(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(top)
+	out, err := gitOutput(ctx, "diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasSuffix(line, ".go") {
+			continue
+		}
+		changed[canonicalPath(filepath.Join(root, filepath.FromSlash(line)))] = true
+	}
+	return changed, nil
+}
+
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// canonicalPath resolves symlinks so paths from git and from go/packages
+// compare equal.
+func canonicalPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// filterChangedSymbols keeps only the symbols of pkg declared in one of the
+// changed files. A type declared elsewhere survives, with only its changed
+// members, when any of its methods or associated declarations changed.
+func filterChangedSymbols(pkg *doc.Package, fset *token.FileSet, changed map[string]bool) {
+	files := make(map[string]bool)
+	inChanged := func(pos token.Pos) bool {
+		file := fset.File(pos)
+		if file == nil {
+			return false
+		}
+		name := file.Name()
+		keep, ok := files[name]
+		if !ok {
+			keep = changed[canonicalPath(name)]
+			files[name] = keep
+		}
+		return keep
+	}
+	keepValues := func(values []*doc.Value) []*doc.Value {
+		kept := values[:0]
+		for _, v := range values {
+			if inChanged(v.Decl.Pos()) {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	}
+	keepFuncs := func(funcs []*doc.Func) []*doc.Func {
+		kept := funcs[:0]
+		for _, f := range funcs {
+			if inChanged(f.Decl.Pos()) {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	}
+	pkg.Consts = keepValues(pkg.Consts)
+	pkg.Vars = keepValues(pkg.Vars)
+	pkg.Funcs = keepFuncs(pkg.Funcs)
+	types := pkg.Types[:0]
+	for _, t := range pkg.Types {
+		if !inChanged(t.Decl.Pos()) {
+			t.Consts = keepValues(t.Consts)
+			t.Vars = keepValues(t.Vars)
+			t.Funcs = keepFuncs(t.Funcs)
+			t.Methods = keepFuncs(t.Methods)
+			if len(t.Consts)+len(t.Vars)+len(t.Funcs)+len(t.Methods) == 0 {
+				continue
+			}
+		}
+		types = append(types, t)
+	}
+	pkg.Types = types
+}
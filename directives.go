@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+)
+
+// compilerDirectives are the //go: directives renderFuncDoc surfaces as
+// badges. go/doc already leaves directive lines out of the doc text.
+var compilerDirectives = map[string]bool{
+	"noinline":          true,
+	"nosplit":           true,
+	"noescape":          true,
+	"norace":            true,
+	"nocheckptr":        true,
+	"linkname":          true,
+	"uintptrescapes":    true,
+	"uintptrkeepalive":  true,
+	"systemstack":       true,
+	"nowritebarrier":    true,
+	"nowritebarrierrec": true,
+	"registerparams":    true,
+	"wasmimport":        true,
+	"wasmexport":        true,
+}
+
+// packageFuncDirectives maps the position of each function declaration in
+// files, as directiveKey gives it, to its recognized //go: directive lines,
+// arguments included, in source order.
+func packageFuncDirectives(fset *token.FileSet, files []*ast.File) map[string][]string {
+	var directives map[string][]string
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, c := range fn.Doc.List {
+				name, ok := strings.CutPrefix(c.Text, "//go:")
+				if !ok {
+					continue
+				}
+				if i := strings.IndexAny(name, " \t"); i >= 0 {
+					name = name[:i]
+				}
+				if !compilerDirectives[name] {
+					continue
+				}
+				if directives == nil {
+					directives = make(map[string][]string)
+				}
+				key := directiveKey(fset, fn)
+				directives[key] = append(directives[key], strings.TrimSpace(c.Text))
+			}
+		}
+	}
+	return directives
+}
+
+// directiveKey identifies decl by file and line, which stay the same when a
+// cgo package's files are parsed again for go/doc.
+func directiveKey(fset *token.FileSet, decl *ast.FuncDecl) string {
+	pos := fset.Position(decl.Pos())
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+}
+
+// renderDirectiveBadges writes a function's compiler directives as code
+// spans under its heading.
+func (r *markdownRenderer) renderDirectiveBadges(w io.Writer, decl *ast.FuncDecl) {
+	if len(r.directives) == 0 || decl == nil || r.fileset == nil {
+		return
+	}
+	directives := r.directives[directiveKey(r.fileset, decl)]
+	if len(directives) == 0 {
+		return
+	}
+	badges := make([]string, len(directives))
+	for i, d := range directives {
+		badges[i] = "`" + d + "`"
+	}
+	fmt.Fprintf(w, "%s\n\n", strings.Join(badges, " "))
+}
@@ -16,8 +16,13 @@
 //     `README.md` per package, optionally in-place.
 //   - provide `-mainvars` and `-mainfuncs` so command packages can opt into the
 //     variable/function tables that are hidden by default.
-//   - ship a Cobra-powered CLI with rich `--help`, `--version`, shell completion,
-//     and a `gen-docs` helper for publishing the CLI reference itself.
+//   - ship a Cobra-powered CLI with rich `--help`, `--version` (or the
+//     `version` subcommand, with `--json` for tooling), shell completion, and
+//     a `gen-docs` helper for publishing the CLI reference itself.
+//   - debug rendering with `go-docmd inspect ./pkg`, which prints the parsed
+//     go/doc model (each declaration's kind, names, position, and doc length)
+//     as indented text or, with `--json`, JSON. Its shape follows go/doc and
+//     is not a stable format.
 //
 // ## Usage
 //
@@ -42,6 +47,12 @@
 //
 //     go run ./go-docmd completion bash > /usr/local/etc/bash_completion.d/go-docmd
 //
+// A directory outside any module (no `go.mod` or `go.work` above it) that
+// the go command cannot load is parsed directly instead. Its document has no
+// import line or type information, so features that need types, such as
+// `-implements` and `-apischema`, find nothing, but doc comments and
+// signatures still render.
+//
 // ## Supported Flags
 //
 // The CLI mirrors `go doc` and extends it with Markdown-specific behavior:
@@ -52,13 +63,363 @@
 //   - `-cmd`: include symbol documentation for `package main`.
 //   - `-short`: collapse each symbol to a single-line summary.
 //   - `-src`: include the full declaration source.
+//   - `-format FORMAT`: `markdown` (the default) or `confluence`, which
+//     writes Confluence storage format (XHTML) instead: headings become
+//     `<h1>`–`<h6>`, code blocks become `code` macros, alerts become
+//     `info`/`tip`/`note`/`warning` macros, and anchors become `anchor`
+//     macros. Directory and in-place modes then write `.xml` files unless
+//     `-out-ext` or `-readme-name` chooses another extension.
+//   - `-strip-copyright`: with `-src`, drop a copyright or license paragraph
+//     (leading `//` lines mentioning "Copyright" or "License", or a `/* */`
+//     block at the top) from the declaration source.
 //   - `-u`: include unexported symbols.
 //   - `-o FILE`: write Markdown to `FILE` (stdout when omitted).
+//     `FILE` may contain `{pkg}` (package name) and `{dir}` (directory relative
+//     to the root) placeholders to write one file per package, e.g.
+//     `-o docs/{dir}/{pkg}.md`.
 //   - `-inplace`: treat the output path as a directory and write one
 //     `README.md` into each package directory (overwriting existing files).
 //   - `-mainvars`: show package-level variables for `package main` (default:
 //     hidden so command docs stay concise).
 //   - `-mainfuncs`: show package-level functions for `package main`.
+//   - `-heading-offset N`: shift every heading down by `N` levels so output
+//     can nest under an existing document (clamped at `######`).
+//   - `-number-headings`: number the headings under each document's title
+//     as sections, `1.`, `1.1.`, `2.`, and so on, for formal or printed
+//     references. Headings keep their levels; only the number is added.
+//     Numbering restarts in every package's document, follows
+//     `-heading-offset`, and keeps each heading's unnumbered anchor working.
+//     A heading that repeats in a document, such as Example, gets its anchor
+//     suffixed `-1`, `-2`, and so on, as GitHub does.
+//     Numbers are given as sections render, so a section
+//     `-no-empty-sections` drops leaves a gap.
+//   - `-summary-format table`: render the package summary as a Symbol, Kind,
+//     Description table instead of bullet lines (default `bullets`). It has
+//     no effect with `-index`, which replaces the summary.
+//   - `-error-format json`: report a failure on stderr as one JSON object
+//     instead of a `go-docmd:` line; see Error Output. Default `text`.
+//   - `-compact`: tighten each document by collapsing runs of blank lines
+//     and dropping the blank lines between list items and around headings.
+//     Blank lines that paragraphs, code fences, tables, and HTML blocks
+//     need are kept.
+//   - `-allow-network`: document a package outside the current module's
+//     dependency graph, such as `golang.org/x/time/rate`, by fetching its
+//     module's latest version through the module proxy (`go get` in a
+//     scratch module), as `go doc` can. Without it such paths fail to
+//     resolve with a hint to pass the flag.
+//   - `-max-code-line N`: warn, naming the symbol, when a rendered
+//     declaration or signature has a line wider than N columns, with tabs
+//     counting to 8-column stops. The warning fails the run under
+//     `-fail-on-warning`.
+//   - `-group-packages-by-dir`: nest the `## Packages` list by directory, one
+//     indented bullet level per path segment. Directories without a package
+//     of their own appear as plain `dir/` bullets.
+//   - `-hoist-example`: repeat the first code block of the package doc in a
+//     `## Quick Start` section right after the title; the doc below is
+//     unchanged.
+//   - `-exclude-deprecated`: leave out every symbol whose doc comment has a
+//     `Deprecated:` paragraph, from the summary and every section. A
+//     deprecated type takes its constructors and methods with it. Add
+//     `-show-deprecated-list` to name them in a collapsed `<details>` block
+//     at the end of the package document.
+//   - `-cli-flags`: for `package main`, add a `## Flags` table (Name,
+//     Default, Usage) built from `flag` and pflag registration calls such
+//     as `flag.String("name", "def", "usage")` found in the source.
+//   - `-skip-empty`: in tree modes, leave out library packages without
+//     exported symbols. Rendered on their own, such packages get a
+//     "This package has no exported symbols." note.
+//   - `-sort-types=name|source|methods`: order the type sections by name
+//     (ignoring case unless `-c` is set), by declaration order, or by method
+//     count with the most methods first. By default types keep go/doc's
+//     order.
+//   - `-progress`: while rendering a tree, report `[42/200] example/subpkg`
+//     on stderr. A terminal sees one line redrawn in place; other stderr
+//     destinations get a line every tenth of the way.
+//   - `-include-ignored`: add a `## Tools` section describing the
+//     `//go:build ignore` programs in each package directory, typically
+//     generators run with `go run`, from their file doc comments.
+//   - `-validate-links`: after writing a directory, combined, or in-place
+//     tree, check every relative link in the generated Markdown and fail,
+//     listing each dangling link with its file, when a target does not
+//     exist. Unlike `-strict-links`, this checks the output files rather
+//     than doc comment symbol links.
+//   - `-sentence-terminators CHARS`: the characters that end the first
+//     sentence taken as a summary (default `.。．`). ASCII terminators end a
+//     sentence when whitespace follows, or an uppercase letter after a
+//     capitalized word, so `A.Then` splits while `io.Reader`, `e.g.` and
+//     `v1.2` stay whole; others, such as the Japanese full stop, end it
+//     immediately. Add `!?` for prose that uses them.
+//   - `-signature-links`: under each function signature, add a
+//     `**References:**` line linking the package's own types and constants
+//     the signature mentions, resolved through type information. Names
+//     whose headings a single-symbol document lacks link to pkg.go.dev.
+//   - `-no-signatures`: leave out the declaration and signature code blocks
+//     of types, functions, values, and fields, so a full document reads as
+//     headings and prose. Examples keep their code.
+//   - `-use-export-data`: load packages with the lightest `go/packages`
+//     mode the requested output allows. Packages are parsed but not type
+//     checked unless a symbol argument or a feature that consults types
+//     (`-callers`, `-signature-links`, `-enum-table`, `-vartable`,
+//     `-flatten-interfaces`, `-implements`, `-apischema`) needs it;
+//     dependencies then come from export data. Without type checking, type
+//     errors no longer stop rendering.
+//   - `-coverage-badges`: after each package in generated package lists,
+//     show the share of its exported symbols that have doc comments, as
+//     in `*(85% documented)*`, counted the way `-lint-undocumented` counts.
+//   - `-max-methods N`: render the first `N` methods of a type in full and
+//     list the rest, by signature and summary, under
+//     `### Additional Methods (M more)`. The default, 0, renders them all.
+//   - `-callers`: when documenting a single function or method, add a
+//     `Used By` list of the functions and methods in the same package that
+//     refer to it. Unexported callers are listed with `-u` or `-all`.
+//   - `-ref-links`: rewrite the links of each generated document as
+//     reference links, `[text][1]`, and list the URLs once at the end
+//     (`[1]: https://...`). In-page `#anchor` links, images, and code stay
+//     as written.
+//   - `-no-toc`: in directory and in-place modes, write the root package
+//     document without the appended `## Packages` list. Per-package
+//     documents are unchanged.
+//   - `-flatten-interfaces`: under an interface that embeds other
+//     interfaces, add a `### Method Set` list of every method it requires,
+//     marking inherited ones with the interface that declares them, as in
+//     `(from io.Reader)`.
+//   - `-toc-summary-len N`: shorten each package summary in generated
+//     package lists to at most `N` characters, cutting at a word boundary
+//     and ending with `…`. Symbol summaries are unaffected.
+//   - `-playground-links`: upload each example that go/doc can turn into a
+//     complete program to the Go Playground and add a `[Run in Playground]`
+//     link under it. Upload failures leave the example without a link.
+//   - `-no-network`: never contact network services, disabling
+//     `-playground-links`.
+//   - `-go-version-note`: under the title of a module's root package, add
+//     `*Requires Go 1.xx or later.*` from the module's `go` directive.
+//     Packages below the root, and packages without module information, get
+//     no note.
+//   - `-pkg-heading-format FORMAT`: format the package title line, replacing
+//     `{name}` with the package name, `{import}` with its import path, and
+//     `{module}` with its module path (default `package {name}`). The title
+//     stays a level-1 heading subject to `-heading-offset`, so
+//     `-pkg-heading-format '{import}'` writes `# example.com/mod/pkg`.
+//   - `-lint-undocumented`: report every exported symbol without a doc comment
+//     as `file:line: exported X is undocumented` on stderr and exit non-zero.
+//   - `-module-root`: anchor directory and in-place output at the module root
+//     so relative paths and TOC links don't depend on where the tool runs.
+//   - `-timing`: after directory or in-place generation, print load, render,
+//     and write durations plus the slowest packages to stderr.
+//   - `-fence-lang LANG`: override the fence language used for doc comment
+//     code blocks (inferred by default, untagged when the block is neither
+//     Go nor a recognized language; `none` omits it). Declaration blocks
+//     always use `go`.
+//   - `-all-matches`: render every package that matches an ambiguous
+//     argument (e.g. `rand.Int` in both `crypto/rand` and `math/rand`) or a
+//     `...` pattern, each under a `## from <pkgpath>` heading.
+//   - `-max-go-version VERSION`: omit symbols whose doc carries an
+//     `availableSince: go1.N` line newer than `VERSION`; omitted symbols are
+//     reported on stderr.
+//   - `-anchor-format FORMAT`: slug rules used for in-document anchors
+//     (`github`, `gitlab`, or `bitbucket`; default `github`).
+//   - `-enum-table`: render typed `iota` constant blocks as a table of names,
+//     computed values, and descriptions instead of a code block.
+//   - `-index-name NAME` and `-out-ext EXT`: change the per-package file
+//     written in directory and in-place modes (default `README` and `md`;
+//     e.g. `-index-name _index` for Hugo). TOC links follow the same name.
+//     `-readme-name DOCS.md` sets both at once, so generated files sit next
+//     to hand-written READMEs instead of overwriting them.
+//   - `-exclude-unexported-fields`: hide unexported struct fields even when
+//     `-u` or `-all` include unexported symbols.
+//   - `-exclude-symbol NAME`: leave out symbols matching NAME, a name or
+//     glob such as `Generated*`, from the summary and every section; use
+//     `Type.Method` for a method. Repeat the flag to exclude several. Names
+//     match like `.docmdignore` entries (see Ignore Files).
+//   - `-only KINDS`: restrict package output (summary and `-all` body) to a
+//     comma-separated subset of `types`, `funcs`, `consts`, and `vars`.
+//   - `-apischema`: in directory, combined, or in-place mode, write a JSON
+//     Schema (`schemas/<Type>.json`) for every struct whose doc comment
+//     contains a `+apischema` line. Property names follow `json` tags and
+//     descriptions come from field comments.
+//   - `-strict-links`: after writing output, report every `[Symbol]` doc link
+//     that names a missing local symbol (as `file:line`) and exit non-zero.
+//     Links into other packages are assumed valid.
+//   - `-fail-on-warning`: exit non-zero when the run reports any warning,
+//     such as symbols omitted by `-max-go-version` or test files that could
+//     not be parsed. Warnings, including the reports of `-strict-links`,
+//     `-validate-links`, and `-lint-undocumented`, are printed on stderr once
+//     rendering finishes, with or without this flag.
+//   - `-output-encoding NAME`: transcode every written document from UTF-8
+//     to an IANA-registered encoding such as `Shift_JIS` or `ISO-8859-1`.
+//     A character the encoding cannot represent fails the run, naming its
+//     line, unless `-on-unmappable=replace` turns it into `?`. JSON files,
+//     such as `-nav-json` and `-apischema` output, stay UTF-8.
+//   - `-timeout DURATION`: give up when loading packages takes longer than
+//     DURATION (such as `30s` or `2m`) and exit with a timeout error instead
+//     of hanging. Each load gets its own DURATION; rendering and writing are
+//     not limited. The default, `0`, sets no limit.
+//   - `-default-prefix PREFIX`: a struct field comment line starting with
+//     PREFIX (default `default:`), such as `// default: "info"`, is lifted
+//     out of the field's description and shown after it as
+//     `(default: "info")`, with the value as code, when the field is
+//     rendered. An empty prefix turns this off.
+//   - `-alias`: list type aliases (`type Reader = io.Reader`) in an Aliases
+//     table of alias, target, and description after the package's other
+//     sections instead of giving each its own type section. Functions that
+//     go/doc groups under an alias move to the package's Functions.
+//   - `-embed-source`: in directory, combined, and in-place modes, end each
+//     package document with a collapsed `<details>` Source block holding
+//     every Go file in a fenced `go` block. Packages whose files exceed
+//     64 KiB are cut off with a warning. Markdown output only.
+//   - `-examples-file`: in directory and in-place modes, render examples into
+//     a sibling `EXAMPLES.md` per package and link to it from the README.
+//   - `-mark-generated`: start every generated Markdown file with
+//     `<!-- Code generated by go-docmd; DO NOT EDIT. -->` and refuse to
+//     overwrite an existing file that lacks the marker, so hand-written
+//     READMEs are not clobbered. `-force` overwrites them anyway.
+//   - `-nav-json`: in directory, archive, and in-place modes, also write a
+//     `nav.json` next to the root README describing the package hierarchy.
+//     Each node has a `title`, the `path` of its README, a `summary`, and
+//     `children` sorted by title; directories without a package of their own
+//     have no `path`.
+//   - `-template-dir DIR`: override the rendering of individual kinds with Go
+//     templates; see Templates below.
+//   - `-index`: replace the flat symbol summary with an Index grouped the way
+//     pkg.go.dev groups it: constants, variables, functions, then each type
+//     with its constructors and methods nested beneath it. With `-all` every
+//     entry links to its section.
+//   - `-glossary`: append a Glossary listing every exported symbol, methods
+//     as `Type.Method`, alphabetically with its one-line summary. With
+//     `-all` every entry links to its section.
+//   - `-deps`: add an Imports section listing the package's direct imports,
+//     standard library and external packages grouped separately and linked
+//     to pkg.go.dev. In directory and in-place modes the root README also
+//     gets an External Dependencies list of packages imported from outside
+//     the tree.
+//   - `-sort-imports-grouped`: with `-deps`, list imports in three groups as
+//     `goimports` orders them: standard library, packages from the same
+//     module, and third-party packages, each sorted.
+//   - `-admonitions LIST`: doc comment paragraphs that open with one of these
+//     comma-separated keywords and a colon (default `Note,Warning,Caution`;
+//     `Tip` and `Important` are also accepted), such as `Note: ...`, render
+//     as GitHub alert blockquotes (`> [!NOTE]`). Pass an empty list to
+//     render them as ordinary paragraphs.
+//   - `-build-notes`: after each symbol declared in a file with build
+//     constraints, add a note such as `*Available on: darwin*`. Both
+//     `//go:build` lines and `_GOOS`/`_GOARCH` file name suffixes count;
+//     constraints other than a list of alternatives keep their expression
+//     syntax (`*Available on: linux && !cgo*`).
+//   - `-embed-examples`: when a doc comment mentions a file that one of the
+//     package's `//go:embed` directives embeds (by its path or base name),
+//     append the file's contents as a fenced code block whose language
+//     follows the file extension. Embedded directories are not expanded.
+//   - `-benchmarks`: add a Benchmarks section listing each `BenchmarkXxx`
+//     function in the package's `_test.go` files with its doc comment. The
+//     benchmarks are only listed, never run.
+//   - `-added-since TAG`: add an `## Added since TAG` section listing the
+//     exported top-level constants, variables, functions, and types that
+//     exist now but not at `TAG` (any git ref). Requires `git` on `PATH` and
+//     a working directory inside the repository that holds the documented
+//     packages; the old sources are read with `git show` and only parsed,
+//     so build constraints are not applied to them. Methods and fields are
+//     not compared.
+//   - `-wrap N`: reflow doc comment paragraphs and list items to at most `N`
+//     columns (0, the default, leaves them as written). Code spans, links,
+//     and URLs are never split, and code blocks, tables, and headings are
+//     never wrapped.
+//   - `-relative-links=absolute` with `-base-url URL`: write the TOC and
+//     cross-package links as absolute URLs, `URL` followed by the package's
+//     path in the docs tree and a trailing slash (for example
+//     `https://docs.example.com/api/subpkg/`), for hosts that serve rendered
+//     pages at paths other than the Markdown files. Relative links are the
+//     default.
+//   - `-method-index`: before a type's detailed methods, add a Method Index
+//     code block listing every method signature, receiver elided, one per
+//     line.
+//   - `-raw-doc PATTERNS`: an escape hatch for packages whose doc comments
+//     use markup other than Go doc conventions. For the comma-separated import
+//     paths in `PATTERNS` (a trailing `/...` includes subpackages), comment
+//     text is emitted verbatim apart from dedenting, with no code fencing or
+//     link rewriting.
+//   - `-list-packages`: print each package the argument expands to as a
+//     tab-separated line of import path, directory, and `main` or `package`,
+//     then exit without rendering. `-no-recurse` applies.
+//   - `-vartable`: render variables as a single name, type, and description
+//     table instead of one declaration block per group. Types come from the
+//     declaration or, when omitted, from the initializer.
+//   - `-dry-run`: list each file directory, archive, combined, or in-place
+//     output would write, prefixed `create` or `overwrite`, without writing
+//     anything.
+//   - `-max-depth-headings`: headings that `-heading-offset` or nesting would
+//     push past `######` are rendered as bold lines rather than clamped to
+//     `######`, so their hierarchy stays visible.
+//   - `-color[=WHEN]`: print a lightly colorized terminal preview instead of
+//     raw Markdown (bold headings, dim code, underlined link text). The
+//     default, `auto`, only colorizes when stdout is a terminal and the
+//     `NO_COLOR` environment variable is unset; `always` forces it and
+//     `never` turns it off. Give WHEN with `=`, as in `-color=always`; a
+//     separate `always`, `never`, or `auto` argument is rejected. Files
+//     written with `-o` are never colorized.
+//   - `-no-color`: disable colorized output regardless of `-color`.
+//   - `-external-doc-links`: render doc links into other packages (such as
+//     `[io.Reader]`) as pkg.go.dev links instead of leaving the bracketed
+//     text as written.
+//   - `-stdlib-version VERSION`: pin the pkg.go.dev links of
+//     `-external-doc-links` and `-deps` into the standard library to a Go
+//     version, e.g. `https://pkg.go.dev/io@go1.22#Reader`.
+//     `latest` (the default) leaves them unpinned and `module` uses the
+//     documented module's `go` directive.
+//   - `-no-empty-sections`: drop any heading that filtering left without
+//     content before the next heading of the same or a higher level.
+//   - `-goos LIST`: load a single package once per comma-separated GOOS
+//     value (e.g. `linux,darwin,windows`) and render one merged document.
+//     Symbols declared identically on every platform form the main output;
+//     the rest follow under headings such as `### Linux-only` or
+//     `### Darwin/Linux-only`.
+//   - `-platform-matrix`: with `-goos`, end the merged document with a
+//     Platform Availability table: a row per exported top-level symbol, a
+//     column per GOOS, and ✓ where that platform declares the symbol.
+//   - `-diff-base REF`: render only the symbols declared in `.go` files that
+//     `git diff --name-only REF` reports as changed, for "what changed"
+//     review docs. A type declared in an unchanged file is kept when one of
+//     its methods or associated declarations changed.
+//   - `-note-interfaces`: after each method whose signature matches a
+//     well-known single-method interface (`error`, `fmt.Stringer`,
+//     `io.Reader`, `io.Writer`, `json.Marshaler`, and similar), add a note
+//     such as `*Implements fmt.Stringer.*`. Type bullets in the package
+//     summary list the same interfaces, up to three, as in
+//     `(io.Closer, fmt.Stringer)`.
+//   - `-no-import-line`: omit the import statement that follows the package
+//     title. The statement otherwise uses the package's canonical import
+//     comment (`package foo // import "example.com/foo"`) when it has one.
+//   - `-param-docs`: lines of the form `name: description` in a function's
+//     doc comment, where `name` is one of its parameters or named results,
+//     are removed from the prose and rendered as Parameter and Result tables
+//     under the signature.
+//   - `-implements`: under each interface, list the types that implement it
+//     (`### Implemented By`); under each concrete type, list the interfaces
+//     it satisfies (`### Implements`). Candidates come from the documented
+//     package, or from every package in directory, combined, and in-place
+//     modes, with links to the matching type headings.
+//   - `-header FILE|TEXT`: prepend a banner such as a license notice or a
+//     "DO NOT EDIT" warning to every generated document, above the package
+//     title. The value is read as a file when one exists at that path and
+//     used as inline text otherwise.
+//   - `-no-recurse`: in directory and in-place modes, document only the
+//     package named by the argument rather than it and every package below
+//     it. Patterns that already contain `...` are used as given.
+//   - `-stability-style STYLE`: how a `+stability: LEVEL` line in the package
+//     doc is rendered below the title: `text` (a bold line, the default),
+//     `badge` (a shields.io badge colored for `experimental`, `stable`, or
+//     `deprecated`), or `none`. The directive never appears in the prose.
+//
+// ## Default Flags
+//
+// Set `GODOCMD_FLAGS` to supply default flags for every invocation, for
+// example in a Makefile shared across repositories:
+//
+//	GODOCMD_FLAGS='-cmd -heading-offset 1' go run ./go-docmd ./...
+//
+// The value is split like a shell command line (quotes and backslashes are
+// honored) and placed before the command-line arguments, so flags passed
+// explicitly always take precedence over the environment. Subcommands such as
+// `completion` and `gen-docs` ignore the variable.
 //
 // ## Shell Completion
 //
@@ -71,7 +432,9 @@
 //
 // Add the appropriate command to your shell startup files (see Cobra's docs for
 // installation paths) and enjoy tab-completion for flags, subcommands, and Go
-// package arguments.
+// package arguments. Package arguments complete against standard library and
+// current-module import paths; local paths (`./...`) fall back to directory
+// completion.
 //
 // ## CLI Docs
 //
@@ -91,6 +454,163 @@
 // README automatically includes a table of contents linking to each
 // subpackage's README.
 //
+// With `-flatten-single-package`, a pattern that matches a single package,
+// even one nested below the pattern's root (`./tools/...` holding only
+// `tools/gen`), writes just that package's README at the output root with no
+// table of contents, exactly as a single-package run would; combined and
+// in-place output flatten the same way.
+//
+// When the pattern spans several modules of a `go.work` workspace, each
+// module's packages are nested under a directory named for the module (its
+// last path element, or the full module path if two would collide):
+//
+//	go run ./go-docmd -o ./docs example.com/...
+//
+// With `-summary-only` (here or with `-inplace`) only the root README is
+// written: the table of contents listing every package with its summary,
+// without the root package's own documentation. Its links point where the
+// per-package READMEs would go, so pair it with `-base-url` or a previous
+// full run.
+//
+// ## Symbol Sets
+//
+// Symbol arguments accept glob patterns (`*`, `?`, `[...]`). On stdout every
+// match is rendered in sequence; with `-o` pointing at a directory each
+// matching symbol gets its own `Name.md` plus an `index.md` linking them,
+// with the extension `-out-ext` or `-format` selects:
+//
+//	go run ./go-docmd -o ./docs/api ./pkg 'New*'
+//
+// ## Ignore Files
+//
+// A package directory may hold a `.docmdignore` file naming symbols to leave
+// out of that package's documentation, one name or glob per line; blank
+// lines and lines starting with `#` are skipped. `Type.Method` drops a
+// single method, and a type drops together with the constructors and
+// values grouped under it. Names match case-insensitively unless `-c` is
+// set:
+//
+//	# generated bindings
+//	zz_*
+//	Client.Reset
+//
+// ## Method Headings
+//
+// A method's heading names its receiver the way Go declares it:
+// `(*Greeter) Greet` for a pointer receiver and `(Greeter) Greet` for a
+// value receiver, so the heading tells which method set the method belongs
+// to. Headings were `Greeter.Greet` in earlier releases, and their anchors
+// change with them: with the default `github` `-anchor-format`, a link to
+// `#greetergreet` must now point at `#greeter-greet`. Constructors and other
+// functions grouped under a type keep the `Type.Func` form.
+//
+// ## Examples
+//
+// With `-all`, examples from the package's `_test.go` files appear next to
+// what they document, following go/doc's naming: `ExampleWidget` under the
+// `type Widget` section, `ExampleWidget_Resize` under its `Resize` method,
+// `ExampleNewWidget` under the constructor, and `Example` at the end of the
+// package. `-examples-file` moves them all to `EXAMPLES.md` instead.
+//
+// ## See Also
+//
+// A `See also: Foo, Bar.Baz` line in a type or function doc comment is lifted
+// out of the prose and rendered as a `**See also:**` line linking each name
+// to its heading, or to its pkg.go.dev entry when a single-symbol document
+// does not include that heading. Names that are not symbols of the package
+// appear as code spans.
+//
+// ## Compiler Directives
+//
+// Compiler directives in a function's doc comment, such as `//go:noinline`,
+// `//go:nosplit`, and `//go:linkname`, are shown as code-span badges under
+// the function's heading. Other `//go:` lines, like doc directives in
+// general, never appear in the prose.
+//
+// In a package with `.s` files, a function or method declared without a body
+// is documented with an *Implemented in assembly.* note, unless it is pulled
+// in with `//go:linkname`.
+//
+// ## Templates
+//
+// `-template-dir` names a directory holding any of `package.tmpl`,
+// `type.tmpl`, `func.tmpl`, and `value.tmpl` (Go `text/template` syntax).
+// Each file present replaces the built-in layout for that kind; missing files
+// fall back to it. Every template receives `.Heading` (the built-in heading
+// line, honoring `-heading-offset`) and `.Doc` (the rendered doc comment),
+// plus:
+//
+//   - package: `.Name`, `.ImportPath`, and `.Body`, the built-in rendering of
+//     everything after the package doc.
+//   - type: `.Name`, `.Decl`, `.Examples`, and `.Members`, the rendered
+//     constants, variables, functions, and methods of the type.
+//   - func: `.Name`, `.Receiver`, `.Signature`, `.Examples`, and `.Source`
+//     (with `-src`).
+//   - value: `.Names` and `.Decl`.
+//
+// For example, a `func.tmpl` that drops the code fence:
+//
+//	{{.Heading}}`{{.Signature}}`
+//
+//	{{.Doc}}
+//
+// ## Combined Mode
+//
+// When `-o` names a single file and the package argument is a tree pattern
+// (`./...`), every package is concatenated into that file in import-path
+// order. A table of contents at the top links to each package section via
+// in-document anchors:
+//
+//	go run ./go-docmd -o MODULE.md ./...
+//
+// ## Archive Mode
+//
+// When `-o` ends in `.tar.gz`, `.tgz`, `.tar`, or `.zip`, the files directory
+// mode would write (per-package READMEs, the TOC, and any examples or schemas)
+// are bundled into an archive instead, keeping the relative layout. Tar
+// archives are gzip-compressed unless the name ends in plain `.tar`:
+//
+//	go run ./go-docmd -o docs.tar.gz ./...
+//
+// A symbol glob bundles one Markdown file per matching symbol plus
+// `index.md`, as a symbol-set export to a directory would:
+//
+//	go run ./go-docmd -o api.zip ./pkg 'New*'
+//
+// ## Pipe Mode
+//
+// When `-o` starts with `|`, the rest is a command that receives the Markdown
+// on its standard input instead of a file being written, for example to
+// convert straight to PDF:
+//
+//	go run ./go-docmd -o '|pandoc -o api.pdf' ./pkg
+//
+// Arguments are split on white space without shell quoting. A command that
+// exits non-zero fails the run. With `-dry-run` the command is listed as
+// `pipe COMMAND` instead of being run.
+//
+// ## Error Output
+//
+// With `-error-format json` a failed run writes its error to stderr as a
+// single line, still exiting with status 1:
+//
+//	{"error":"could not resolve package path for \"./nope\"","kind":"package-not-found","package":"./nope"}
+//
+// `error` is the message text mode prints after `go-docmd:`. `package` is the
+// package argument or import path the failure is about, and is left out when
+// there is none. `kind` is one of:
+//
+//   - `package-not-found`: a package argument did not resolve, or fetching it
+//     with `-allow-network` failed.
+//   - `symbol-not-found`: the package resolved but has no matching symbol.
+//   - `usage`: a flag could not be parsed.
+//   - `timeout`: loading packages exceeded `-timeout`.
+//   - `warnings`: `-fail-on-warning` turned warnings into a failure. The
+//     warnings themselves are printed as text lines before the object.
+//   - `error`: any other failure.
+//
+// New kinds may be added; treat an unknown kind like `error`.
+//
 // ## In-Place Mode
 //
 // `-inplace` behaves like directory mode except output is written directly into
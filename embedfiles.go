@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// embeddedFile is a file named by a //go:embed directive, for -embed-examples.
+type embeddedFile struct {
+	name string // slash-separated path relative to the package directory
+	data []byte
+}
+
+// embedLanguages maps file extensions to the info string of the fenced code
+// block an embedded file is inlined in.
+var embedLanguages = map[string]string{
+	".go":   "go",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".xml":  "xml",
+	".html": "html",
+	".sql":  "sql",
+	".sh":   "sh",
+	".md":   "markdown",
+	".txt":  "text",
+}
+
+// packageEmbeds returns the regular files the package's //go:embed
+// directives name, sorted by path. Directory patterns are skipped. The
+// directives are read from the source files themselves.
+func packageEmbeds(pkgInfo *packages.Package) []embeddedFile {
+	dir := packageDir(pkgInfo)
+	if dir == "" {
+		return nil
+	}
+	var patterns []string
+	fset := token.NewFileSet()
+	for _, file := range pkgInfo.GoFiles {
+		syntax, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, group := range syntax.Comments {
+			for _, c := range group.List {
+				if args, ok := strings.CutPrefix(c.Text, "//go:embed "); ok {
+					patterns = append(patterns, embedPatterns(args)...)
+				}
+			}
+		}
+	}
+	return resolveEmbeds(dir, patterns)
+}
+
+// embedPatterns splits the arguments of a //go:embed line, which may be
+// double- or back-quoted.
+func embedPatterns(args string) []string {
+	var patterns []string
+	for _, field := range strings.Fields(args) {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+		patterns = append(patterns, strings.TrimPrefix(field, "all:"))
+	}
+	return patterns
+}
+
+func resolveEmbeds(dir string, patterns []string) []embeddedFile {
+	seen := make(map[string]bool)
+	var files []embeddedFile
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			rel, err := filepath.Rel(dir, match)
+			if err != nil || seen[rel] {
+				continue
+			}
+			data, err := os.ReadFile(match)
+			if err != nil {
+				continue
+			}
+			seen[rel] = true
+			files = append(files, embeddedFile{name: filepath.ToSlash(rel), data: data})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files
+}
+
+// embeddedFileBlocks renders, as fenced code blocks, the embedded files that
+// doc text mentions by path or base name.
+func (r *markdownRenderer) embeddedFileBlocks(text string) string {
+	var blocks []string
+	for _, file := range r.embeds {
+		if !mentionsFile(text, file.name) && !mentionsFile(text, path.Base(file.name)) {
+			continue
+		}
+		lang := embedLanguages[strings.ToLower(path.Ext(file.name))]
+		blocks = append(blocks, "`"+file.name+"`:\n\n"+fencedBlock(lang, string(file.data)))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// fencedBlock wraps body in a code fence tagged lang, lengthening the fence
+// past any backtick run in the body.
+func fencedBlock(lang, body string) string {
+	body = strings.TrimRight(body, "\n")
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + body + "\n" + fence
+}
+
+// mentionsFile reports whether text contains name as a whole path, not as
+// part of a longer file name.
+func mentionsFile(text, name string) bool {
+	re := regexp.MustCompile(`(^|[^\w./-])` + regexp.QuoteMeta(name) + `($|[^\w/-])`)
+	return re.MatchString(text)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// escapeStrayAsterisks keeps conventional **bold** and *italic* spans in doc
+// prose and escapes every other asterisk, so pointer types such as *T and
+// arithmetic such as a * b read literally instead of toggling emphasis. Code
+// blocks, code spans, and list bullets are left alone.
+func escapeStrayAsterisks(md string) string {
+	if !strings.Contains(md, "*") {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || (leadingWhitespace(line) > 0 && !isListLine(line)) {
+			continue
+		}
+		lines[i] = escapeLineAsterisks(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeLineAsterisks(line string) string {
+	var b strings.Builder
+	start := 0
+	if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "* ") {
+		start = len(line) - len(trimmed) + 2
+	}
+	b.WriteString(line[:start])
+	for i := start; i < len(line); {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			b.WriteString(line[i : i+2])
+			i += 2
+		case c == '`':
+			end := codeSpanEnd(line, i)
+			b.WriteString(line[i:end])
+			i = end
+		case c == '*':
+			if end := emphasisEnd(line, i); end > i {
+				b.WriteString(line[i:end])
+				i = end
+				continue
+			}
+			b.WriteString(`\*`)
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// codeSpanEnd returns the index just past the code span opening at i, or the
+// end of the backtick run when it is never closed.
+func codeSpanEnd(line string, i int) int {
+	run := 0
+	for i+run < len(line) && line[i+run] == '`' {
+		run++
+	}
+	fence := line[i : i+run]
+	if j := strings.Index(line[i+run:], fence); j >= 0 {
+		return i + run + j + run
+	}
+	return i + run
+}
+
+// emphasisEnd returns the index just past a **bold** or *italic* span opening
+// at i, or i when the asterisks there do not open one. The delimiters must
+// hug non-space text and sit at word boundaries.
+func emphasisEnd(line string, i int) int {
+	if i > 0 && isWordByte(line[i-1]) {
+		return i
+	}
+	delim := "*"
+	if strings.HasPrefix(line[i:], "**") {
+		delim = "**"
+	}
+	body := i + len(delim)
+	j := strings.Index(line[body:], delim)
+	if j <= 0 {
+		return i
+	}
+	text := line[body : body+j]
+	end := body + j + len(delim)
+	if strings.Contains(text, "*") || unicode.IsSpace(rune(text[0])) || unicode.IsSpace(rune(text[len(text)-1])) {
+		return i
+	}
+	if end < len(line) && isWordByte(line[end]) {
+		return i
+	}
+	return end
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+)
+
+// noExportsNote is rendered in place of the symbol listing of a library
+// package that exports nothing, so its document is not mysteriously empty.
+const noExportsNote = "> This package has no exported symbols."
+
+// hasNoExports reports whether pkg is a library package without exported
+// declarations. Commands are exempt, as is -u output, which lists
+// unexported declarations too.
+func hasNoExports(pkg *doc.Package, opts options) bool {
+	if opts.unexported || pkg.Name == "main" {
+		return false
+	}
+	return len(pkg.Consts) == 0 && len(pkg.Vars) == 0 && len(pkg.Funcs) == 0 && len(pkg.Types) == 0
+}
+
+func (r *markdownRenderer) renderNoExportsNote(w io.Writer) {
+	if r.noExports {
+		fmt.Fprintf(w, "%s\n\n", noExportsNote)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	unmappableError   = "error"
+	unmappableReplace = "replace"
+)
+
+// lookupOutputEncoding resolves an -output-encoding name such as Shift_JIS
+// or ISO-8859-1 through the IANA registry. UTF-8 needs no transcoding and
+// returns nil.
+func lookupOutputEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unsupported -output-encoding %q", name)
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// encodeOutput transcodes a rendered document for -output-encoding. A
+// character the encoding cannot represent is an error naming its line, or
+// with -on-unmappable=replace becomes "?".
+func encodeOutput(data []byte, opts options) ([]byte, error) {
+	if opts.outputEncoder == nil {
+		return data, nil
+	}
+	if out, err := opts.outputEncoder.NewEncoder().Bytes(data); err == nil {
+		return out, nil
+	}
+	enc := opts.outputEncoder.NewEncoder()
+	var buf bytes.Buffer
+	line := 1
+	for _, r := range string(data) {
+		encoded, err := enc.String(string(r))
+		if err != nil {
+			if opts.onUnmappable != unmappableReplace {
+				return nil, fmt.Errorf("line %d: %q cannot be represented in %s (use -on-unmappable=replace)", line, r, opts.outputEncoding)
+			}
+			encoded = "?"
+		}
+		buf.WriteString(encoded)
+		if r == '\n' {
+			line++
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeDocument writes a generated document through fw with the -header
+// banner and markers applied and transcoded for -output-encoding.
+func writeDocument(fw docWriter, path string, data []byte, opts options) error {
+	data, err := encodeOutput(withHeader(data, opts), opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return fw.writeFile(path, data)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// isIotaBlock reports whether decl is a const block of a named type whose
+// values are derived from iota.
+func isIotaBlock(decl *ast.GenDecl) bool {
+	if decl == nil || decl.Tok != token.CONST || len(decl.Specs) == 0 {
+		return false
+	}
+	first, ok := decl.Specs[0].(*ast.ValueSpec)
+	if !ok || first.Type == nil {
+		return false
+	}
+	var usesIota bool
+	for _, expr := range first.Values {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+				usesIota = true
+			}
+			return !usesIota
+		})
+	}
+	return usesIota
+}
+
+func (r *markdownRenderer) renderEnumTable(w io.Writer, decl *ast.GenDecl) {
+	fmt.Fprintln(w, "| Name | Value | Description |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		desc := ""
+		switch {
+		case vs.Doc != nil:
+			desc = vs.Doc.Text()
+		case vs.Comment != nil:
+			desc = vs.Comment.Text()
+		}
+		desc = escapeTableCell(r.summaryText(desc))
+		for _, ident := range vs.Names {
+			if ident.Name == "_" {
+				continue
+			}
+			fmt.Fprintf(w, "| `%s` | `%s` | %s |\n", ident.Name, r.constValue(ident), desc)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *markdownRenderer) constValue(ident *ast.Ident) string {
+	if r.typesInfo == nil {
+		return "?"
+	}
+	c, ok := r.typesInfo.Defs[ident].(*types.Const)
+	if !ok {
+		return "?"
+	}
+	return c.Val().ExactString()
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
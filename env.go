@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const envFlagsVar = "GODOCMD_FLAGS"
+
+// withEnvFlags prepends the flags from GODOCMD_FLAGS to argv so that flags
+// given on the command line are parsed later and therefore win. Subcommand
+// invocations, even those with root flags before the subcommand, are left
+// untouched because they don't accept the root flags.
+func withEnvFlags(root *cobra.Command, argv []string) ([]string, error) {
+	raw := strings.TrimSpace(os.Getenv(envFlagsVar))
+	if raw == "" {
+		return argv, nil
+	}
+	if name := firstPositional(root, argv); name != "" {
+		for _, sub := range root.Commands() {
+			if sub.Name() == name || sub.HasAlias(name) {
+				return argv, nil
+			}
+		}
+	}
+	envArgs, err := splitShellWords(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envFlagsVar, err)
+	}
+	return append(envArgs, argv...), nil
+}
+
+// firstPositional returns the first argument of argv that is not a root
+// flag or a flag's value, or "" when there is none.
+func firstPositional(root *cobra.Command, argv []string) string {
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			return arg
+		case strings.Contains(arg, "="):
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		flag := root.Flags().Lookup(name)
+		if flag == nil && len(name) == 1 {
+			flag = root.Flags().ShorthandLookup(name)
+		}
+		if flag != nil && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return ""
+}
+
+// splitShellWords tokenizes s the way a POSIX shell splits words, honoring
+// single quotes, double quotes, and backslash escapes.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// Error kinds reported by -error-format=json. Wrappers branch on these, so
+// they only ever gain values.
+const (
+	kindError           = "error"
+	kindUsage           = "usage"
+	kindPackageNotFound = "package-not-found"
+	kindSymbolNotFound  = "symbol-not-found"
+	kindTimeout         = "timeout"
+	kindWarnings        = "warnings"
+)
+
+var validErrorFormats = map[string]bool{"text": true, "json": true}
+
+// kindedError tags an error with its kind and, when known, the package it is
+// about.
+type kindedError struct {
+	kind string
+	pkg  string
+	err  error
+}
+
+func (e *kindedError) Error() string { return e.err.Error() }
+func (e *kindedError) Unwrap() error { return e.err }
+
+func withKind(kind, pkg string, err error) error {
+	return &kindedError{kind: kind, pkg: pkg, err: err}
+}
+
+// errorReport is the object -error-format=json writes to stderr.
+type errorReport struct {
+	Error   string `json:"error"`
+	Kind    string `json:"kind"`
+	Package string `json:"package,omitempty"`
+}
+
+// reportedError is an error already written to stderr, so main only has to
+// set the exit status.
+type reportedError struct{ err error }
+
+func (e *reportedError) Error() string { return e.err.Error() }
+func (e *reportedError) Unwrap() error { return e.err }
+
+func newErrorReport(err error) errorReport {
+	report := errorReport{Error: err.Error(), Kind: kindError}
+	var kinded *kindedError
+	if errors.As(err, &kinded) {
+		report.Kind = kinded.kind
+		report.Package = kinded.pkg
+	}
+	return report
+}
+
+// reportError writes err as JSON to stderr when the root command ran with
+// -error-format=json and returns it marked as reported; otherwise err is
+// returned unchanged for main to print.
+func reportError(root *cobra.Command, stderr io.Writer, err error) error {
+	if err == nil {
+		return nil
+	}
+	flag := root.Flags().Lookup("error-format")
+	if flag == nil || flag.Value.String() != "json" {
+		return err
+	}
+	data, merr := json.Marshal(newErrorReport(err))
+	if merr != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "%s\n", data)
+	return &reportedError{err}
+}
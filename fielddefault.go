@@ -0,0 +1,26 @@
+package main
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// fieldDefault splits the -default-prefix line, such as `default: "info"`,
+// out of a struct field's doc comment, falling back to its line comment. It
+// returns the default value and the doc text without that line.
+func (r *markdownRenderer) fieldDefault(field *ast.Field, docText string) (string, string) {
+	prefix := strings.TrimSpace(r.options.defaultPrefix)
+	if prefix == "" {
+		return "", docText
+	}
+	value, rest := docDirective(docText, prefix)
+	if value == "" && field.Comment != nil {
+		value, _ = docDirective(field.Comment.Text(), prefix)
+	}
+	return value, strings.TrimSpace(rest)
+}
+
+// defaultNote renders a field default as "(default: `"info"`)".
+func defaultNote(value string) string {
+	return "(default: `" + value + "`)"
+}
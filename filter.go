@@ -0,0 +1,148 @@
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/version"
+	"strings"
+)
+
+// filterDocPackage removes symbols excluded by the filtering options from pkg
+// in place and returns the names of everything it dropped.
+func filterDocPackage(pkg *doc.Package, opts options) []string {
+	if opts.excludeUnexportedFields {
+		for _, t := range pkg.Types {
+			stripUnexportedFields(findTypeSpec(t.Decl, t.Name))
+		}
+	}
+	maxVersion := normalizeGoVersion(opts.maxGoVersion)
+	if maxVersion == "" {
+		return nil
+	}
+	var omitted []string
+	keep := func(name, text string) bool {
+		since, _ := docDirective(text, availableSinceDirective)
+		since = normalizeGoVersion(since)
+		if since == "" || version.Compare(since, maxVersion) <= 0 {
+			return true
+		}
+		omitted = append(omitted, name)
+		return false
+	}
+	pkg.Consts = filterValues(pkg.Consts, keep)
+	pkg.Vars = filterValues(pkg.Vars, keep)
+	pkg.Funcs = filterFuncs(pkg.Funcs, "", keep)
+	types := pkg.Types[:0]
+	for _, t := range pkg.Types {
+		if !keep(t.Name, t.Doc) {
+			continue
+		}
+		t.Consts = filterValues(t.Consts, keep)
+		t.Vars = filterValues(t.Vars, keep)
+		t.Funcs = filterFuncs(t.Funcs, "", keep)
+		t.Methods = filterFuncs(t.Methods, t.Name, keep)
+		types = append(types, t)
+	}
+	pkg.Types = types
+	return omitted
+}
+
+func filterValues(values []*doc.Value, keep func(name, text string) bool) []*doc.Value {
+	kept := values[:0]
+	for _, v := range values {
+		if keep(strings.Join(v.Names, ", "), v.Doc) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func filterFuncs(funcs []*doc.Func, receiver string, keep func(name, text string) bool) []*doc.Func {
+	kept := funcs[:0]
+	for _, f := range funcs {
+		name := f.Name
+		if receiver != "" {
+			name = receiver + "." + f.Name
+		}
+		if keep(name, f.Doc) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// stripUnexportedFields removes unexported fields from a struct type spec and,
+// like go/doc, marks the struct incomplete so the printer notes the omission.
+func stripUnexportedFields(spec *ast.TypeSpec) {
+	if spec == nil {
+		return
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return
+	}
+	kept := st.Fields.List[:0]
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			if token.IsExported(embeddedFieldName(field.Type)) {
+				kept = append(kept, field)
+			} else {
+				st.Incomplete = true
+			}
+			continue
+		}
+		names := field.Names[:0]
+		for _, name := range field.Names {
+			if name.IsExported() {
+				names = append(names, name)
+			} else {
+				st.Incomplete = true
+			}
+		}
+		if len(names) > 0 {
+			field.Names = names
+			kept = append(kept, field)
+		}
+	}
+	st.Fields.List = kept
+}
+
+func embeddedFieldName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return embeddedFieldName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedFieldName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(e.X)
+	}
+	return ""
+}
+
+// normalizeGoVersion accepts "1.21", "go1.21", or "go1.21.3" and returns the
+// go/version form, or "" when v is not a recognizable Go version.
+func normalizeGoVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return ""
+	}
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if !version.IsValid(v) {
+		return ""
+	}
+	return v
+}
+
+func reportOmitted(pkgPath string, omitted []string, opts options) {
+	if len(omitted) == 0 {
+		return
+	}
+	opts.warnings.warnf("%s: omitted %d symbol(s) newer than %s: %s", pkgPath, len(omitted), normalizeGoVersion(opts.maxGoVersion), strings.Join(omitted, ", "))
+}
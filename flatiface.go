@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// renderMethodSet lists, for -flatten-interfaces, every method in the method
+// set of an interface that embeds others, noting which embedded interface
+// declares each inherited one. It needs type information and renders
+// nothing without it or for interfaces that embed nothing.
+func (r *markdownRenderer) renderMethodSet(w io.Writer, spec *ast.TypeSpec) {
+	if !r.options.flattenInterfaces || spec == nil || r.typesInfo == nil {
+		return
+	}
+	obj := r.typesInfo.Defs[spec.Name]
+	if obj == nil {
+		return
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok || iface.NumEmbeddeds() == 0 || iface.NumMethods() == 0 {
+		return
+	}
+	qualifier := func(pkg *types.Package) string {
+		if pkg == obj.Pkg() {
+			return ""
+		}
+		return pkg.Name()
+	}
+	r.heading(w, 3, "Method Set")
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		sig := m.Name() + strings.TrimPrefix(types.TypeString(m.Type(), qualifier), "func")
+		if origin := methodOrigin(obj.Type(), m); origin != nil && origin.Obj() != obj {
+			fmt.Fprintf(w, "- `%s` (from `%s`)\n", sig, types.TypeString(origin, qualifier))
+		} else {
+			fmt.Fprintf(w, "- `%s`\n", sig)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// methodOrigin returns the named interface, at any embedding depth, whose
+// body declares m, or nil when an unnamed interface literal does.
+func methodOrigin(typ types.Type, m *types.Func) *types.Named {
+	iface, ok := typ.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		if iface.ExplicitMethod(i) == m {
+			named, _ := typ.(*types.Named)
+			return named
+		}
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		if origin := methodOrigin(iface.EmbeddedType(i), m); origin != nil {
+			return origin
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// glossaryEntry is one exported symbol listed by -glossary.
+type glossaryEntry struct {
+	name    string // Name, or Type.Method for methods
+	summary string
+}
+
+// renderGlossary appends a flat, alphabetical list of the package's exported
+// symbols with their one-line summaries. Entries link to their sections when
+// -all renders them.
+func (r *markdownRenderer) renderGlossary(w io.Writer) {
+	var entries []glossaryEntry
+	add := func(name, text string) {
+		if token.IsExported(name) {
+			entries = append(entries, glossaryEntry{name: name, summary: r.summaryText(text)})
+		}
+	}
+	values := func(vals []*doc.Value) {
+		for _, v := range vals {
+			for _, name := range v.Names {
+				add(name, v.Doc)
+			}
+		}
+	}
+	if r.wantsKind(kindConsts) {
+		values(r.pkg.Consts)
+	}
+	if r.wantsKind(kindVars) {
+		values(r.pkg.Vars)
+	}
+	if r.wantsKind(kindFuncs) {
+		for _, f := range r.pkg.Funcs {
+			add(f.Name, f.Doc)
+		}
+	}
+	if r.wantsKind(kindTypes) {
+		for _, t := range r.pkg.Types {
+			add(t.Name, t.Doc)
+			values(t.Consts)
+			values(t.Vars)
+			for _, f := range t.Funcs {
+				add(f.Name, f.Doc)
+			}
+			if token.IsExported(t.Name) {
+				for _, m := range t.Methods {
+					add(t.Name+"."+m.Name, m.Doc)
+				}
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].name) < strings.ToLower(entries[j].name)
+	})
+	r.heading(w, 2, "Glossary")
+	for _, e := range entries {
+		label := "`" + e.name + "`"
+		if anchor, ok := r.symbolAnchor(e.name); ok && r.options.all {
+			label = fmt.Sprintf("[%s](#%s)", e.name, anchor)
+		}
+		if e.summary != "" {
+			fmt.Fprintf(w, "- %s — %s\n", label, e.summary)
+		} else {
+			fmt.Fprintf(w, "- %s\n", label)
+		}
+	}
+	fmt.Fprintln(w)
+}
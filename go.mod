@@ -4,6 +4,7 @@ go 1.24.5
 
 require (
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/text v0.29.0
 	golang.org/x/tools v0.37.0
 )
 
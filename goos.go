@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/doc"
+	"go/format"
+	"go/token"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type goosVariant struct {
+	goos    string
+	pkgInfo *packages.Package
+	docPkg  *doc.Package
+	keys    map[string]bool
+}
+
+// parseGOOSList splits a -goos value into its distinct, non-empty entries.
+func parseGOOSList(value string) []string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, goos := range strings.Split(value, ",") {
+		goos = strings.TrimSpace(goos)
+		if goos == "" || seen[goos] {
+			continue
+		}
+		seen[goos] = true
+		list = append(list, goos)
+	}
+	return list
+}
+
+// loadPackageForGOOS loads pattern as it builds for goos. Cross-platform
+// variants disable cgo, which may not be configured for the target.
+func loadPackageForGOOS(ctx context.Context, pattern, goos string) (*packages.Package, error) {
+	env := append(os.Environ(), "GOOS="+goos)
+	if goos != runtime.GOOS {
+		env = append(env, "CGO_ENABLED=0")
+	}
+	cfg := &packages.Config{
+		Mode: loadMode(ctx),
+		Env:  env,
+	}
+	pkgs, err := loadPackages(ctx, cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no Go packages matched %q for GOOS=%s", pattern, goos)
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, fmt.Errorf("GOOS=%s: %s", goos, pkgs[0].Errors[0])
+	}
+	return pkgs[0], nil
+}
+
+// documentGOOSVariants renders one package as it builds for each GOOS in
+// opts.goos. Symbols whose declarations match on every platform form the
+// shared document; the rest are grouped under a heading naming the platforms
+// that declare them, such as "Linux-only".
+func documentGOOSVariants(ctx context.Context, pkgExpr string, opts options) (docResult, error) {
+	goosList := parseGOOSList(opts.goos)
+	if len(goosList) == 0 {
+		return docResult{}, errors.New("-goos needs at least one GOOS value")
+	}
+	var (
+		variants                    []*goosVariant
+		omitted, deprecated, broken []string
+	)
+	for _, goos := range goosList {
+		pkgInfo, err := loadPackageForGOOS(ctx, pkgExpr, goos)
+		if err != nil {
+			return docResult{}, err
+		}
+		docPkg, err := buildDocPackage(pkgInfo, opts)
+		if err != nil {
+			return docResult{}, err
+		}
+		// Files shared by several platforms report the same names and links.
+		o, d, b := applyDocFilters(docPkg, pkgInfo.Fset, opts)
+		omitted = appendNew(omitted, o...)
+		deprecated = appendNew(deprecated, d...)
+		broken = appendNew(broken, b...)
+		variants = append(variants, &goosVariant{
+			goos:    goos,
+			pkgInfo: pkgInfo,
+			docPkg:  docPkg,
+			keys:    symbolKeys(docPkg, pkgInfo.Fset),
+		})
+	}
+
+	common := make(map[string]bool)
+	for key := range variants[0].keys {
+		shared := true
+		for _, v := range variants[1:] {
+			if !v.keys[key] {
+				shared = false
+				break
+			}
+		}
+		common[key] = shared
+	}
+
+	// Group platform-specific symbols by the exact set of platforms that
+	// declare them.
+	groups := make(map[string][]*goosVariant)
+	groupKeys := make(map[string]map[string]bool)
+	for _, v := range variants {
+		for key := range v.keys {
+			if common[key] {
+				continue
+			}
+			var owners []string
+			for _, other := range variants {
+				if other.keys[key] {
+					owners = append(owners, other.goos)
+				}
+			}
+			sort.Strings(owners)
+			group := strings.Join(owners, ",")
+			if groupKeys[group] == nil {
+				groupKeys[group] = make(map[string]bool)
+				groups[group] = []*goosVariant{v}
+			}
+			groupKeys[group][key] = true
+		}
+	}
+
+	base := variants[0]
+	var buf bytes.Buffer
+	renderer := markdownRenderer{options: opts, fileset: base.pkgInfo.Fset, typesInfo: base.pkgInfo.TypesInfo}
+	renderer.importPath = canonicalImportPath(base.pkgInfo)
+	if base.pkgInfo.Module != nil {
+		renderer.modulePath = base.pkgInfo.Module.Path
+	}
+	renderer.pkg = keepSymbols(base.docPkg, base.pkgInfo.Fset, common)
+	renderer.deprecated = deprecated
+	renderer.renderPackage(&buf)
+
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	sectionOpts := opts
+	sectionOpts.headingOffset += 2
+	for _, group := range names {
+		v := groups[group][0]
+		var title []string
+		for _, goos := range strings.Split(group, ",") {
+			title = append(title, strings.ToUpper(goos[:1])+goos[1:])
+		}
+		renderer.closeSections()
+		renderer.heading(&buf, 3, "%s-only", strings.Join(title, "/"))
+		section := markdownRenderer{options: sectionOpts, fileset: v.pkgInfo.Fset, typesInfo: v.pkgInfo.TypesInfo}
+		section.numbers, section.numberDepth = renderer.numbers, 1
+		section.pkg = keepSymbols(v.docPkg, v.pkgInfo.Fset, groupKeys[group])
+		section.pkg.Examples = nil
+		section.renderPackageBody(&buf)
+		if section.templateErr != nil {
+			return docResult{}, section.templateErr
+		}
+	}
+	if opts.platformMatrix {
+		renderer.renderPlatformMatrix(&buf, variants)
+	}
+	if renderer.templateErr != nil {
+		return docResult{}, renderer.templateErr
+	}
+	reportOmitted(base.pkgInfo.PkgPath, omitted, opts)
+	return docResult{Markdown: finishMarkdown(buf.Bytes(), opts), Summary: renderer.packageSummary(), Omitted: omitted, Broken: broken}, nil
+}
+
+// appendNew appends the values not already in list.
+func appendNew(list []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// symbolKeys identifies each top-level declaration of pkg by its formatted
+// source, so the same name with a different signature counts as distinct.
+func symbolKeys(pkg *doc.Package, fset *token.FileSet) map[string]bool {
+	keys := make(map[string]bool)
+	for _, v := range pkg.Consts {
+		keys[valueKey("const", v, fset)] = true
+	}
+	for _, v := range pkg.Vars {
+		keys[valueKey("var", v, fset)] = true
+	}
+	for _, f := range pkg.Funcs {
+		keys[funcKey(f, fset)] = true
+	}
+	for _, t := range pkg.Types {
+		keys[typeKey(t, fset)] = true
+	}
+	return keys
+}
+
+// keepSymbols returns a shallow copy of pkg holding only the declarations
+// whose keys are in keep.
+func keepSymbols(pkg *doc.Package, fset *token.FileSet, keep map[string]bool) *doc.Package {
+	copied := *pkg
+	copied.Consts = nil
+	copied.Vars = nil
+	copied.Funcs = nil
+	copied.Types = nil
+	for _, v := range pkg.Consts {
+		if keep[valueKey("const", v, fset)] {
+			copied.Consts = append(copied.Consts, v)
+		}
+	}
+	for _, v := range pkg.Vars {
+		if keep[valueKey("var", v, fset)] {
+			copied.Vars = append(copied.Vars, v)
+		}
+	}
+	for _, f := range pkg.Funcs {
+		if keep[funcKey(f, fset)] {
+			copied.Funcs = append(copied.Funcs, f)
+		}
+	}
+	for _, t := range pkg.Types {
+		if keep[typeKey(t, fset)] {
+			copied.Types = append(copied.Types, t)
+		}
+	}
+	return &copied
+}
+
+func valueKey(kind string, v *doc.Value, fset *token.FileSet) string {
+	return kind + " " + strings.Join(v.Names, ",") + "\n" + nodeString(fset, v.Decl)
+}
+
+func funcKey(f *doc.Func, fset *token.FileSet) string {
+	return "func " + f.Name + "\n" + nodeString(fset, f.Decl.Type)
+}
+
+// typeKey covers the type declaration together with its associated
+// declarations and method signatures.
+func typeKey(t *doc.Type, fset *token.FileSet) string {
+	parts := []string{"type " + t.Name, nodeString(fset, t.Decl)}
+	for _, v := range t.Consts {
+		parts = append(parts, valueKey("const", v, fset))
+	}
+	for _, v := range t.Vars {
+		parts = append(parts, valueKey("var", v, fset))
+	}
+	for _, f := range t.Funcs {
+		parts = append(parts, funcKey(f, fset))
+	}
+	for _, m := range t.Methods {
+		parts = append(parts, "method "+funcKey(m, fset))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func nodeString(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
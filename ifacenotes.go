@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/types"
+	"strings"
+)
+
+type methodShape struct {
+	name    string
+	params  string
+	results string
+}
+
+// wellKnownInterfaces maps single-method standard interfaces to the method
+// signature that satisfies them, with parameter and result types written as
+// comma-separated type expressions.
+var wellKnownInterfaces = []struct {
+	iface string
+	shape methodShape
+}{
+	{"error", methodShape{"Error", "", "string"}},
+	{"fmt.Stringer", methodShape{"String", "", "string"}},
+	{"fmt.GoStringer", methodShape{"GoString", "", "string"}},
+	{"fmt.Formatter", methodShape{"Format", "fmt.State,rune", ""}},
+	{"io.Reader", methodShape{"Read", "[]byte", "int,error"}},
+	{"io.Writer", methodShape{"Write", "[]byte", "int,error"}},
+	{"io.Closer", methodShape{"Close", "", "error"}},
+	{"io.ReaderAt", methodShape{"ReadAt", "[]byte,int64", "int,error"}},
+	{"io.WriterTo", methodShape{"WriteTo", "io.Writer", "int64,error"}},
+	{"io.ReaderFrom", methodShape{"ReadFrom", "io.Reader", "int64,error"}},
+	{"json.Marshaler", methodShape{"MarshalJSON", "", "[]byte,error"}},
+	{"json.Unmarshaler", methodShape{"UnmarshalJSON", "[]byte", "error"}},
+	{"encoding.TextMarshaler", methodShape{"MarshalText", "", "[]byte,error"}},
+	{"encoding.TextUnmarshaler", methodShape{"UnmarshalText", "[]byte", "error"}},
+	{"encoding.BinaryMarshaler", methodShape{"MarshalBinary", "", "[]byte,error"}},
+	{"encoding.BinaryUnmarshaler", methodShape{"UnmarshalBinary", "[]byte", "error"}},
+	{"http.Handler", methodShape{"ServeHTTP", "http.ResponseWriter,*http.Request", ""}},
+}
+
+// interfaceNote returns the `*Implements ...*` note for a method whose
+// signature matches one of wellKnownInterfaces, or "" when none does.
+func interfaceNote(decl *ast.FuncDecl) string {
+	if iface := wellKnownInterface(decl); iface != "" {
+		return "*Implements " + iface + ".*"
+	}
+	return ""
+}
+
+// wellKnownInterface names the entry of wellKnownInterfaces a method's
+// signature satisfies, or "".
+func wellKnownInterface(decl *ast.FuncDecl) string {
+	if decl == nil || decl.Recv == nil || decl.Type == nil {
+		return ""
+	}
+	shape := methodShape{
+		name:    decl.Name.Name,
+		params:  fieldTypes(decl.Type.Params),
+		results: fieldTypes(decl.Type.Results),
+	}
+	for _, known := range wellKnownInterfaces {
+		if known.shape == shape {
+			return known.iface
+		}
+	}
+	return ""
+}
+
+// maxSummaryInterfaces caps the interfaces listed after a type's summary.
+const maxSummaryInterfaces = 3
+
+// typeInterfacesNote lists the well-known interfaces t's methods satisfy,
+// "(io.Reader, fmt.Stringer)", for the package summary; past three it ends
+// with "…".
+func typeInterfacesNote(t *doc.Type) string {
+	var ifaces []string
+	for _, m := range t.Methods {
+		if iface := wellKnownInterface(m.Decl); iface != "" {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	if len(ifaces) == 0 {
+		return ""
+	}
+	if len(ifaces) > maxSummaryInterfaces {
+		ifaces = append(ifaces[:maxSummaryInterfaces], "…")
+	}
+	return "(" + strings.Join(ifaces, ", ") + ")"
+}
+
+func fieldTypes(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+	var list []string
+	for _, field := range fields.List {
+		expr := types.ExprString(field.Type)
+		for range max(len(field.Names), 1) {
+			list = append(list, expr)
+		}
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/doc"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// docmdIgnoreName is the per-package file listing symbols to leave out of
+// that package's documentation.
+const docmdIgnoreName = ".docmdignore"
+
+// ignoreFiles caches the parsed .docmdignore of each package directory for a
+// run. A nil cache reads none.
+type ignoreFiles struct {
+	mu       sync.Mutex
+	patterns map[string][]string
+}
+
+// forDir returns the patterns of dir's .docmdignore: one symbol name or glob
+// per line, with blank lines and # comments skipped. A missing file has none;
+// a malformed glob is an error naming its line.
+func (c *ignoreFiles) forDir(dir string) ([]string, error) {
+	if c == nil || dir == "" {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if patterns, ok := c.patterns[dir]; ok {
+		return patterns, nil
+	}
+	var patterns []string
+	name := filepath.Join(dir, docmdIgnoreName)
+	if f, err := os.Open(name); err == nil {
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := checkSymbolPattern(line); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+			}
+			patterns = append(patterns, line)
+		}
+		f.Close()
+	}
+	if c.patterns == nil {
+		c.patterns = make(map[string][]string)
+	}
+	c.patterns[dir] = patterns
+	return patterns, nil
+}
+
+// checkSymbolPattern reports a glob that path.Match cannot parse, which would
+// otherwise silently match nothing.
+func checkSymbolPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid symbol pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// excludeSymbols removes from pkg every symbol matching one of patterns.
+// A pattern matches a top-level name (a type drops with everything grouped
+// under it) or, written as Type.Method, a method. Matching follows -c.
+func excludeSymbols(pkg *doc.Package, patterns []string, caseSensitive bool) {
+	if len(patterns) == 0 {
+		return
+	}
+	excluded := func(name string) bool {
+		for _, pattern := range patterns {
+			if matchSymbolName(name, pattern, caseSensitive) {
+				return true
+			}
+		}
+		return false
+	}
+	keep := func(name, _ string) bool {
+		for _, n := range strings.Split(name, ", ") {
+			if excluded(n) {
+				return false
+			}
+		}
+		return true
+	}
+	pkg.Consts = filterValues(pkg.Consts, keep)
+	pkg.Vars = filterValues(pkg.Vars, keep)
+	pkg.Funcs = filterFuncs(pkg.Funcs, "", keep)
+	types := pkg.Types[:0]
+	for _, t := range pkg.Types {
+		if excluded(t.Name) {
+			continue
+		}
+		t.Consts = filterValues(t.Consts, keep)
+		t.Vars = filterValues(t.Vars, keep)
+		t.Funcs = filterFuncs(t.Funcs, "", keep)
+		t.Methods = filterFuncs(t.Methods, t.Name, keep)
+		types = append(types, t)
+	}
+	pkg.Types = types
+}
+
+// matchSymbolName reports whether name matches target, a symbol name or
+// glob, ignoring case unless caseSensitive is set.
+func matchSymbolName(name, target string, caseSensitive bool) bool {
+	if hasGlobMeta(target) {
+		if !caseSensitive {
+			name, target = strings.ToLower(name), strings.ToLower(target)
+		}
+		// Patterns are checked up front, so err is always nil here.
+		ok, _ := path.Match(target, name)
+		return ok
+	}
+	if caseSensitive {
+		return name == target
+	}
+	return strings.EqualFold(name, target)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// implementsIndex holds the named types -implements compares against: the
+// documented package alone, or every package of a tree.
+type implementsIndex struct {
+	types   []*types.TypeName
+	relDirs map[string]string
+	// linkFiles reports whether each package is written to its own file, so
+	// cross-package entries can link to the sibling document.
+	linkFiles bool
+}
+
+func newImplementsIndex(pkgs []*packages.Package, relDirs map[string]string, linkFiles bool) *implementsIndex {
+	index := &implementsIndex{relDirs: relDirs, linkFiles: linkFiles}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			index.types = append(index.types, obj)
+		}
+	}
+	return index
+}
+
+// renderImplements lists the types in the index that implement t when t is an
+// interface, or the interfaces t satisfies otherwise.
+func (r *markdownRenderer) renderImplements(w io.Writer, t *doc.Type) {
+	if r.implements == nil || r.typesInfo == nil {
+		return
+	}
+	spec := findTypeSpec(t.Decl, t.Name)
+	if spec == nil {
+		return
+	}
+	self, ok := r.typesInfo.Defs[spec.Name].(*types.TypeName)
+	if !ok {
+		return
+	}
+	iface, isInterface := self.Type().Underlying().(*types.Interface)
+	var related []*types.TypeName
+	for _, other := range r.implements.types {
+		if other == self || !r.listable(other) {
+			continue
+		}
+		otherIface, otherIsInterface := other.Type().Underlying().(*types.Interface)
+		switch {
+		case isInterface && !otherIsInterface:
+			if iface.NumMethods() > 0 && satisfies(other.Type(), iface) {
+				related = append(related, other)
+			}
+		case !isInterface && otherIsInterface:
+			if otherIface.NumMethods() > 0 && satisfies(self.Type(), otherIface) {
+				related = append(related, other)
+			}
+		}
+	}
+	if len(related) == 0 {
+		return
+	}
+	sort.Slice(related, func(i, j int) bool {
+		a, b := related[i], related[j]
+		if a.Pkg().Path() != b.Pkg().Path() {
+			return a.Pkg().Path() < b.Pkg().Path()
+		}
+		return a.Name() < b.Name()
+	})
+	title := "Implements"
+	if isInterface {
+		title = "Implemented By"
+	}
+	r.heading(w, 3, "%s", title)
+	for _, obj := range related {
+		fmt.Fprintf(w, "- %s\n", r.implementsLink(self, obj))
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *markdownRenderer) listable(obj *types.TypeName) bool {
+	return obj.Exported() || r.options.unexported || r.options.all
+}
+
+func satisfies(t types.Type, iface *types.Interface) bool {
+	return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+}
+
+// implementsLink renders obj as a link to its type heading: an anchor within
+// the same package, or the sibling package document in tree modes.
+func (r *markdownRenderer) implementsLink(self, obj *types.TypeName) string {
+	anchor := "#" + slugify(r.options.anchorFormat, "type "+obj.Name())
+	if obj.Pkg() == self.Pkg() {
+		return fmt.Sprintf("[%s](%s)", obj.Name(), anchor)
+	}
+	label := obj.Pkg().Name() + "." + obj.Name()
+	from, okFrom := r.implements.relDirs[self.Pkg().Path()]
+	to, okTo := r.implements.relDirs[obj.Pkg().Path()]
+	if absoluteLinks(r.options) && okTo {
+		return fmt.Sprintf("[%s](%s%s)", label, docURL(r.options, to), anchor)
+	}
+	if !r.implements.linkFiles || !okFrom || !okTo {
+		return fmt.Sprintf("`%s`", label)
+	}
+	return fmt.Sprintf("[%s](%s%s)", label, relativeDocLink(from, to, indexFileName(r.options)), anchor)
+}
+
+// relativeDocLink returns the slash-separated path from the document in
+// directory from to the file name in directory to.
+func relativeDocLink(from, to, name string) string {
+	fromParts := splitRelDir(from)
+	toParts := splitRelDir(to)
+	common := 0
+	for common < len(fromParts) && common < len(toParts) && fromParts[common] == toParts[common] {
+		common++
+	}
+	parts := make([]string, 0, len(fromParts)+len(toParts))
+	for range fromParts[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[common:]...)
+	parts = append(parts, name)
+	return path.Join(parts...)
+}
+
+func splitRelDir(dir string) []string {
+	dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	if dir == "." || dir == "" {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// canonicalImportPath returns the path named by a package clause import
+// comment (package foo // import "example.com/foo") in any of the package's
+// files, or "" when none carries one. The files are re-read because go/doc
+// strips comments from the loaded syntax trees.
+func canonicalImportPath(pkgInfo *packages.Package) string {
+	fset := token.NewFileSet()
+	for _, path := range pkgInfo.GoFiles {
+		file, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		line := fset.Position(file.Name.End()).Line
+		for _, group := range file.Comments {
+			if group.Pos() < file.Name.End() {
+				continue
+			}
+			if fset.Position(group.Pos()).Line != line {
+				break
+			}
+			if path := importCommentPath(group.List[0].Text); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// displayImportPath is the path shown in the package's import line.
+func (r *markdownRenderer) displayImportPath() string {
+	if r.importPath != "" {
+		return r.importPath
+	}
+	return r.pkg.ImportPath
+}
+
+func importCommentPath(comment string) string {
+	text, ok := strings.CutPrefix(comment, "//")
+	if !ok {
+		text = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSpace(text), "import ")
+	if !ok {
+		return ""
+	}
+	path, err := strconv.Unquote(strings.TrimSpace(rest))
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"strings"
+)
+
+// renderIndex writes a pkg.go.dev-style Index in place of the flat summary:
+// constants and variables, then functions, then types with their
+// constructors and methods nested beneath them. Entries link to their
+// sections when -all renders them.
+func (r *markdownRenderer) renderIndex(w io.Writer) {
+	var lines []string
+	entry := func(indent, label, heading string) {
+		if r.options.all {
+			lines = append(lines, fmt.Sprintf("%s- [%s](#%s)", indent, label, slugify(r.options.anchorFormat, heading)))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s- `%s`", indent, label))
+		}
+	}
+	showMain := r.pkg.Name != "main" || r.options.all
+	if r.wantsKind(kindConsts) && len(r.pkg.Consts) > 0 {
+		entry("", "Constants", "Constants")
+	}
+	if (showMain || r.options.includeMainVars) && r.wantsKind(kindVars) && len(r.pkg.Vars) > 0 {
+		entry("", "Variables", "Variables")
+	}
+	if (showMain || r.options.includeMainFuncs) && r.wantsKind(kindFuncs) {
+		for _, f := range r.pkg.Funcs {
+			entry("", r.signature(f.Decl), f.Name)
+		}
+	}
+	if r.wantsKind(kindTypes) {
+		for _, t := range r.pkg.Types {
+			entry("", "type "+t.Name, "type "+t.Name)
+			for _, f := range t.Funcs {
+				entry("  ", r.signature(f.Decl), f.Name)
+			}
+			for _, m := range t.Methods {
+				entry("  ", r.signature(m.Decl), funcHeading(t.Name, m.Decl))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	r.heading(w, 2, "Index")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// renderMethodIndex lists the signatures of t's methods, receivers elided, in
+// one code block shaped like an interface body.
+func (r *markdownRenderer) renderMethodIndex(w io.Writer, t *doc.Type) {
+	if len(t.Methods) == 0 {
+		return
+	}
+	r.heading(w, 3, "Method Index")
+	lines := make([]string, 0, len(t.Methods))
+	for _, m := range t.Methods {
+		lines = append(lines, m.Name+strings.TrimPrefix(r.formatNode(m.Decl.Type), "func"))
+	}
+	r.writeCodeBlock(w, strings.Join(lines, "\n"))
+}
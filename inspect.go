@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// inspectNode is one declaration of the go/doc model as `go-docmd inspect`
+// reports it. The shape follows go/doc and may change with it; it is a
+// diagnostic, not a stable schema.
+type inspectNode struct {
+	Kind     string        `json:"kind"`
+	Names    []string      `json:"names"`
+	Pos      string        `json:"pos,omitempty"`
+	DocLen   int           `json:"docLen"`
+	Children []inspectNode `json:"children,omitempty"`
+}
+
+type inspectPackage struct {
+	Name       string        `json:"name"`
+	ImportPath string        `json:"importPath"`
+	DocLen     int           `json:"docLen"`
+	Files      []string      `json:"files"`
+	Notes      int           `json:"notes"`
+	Examples   int           `json:"examples"`
+	Decls      []inspectNode `json:"decls"`
+}
+
+func newInspectCmd(root *cobra.Command) *cobra.Command {
+	var asJSON, unexported bool
+	cmd := &cobra.Command{
+		Use:   "inspect [package]",
+		Short: "Print the parsed go/doc model of a package",
+		Long: `Print the go/doc model go-docmd renders from: every declaration with its
+kind, names, position, and doc comment length, without Markdown rendering.
+Use it to see why a symbol does or does not appear in the output.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the model as JSON")
+	cmd.Flags().BoolVar(&unexported, "unexported", false, "include unexported declarations, as -u does")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		expr := "."
+		if len(args) == 1 {
+			expr = args[0]
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		pkgInfo, err := resolvePackage(withLoadCache(ctx), expr)
+		if err != nil {
+			return err
+		}
+		docPkg, err := buildDocPackage(pkgInfo, options{unexported: unexported})
+		if err != nil {
+			return err
+		}
+		model := inspectModel(docPkg, pkgInfo.Fset)
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(model)
+		}
+		return writeInspectText(cmd.OutOrStdout(), model)
+	}
+	return cmd
+}
+
+func inspectModel(pkg *doc.Package, fset *token.FileSet) inspectPackage {
+	model := inspectPackage{
+		Name:       pkg.Name,
+		ImportPath: pkg.ImportPath,
+		DocLen:     len(pkg.Doc),
+		Examples:   len(pkg.Examples),
+	}
+	for _, file := range pkg.Filenames {
+		model.Files = append(model.Files, filepath.Base(file))
+	}
+	for _, notes := range pkg.Notes {
+		model.Notes += len(notes)
+	}
+	pos := func(node ast.Node) string {
+		if node == nil || fset == nil || !node.Pos().IsValid() {
+			return ""
+		}
+		p := fset.Position(node.Pos())
+		return fmt.Sprintf("%s:%d", filepath.Base(p.Filename), p.Line)
+	}
+	values := func(kind string, vals []*doc.Value) []inspectNode {
+		var nodes []inspectNode
+		for _, v := range vals {
+			nodes = append(nodes, inspectNode{Kind: kind, Names: v.Names, Pos: pos(v.Decl), DocLen: len(v.Doc)})
+		}
+		return nodes
+	}
+	funcs := func(kind string, fns []*doc.Func) []inspectNode {
+		var nodes []inspectNode
+		for _, f := range fns {
+			nodes = append(nodes, inspectNode{Kind: kind, Names: []string{f.Name}, Pos: pos(f.Decl), DocLen: len(f.Doc)})
+		}
+		return nodes
+	}
+	model.Decls = append(model.Decls, values("const", pkg.Consts)...)
+	model.Decls = append(model.Decls, values("var", pkg.Vars)...)
+	model.Decls = append(model.Decls, funcs("func", pkg.Funcs)...)
+	for _, t := range pkg.Types {
+		node := inspectNode{Kind: "type", Names: []string{t.Name}, Pos: pos(t.Decl), DocLen: len(t.Doc)}
+		node.Children = append(node.Children, values("const", t.Consts)...)
+		node.Children = append(node.Children, values("var", t.Vars)...)
+		node.Children = append(node.Children, funcs("func", t.Funcs)...)
+		node.Children = append(node.Children, funcs("method", t.Methods)...)
+		model.Decls = append(model.Decls, node)
+	}
+	return model
+}
+
+func writeInspectText(w io.Writer, model inspectPackage) error {
+	fmt.Fprintf(w, "package %s %q (doc %d bytes, %d examples, %d notes)\n", model.Name, model.ImportPath, model.DocLen, model.Examples, model.Notes)
+	for _, file := range model.Files {
+		fmt.Fprintf(w, "  file %s\n", file)
+	}
+	var write func(nodes []inspectNode, indent string)
+	write = func(nodes []inspectNode, indent string) {
+		for _, n := range nodes {
+			fmt.Fprintf(w, "%s%s %v %s doc=%d\n", indent, n.Kind, n.Names, n.Pos, n.DocLen)
+			write(n.Children, indent+"  ")
+		}
+	}
+	write(model.Decls, "  ")
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"go/doc/comment"
+	"go/token"
+	"strings"
+)
+
+// brokenDocLinks returns a "file:line: ..." message for every [Symbol] doc
+// link in pkg that names a local symbol which does not exist. Links into
+// other packages are assumed valid.
+func brokenDocLinks(pkg *doc.Package, fset *token.FileSet) []string {
+	parser := pkg.Parser()
+	lookupSym := parser.LookupSym
+	// Treat every candidate as a link so typos surface instead of silently
+	// rendering as plain text.
+	parser.LookupSym = func(recv, name string) bool { return true }
+	var broken []string
+	check := func(pos token.Pos, owner, text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		for _, link := range docLinks(parser.Parse(text).Content) {
+			if link.ImportPath != "" && link.ImportPath != pkg.ImportPath {
+				continue
+			}
+			if link.Name == "" || lookupSym(link.Recv, link.Name) {
+				continue
+			}
+			target := link.Name
+			if link.Recv != "" {
+				target = link.Recv + "." + link.Name
+			}
+			broken = append(broken, fmt.Sprintf("%s: unresolved doc link [%s] in %s", positionString(fset, pos), target, owner))
+		}
+	}
+	var pkgPos token.Pos
+	if len(pkg.Filenames) > 0 {
+		fset.Iterate(func(f *token.File) bool {
+			if f.Name() == pkg.Filenames[0] {
+				pkgPos = f.Pos(0)
+				return false
+			}
+			return true
+		})
+	}
+	check(pkgPos, "package "+pkg.Name, pkg.Doc)
+	checkValues := func(values []*doc.Value) {
+		for _, v := range values {
+			check(v.Decl.Pos(), strings.Join(v.Names, ", "), v.Doc)
+		}
+	}
+	checkFuncs := func(funcs []*doc.Func, receiver string) {
+		for _, f := range funcs {
+			name := f.Name
+			if receiver != "" {
+				name = receiver + "." + f.Name
+			}
+			check(f.Decl.Pos(), name, f.Doc)
+		}
+	}
+	checkValues(pkg.Consts)
+	checkValues(pkg.Vars)
+	checkFuncs(pkg.Funcs, "")
+	for _, t := range pkg.Types {
+		check(t.Decl.Pos(), t.Name, t.Doc)
+		checkValues(t.Consts)
+		checkValues(t.Vars)
+		checkFuncs(t.Funcs, "")
+		checkFuncs(t.Methods, t.Name)
+	}
+	return broken
+}
+
+func docLinks(blocks []comment.Block) []*comment.DocLink {
+	var links []*comment.DocLink
+	var walkText func([]comment.Text)
+	walkText = func(texts []comment.Text) {
+		for _, t := range texts {
+			switch t := t.(type) {
+			case *comment.DocLink:
+				links = append(links, t)
+			case *comment.Link:
+				walkText(t.Text)
+			}
+		}
+	}
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *comment.Paragraph:
+			walkText(b.Text)
+		case *comment.Heading:
+			walkText(b.Text)
+		case *comment.List:
+			for _, item := range b.Items {
+				links = append(links, docLinks(item.Content)...)
+			}
+		}
+	}
+	return links
+}
+
+func positionString(fset *token.FileSet, pos token.Pos) string {
+	if !pos.IsValid() {
+		return "-"
+	}
+	p := fset.Position(pos)
+	return fmt.Sprintf("%s:%d", displayFilename(p.Filename), p.Line)
+}
+
+const stdlibLatest = "latest"
+
+// linkExternalDocLinks rewrites, for -external-doc-links, [pkg.Name] doc
+// links that point into other packages as Markdown links to pkg.go.dev.
+// Indented code lines and inline code spans are left untouched, as are local
+// links.
+func (r *markdownRenderer) linkExternalDocLinks(md string) string {
+	if !r.options.externalDocLinks || r.pkg == nil || !strings.Contains(md, "[") {
+		return md
+	}
+	parser := r.pkg.Parser()
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		if leadingWhitespace(line) > 0 && !isListLine(line) {
+			continue
+		}
+		lines[i] = r.linkLine(parser, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *markdownRenderer) linkLine(parser *comment.Parser, line string) string {
+	var b strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '`' {
+			inCode = !inCode
+		}
+		if c != '[' || inCode {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(line[i:], ']')
+		if end < 0 {
+			b.WriteString(line[i:])
+			break
+		}
+		end += i
+		next := byte(0)
+		if end+1 < len(line) {
+			next = line[end+1]
+		}
+		url := ""
+		if next != '(' && next != ':' && next != '[' {
+			url = r.docLinkURL(parser, line[i:end+1])
+		}
+		if url == "" {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%s(%s)", line[i:end+1], url)
+		i = end
+	}
+	return b.String()
+}
+
+// docLinkURL returns the pkg.go.dev URL for text when it is a doc link into
+// another package, or "" otherwise.
+func (r *markdownRenderer) docLinkURL(parser *comment.Parser, text string) string {
+	doc := parser.Parse(text)
+	if len(doc.Content) != 1 {
+		return ""
+	}
+	para, ok := doc.Content[0].(*comment.Paragraph)
+	if !ok || len(para.Text) != 1 {
+		return ""
+	}
+	link, ok := para.Text[0].(*comment.DocLink)
+	if !ok || link.ImportPath == "" || link.ImportPath == r.pkg.ImportPath {
+		return ""
+	}
+	url := "https://pkg.go.dev/" + link.ImportPath
+	if version := r.stdlibVersion(); version != "" && isStdImportPath(link.ImportPath) {
+		url += "@" + version
+	}
+	switch {
+	case link.Recv != "":
+		url += "#" + link.Recv + "." + link.Name
+	case link.Name != "":
+		url += "#" + link.Name
+	}
+	return url
+}
+
+// stdlibVersion resolves -stdlib-version to the Go version standard library
+// links pin to, or "" for unpinned links. "module" uses the go directive of
+// the documented package's module.
+func (r *markdownRenderer) stdlibVersion() string {
+	switch v := strings.TrimSpace(r.options.stdlibVersion); v {
+	case "", stdlibLatest:
+		return ""
+	case "module":
+		return normalizeGoVersion(r.goVersion)
+	default:
+		return normalizeGoVersion(v)
+	}
+}
+
+func isStdImportPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type lintIssue struct {
+	pos  token.Position
+	name string
+}
+
+func lintPackages(ctx context.Context, patterns []string, opts options) error {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	cfg := &packages.Config{
+		Mode: loadMode(ctx),
+	}
+	pkgs, err := loadPackages(ctx, cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("no Go packages matched %q", strings.Join(patterns, " "))
+	}
+	var issues []lintIssue
+	for _, pkgInfo := range pkgs {
+		if len(pkgInfo.Errors) > 0 {
+			return fmt.Errorf("%s", pkgInfo.Errors[0])
+		}
+		docPkg, err := buildDocPackage(pkgInfo, opts)
+		if err != nil {
+			return err
+		}
+		if docPkg.Name == "main" && !opts.showCmd && !opts.all {
+			continue
+		}
+		issues = append(issues, undocumentedSymbols(docPkg, pkgInfo.Fset, opts)...)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i].pos, issues[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Line < b.Line
+	})
+	for _, issue := range issues {
+		qualifier := "exported "
+		if !token.IsExported(lastSegment(issue.name)) {
+			qualifier = ""
+		}
+		opts.warnings.warnf("%s:%d: %s%s is undocumented", displayFilename(issue.pos.Filename), issue.pos.Line, qualifier, issue.name)
+	}
+	return fmt.Errorf("%d undocumented symbol(s)", len(issues))
+}
+
+func undocumentedSymbols(pkg *doc.Package, fset *token.FileSet, opts options) []lintIssue {
+	var issues []lintIssue
+	visitSymbols(pkg, func(pos token.Pos, name string, documented bool) {
+		if documented || (!opts.unexported && !opts.all && !token.IsExported(lastSegment(name))) {
+			return
+		}
+		issues = append(issues, lintIssue{pos: fset.Position(pos), name: name})
+	})
+	return issues
+}
+
+// docCoverage counts the exported symbols of pkg and how many of them have
+// a doc comment, as -lint-undocumented judges them.
+func docCoverage(pkg *doc.Package) (documented, total int) {
+	visitSymbols(pkg, func(_ token.Pos, name string, ok bool) {
+		if !token.IsExported(lastSegment(name)) {
+			return
+		}
+		total++
+		if ok {
+			documented++
+		}
+	})
+	return documented, total
+}
+
+// coverageBadge is the -coverage-badges note for pkg, "85% documented", or
+// "" when it exports nothing.
+func coverageBadge(pkg *doc.Package) string {
+	documented, total := docCoverage(pkg)
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d%% documented", documented*100/total)
+}
+
+// visitSymbols calls visit for every declared name in pkg, reporting whether
+// a doc comment covers it. Values count as documented through their group's
+// comment or their own line's.
+func visitSymbols(pkg *doc.Package, visit func(pos token.Pos, name string, documented bool)) {
+	checkValues := func(values []*doc.Value) {
+		for _, v := range values {
+			groupDoc := strings.TrimSpace(v.Doc) != ""
+			for _, spec := range v.Decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for _, ident := range vs.Names {
+					if ident.Name != "_" {
+						visit(ident.Pos(), ident.Name, groupDoc || vs.Doc != nil || vs.Comment != nil)
+					}
+				}
+			}
+		}
+	}
+	checkFuncs := func(funcs []*doc.Func, receiver string) {
+		for _, f := range funcs {
+			name := f.Name
+			if receiver != "" {
+				name = receiver + "." + f.Name
+			}
+			visit(f.Decl.Name.Pos(), name, strings.TrimSpace(f.Doc) != "")
+		}
+	}
+	checkValues(pkg.Consts)
+	checkValues(pkg.Vars)
+	checkFuncs(pkg.Funcs, "")
+	for _, t := range pkg.Types {
+		pos := t.Decl.Pos()
+		if spec := findTypeSpec(t.Decl, t.Name); spec != nil {
+			pos = spec.Name.Pos()
+		}
+		visit(pos, t.Name, strings.TrimSpace(t.Doc) != "")
+		checkValues(t.Consts)
+		checkValues(t.Vars)
+		checkFuncs(t.Funcs, "")
+		checkFuncs(t.Methods, t.Name)
+	}
+}
+
+func lastSegment(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+func displayFilename(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// listPackages prints one tab-separated line per package the tree pattern
+// root expands to: import path, directory (relative to the working directory
+// when beneath it), and "main" or "package". Nothing is rendered.
+func listPackages(ctx context.Context, positionals []string, opts options, stdout io.Writer) error {
+	if len(positionals) > 1 {
+		return errors.New("-list-packages accepts at most one package argument")
+	}
+	root := "."
+	if len(positionals) == 1 {
+		root = positionals[0]
+	}
+	pkgs, err := loadPackageTree(ctx, root, !opts.noRecurse)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("no packages matched %q", root)
+	}
+	wd, _ := os.Getwd()
+	for _, pkg := range pkgs {
+		dir := absolutePath(packageDir(pkg))
+		if wd != "" && containsAllDirs(wd, []string{dir}) {
+			if rel, err := filepath.Rel(wd, dir); err == nil {
+				dir = rel
+			}
+		}
+		kind := "package"
+		if pkg.Name == "main" {
+			kind = "main"
+		}
+		if _, err := fmt.Fprintf(stdout, "%s\t%s\t%s\n", pkg.PkgPath, filepath.ToSlash(dir), kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
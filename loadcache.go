@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type loadCacheKey struct{}
+
+// loadCache memoizes packages.Load results for the lifetime of one run, so
+// features that resolve the same package more than once share a single load.
+type loadCache struct {
+	mu      sync.Mutex
+	entries map[string]*loadEntry
+}
+
+type loadEntry struct {
+	done chan struct{}
+	pkgs []*packages.Package
+	err  error
+}
+
+// withLoadCache returns a context whose loads go through a fresh cache.
+func withLoadCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadCacheKey{}, &loadCache{entries: make(map[string]*loadEntry)})
+}
+
+type loadTimeoutKey struct{}
+
+// withLoadTimeout returns a context in which each loadPackages call gives up
+// after d (-timeout).
+func withLoadTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, loadTimeoutKey{}, d)
+}
+
+// isLoadTimeout reports whether err is a load that ran out of -timeout.
+func isLoadTimeout(err error) bool {
+	var kinded *kindedError
+	return errors.As(err, &kinded) && kinded.kind == kindTimeout
+}
+
+// loadPackages is packages.Load with cfg.Context set to ctx, served from the
+// run's cache when ctx carries one. Under withLoadTimeout the load is
+// bounded, and running out of time is reported as a timeout.
+func loadPackages(ctx context.Context, cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+	d, _ := ctx.Value(loadTimeoutKey{}).(time.Duration)
+	if d <= 0 {
+		return loadPackagesCached(ctx, cfg, patterns...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	pkgs, err := loadPackagesCached(ctx, cfg, patterns...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, withKind(kindTimeout, "", fmt.Errorf("timed out loading packages after %s (-timeout)", d))
+	}
+	return pkgs, err
+}
+
+// loadPackagesCached is loadPackages without the timeout. Failed and
+// cancelled loads are not cached.
+func loadPackagesCached(ctx context.Context, cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+	cfg.Context = ctx
+	cache, _ := ctx.Value(loadCacheKey{}).(*loadCache)
+	if cache == nil {
+		return packages.Load(cfg, patterns...)
+	}
+	key := loadKey(cfg, patterns)
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	if !ok {
+		entry = &loadEntry{done: make(chan struct{})}
+		cache.entries[key] = entry
+	}
+	cache.mu.Unlock()
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.pkgs, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	entry.pkgs, entry.err = packages.Load(cfg, patterns...)
+	if entry.err == nil && ctx.Err() != nil {
+		entry.pkgs, entry.err = nil, ctx.Err()
+	}
+	if entry.err != nil {
+		cache.mu.Lock()
+		delete(cache.entries, key)
+		cache.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.pkgs, entry.err
+}
+
+func loadKey(cfg *packages.Config, patterns []string) string {
+	goos, goarch := os.Getenv("GOOS"), os.Getenv("GOARCH")
+	for _, kv := range cfg.Env {
+		if v, ok := strings.CutPrefix(kv, "GOOS="); ok {
+			goos = v
+		}
+		if v, ok := strings.CutPrefix(kv, "GOARCH="); ok {
+			goarch = v
+		}
+	}
+	return fmt.Sprintf("%d|%t|%s|%s/%s|%s|%s", cfg.Mode, cfg.Tests, cfg.Dir, goos, goarch,
+		strings.Join(cfg.BuildFlags, " "), strings.Join(patterns, " "))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"go/token"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type loadModeKey struct{}
+
+// syntaxLoadMode is what every rendering needs: the files, their syntax for
+// go/doc, and the module for import paths and go directives.
+const syntaxLoadMode = packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedFiles |
+	packages.NeedSyntax | packages.NeedModule
+
+// loadModeFor returns the packages.LoadMode a run with opts needs. Without
+// -use-export-data it is the full packageLoadMode. With it, packages are
+// only parsed, and type checking (which reads dependencies' export data) is
+// requested just for the features that consult types; symbolArgs reports
+// whether a symbol argument may need promoted struct fields resolved.
+func loadModeFor(opts options, symbolArgs bool) packages.LoadMode {
+	if !opts.useExportData {
+		return packageLoadMode
+	}
+	mode := packages.LoadMode(syntaxLoadMode)
+	if opts.deps || opts.implements {
+		mode |= packages.NeedImports
+	}
+	if symbolArgs || opts.callers || opts.signatureLinks || opts.enumTable || opts.varTable || opts.flattenInterfaces ||
+		opts.implements || opts.apiSchema {
+		mode |= packages.NeedTypes | packages.NeedTypesInfo | packages.NeedTypesSizes
+	}
+	if mode&packages.NeedImports != 0 && mode&packages.NeedTypes != 0 {
+		// As in packageLoadMode, cgo packages need typed dependencies.
+		mode |= packages.NeedDeps
+	}
+	return mode
+}
+
+// requestsSymbol reports whether the positional arguments may name an
+// exported symbol or a member of one, in any of the ways buildCandidates
+// reads them. The last-resort reading of a lone argument as an unexported
+// local symbol does not count, so a plain package argument stays light.
+func requestsSymbol(positionals []string) bool {
+	candidates, err := buildCandidates(positionals)
+	if err != nil {
+		return len(positionals) > 1
+	}
+	for _, cand := range candidates {
+		if token.IsIdentifier(cand.method) || token.IsExported(cand.symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// withLoadMode returns a context whose package loads use mode.
+func withLoadMode(ctx context.Context, mode packages.LoadMode) context.Context {
+	return context.WithValue(ctx, loadModeKey{}, mode)
+}
+
+// loadMode is the packages.LoadMode of the run ctx belongs to, packageLoadMode
+// unless withLoadMode chose another.
+func loadMode(ctx context.Context) packages.LoadMode {
+	if mode, ok := ctx.Value(loadModeKey{}).(packages.LoadMode); ok {
+		return mode
+	}
+	return packageLoadMode
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadLooseDirectory parses the .go files of a directory outside any module
+// directly, for when packages.Load cannot resolve it. The result has syntax
+// but no type information or import path, so only doc comments and
+// signatures render. ok is false when pattern is not such a directory.
+func loadLooseDirectory(pattern string) (pkg *packages.Package, ok bool) {
+	dir, err := filepath.Abs(pattern)
+	if err != nil {
+		return nil, false
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() || insideModule(dir) {
+		return nil, false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	fset := token.NewFileSet()
+	byName := make(map[string][]*ast.File)
+	paths := make(map[string][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		byName[file.Name.Name] = append(byName[file.Name.Name], file)
+		paths[file.Name.Name] = append(paths[file.Name.Name], path)
+	}
+	if len(byName) == 0 {
+		return nil, false
+	}
+	// Mixed package clauses cannot build; document the most common one.
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(byName[names[i]]) != len(byName[names[j]]) {
+			return len(byName[names[i]]) > len(byName[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	name := names[0]
+	return &packages.Package{
+		ID:              dir,
+		Name:            name,
+		GoFiles:         paths[name],
+		CompiledGoFiles: paths[name],
+		Syntax:          byName[name],
+		Fset:            fset,
+	}, true
+}
+
+// insideModule reports whether dir or one of its parents holds a go.mod or
+// go.work file, in which case packages.Load failures are real errors.
+func insideModule(dir string) bool {
+	for {
+		for _, name := range []string{"go.mod", "go.work"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
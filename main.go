@@ -1,13 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
 
 func main() {
 	if err := run(os.Args[1:], os.Stdout); err != nil {
-		fmt.Fprintln(os.Stderr, "go-docmd:", err)
+		var reported *reportedError
+		if !errors.As(err, &reported) {
+			fmt.Fprintln(os.Stderr, "go-docmd:", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -1,12 +1,34 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
 	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
 )
 
 func TestPackageMarkdown(t *testing.T) {
@@ -35,7 +57,106 @@ func TestMethodMarkdown(t *testing.T) {
 	if err := run([]string{"./testdata/example.Greeter.Greet"}, &buf); err != nil {
 		t.Fatalf("run: %v", err)
 	}
-	assertContains(t, buf.String(), "#### Greeter.Greet")
+	assertContains(t, buf.String(), "#### (\\*Greeter) Greet")
+}
+
+func TestFuncHeadingShowsReceiverKind(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "recv.go", "package p\nfunc (T) Value() {}\nfunc (t *T) Pointer() {}\nfunc Plain() {}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"(T) Value", "(\\*T) Pointer", "Plain"}
+	for i, decl := range file.Decls {
+		fn := decl.(*ast.FuncDecl)
+		receiver := ""
+		if fn.Recv != nil {
+			receiver = "T"
+		}
+		if got := funcHeading(receiver, fn); got != want[i] {
+			t.Fatalf("funcHeading(%s) = %q, want %q", fn.Name.Name, got, want[i])
+		}
+	}
+	if got := funcHeading("T", file.Decls[2].(*ast.FuncDecl)); got != "T.Plain" {
+		t.Fatalf("constructor heading = %q, want T.Plain", got)
+	}
+}
+
+func TestBenchmarksSectionListsBenchmarks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-benchmarks", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Benchmarks\n\n- `BenchmarkGreet` — BenchmarkGreet measures building a greeting. It reuses one Greeter across iterations.\n- `BenchmarkNewGreeter`\n\n")
+	if strings.Contains(out, "Benchmarkish") {
+		t.Fatalf("did not expect non-benchmark functions\n\n%s", out)
+	}
+}
+
+func TestStripCopyright(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"// Copyright 2024 Example Corp.\n// Use of this source is governed by a BSD license.\n//\n// Run starts the job.\nfunc Run() {}", "// Run starts the job.\nfunc Run() {}"},
+		{"// SPDX-License-Identifier: MIT\nfunc Run() {}", "func Run() {}"},
+		{"/*\nCopyright 2024 Example Corp.\n*/\nfunc Run() {}", "func Run() {}"},
+		{"// Run starts the job.\n//\n// Copyright notices further down stay.\nfunc Run() {}", "// Run starts the job.\n//\n// Copyright notices further down stay.\nfunc Run() {}"},
+		{"func Run() {}", "func Run() {}"},
+	}
+	for _, tt := range tests {
+		if got := stripCopyright(tt.in); got != tt.want {
+			t.Errorf("stripCopyright(%q)\n got: %q\nwant: %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownToConfluence(t *testing.T) {
+	md := "# package demo\n\nRun starts `a < b` jobs; see [Job](#job).\n\n- one\n  - nested\n- two\n\n| Name | Value |\n| --- | --- |\n| `A\\|B` | 1 |\n\n> [!WARNING]\n> Close twice **panics**.\n\n```go\nfunc Run() {}\n```\n"
+	got := string(markdownToConfluence([]byte(md)))
+	want := "<h1>package demo</h1>\n" +
+		"<p>Run starts <code>a &lt; b</code> jobs; see <a href=\"#job\">Job</a>.</p>\n" +
+		"<ul><li>one<ul><li>nested</li></ul></li><li>two</li></ul>\n" +
+		"<table><tbody><tr><th>Name</th><th>Value</th></tr><tr><td><code>A|B</code></td><td>1</td></tr></tbody></table>\n" +
+		"<ac:structured-macro ac:name=\"warning\"><ac:rich-text-body><p>Close twice <strong>panics</strong>.</p></ac:rich-text-body></ac:structured-macro>\n" +
+		"<ac:structured-macro ac:name=\"code\"><ac:parameter ac:name=\"language\">go</ac:parameter><ac:plain-text-body><![CDATA[func Run() {}]]></ac:plain-text-body></ac:structured-macro>\n"
+	if got != want {
+		t.Fatalf("markdownToConfluence mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestConfluenceFormatWritesXMLTree(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-format", "confluence", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "README.xml"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(content), "<h1>package example</h1>")
+	assertContains(t, string(content), `<a href="subpkg/README.xml">subpkg</a>`)
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg", "README.xml")); err != nil {
+		t.Fatalf("expected subpkg/README.xml: %v", err)
+	}
+}
+
+func TestEmbedExamplesInlineMentionedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"embed.go":             "package demo\n\nimport _ \"embed\"\n\n//go:embed testdata/config.yaml \"notes.txt\"\nvar files string\n",
+		"testdata/config.yaml": "port: 8080\n",
+		"notes.txt":            "unused\n",
+	})
+	r := markdownRenderer{embeds: packageEmbeds(&packages.Package{GoFiles: []string{filepath.Join(dir, "embed.go")}})}
+	if len(r.embeds) != 2 {
+		t.Fatalf("expected 2 embedded files, got %+v", r.embeds)
+	}
+	got := r.docMarkdown("Load reads config.yaml from the package.")
+	want := "Load reads config.yaml from the package.\n\n`testdata/config.yaml`:\n\n```yaml\nport: 8080\n```"
+	if got != want {
+		t.Fatalf("docMarkdown mismatch\n got: %q\nwant: %q", got, want)
+	}
+	if got := r.docMarkdown("Mynotes.txt is a different file."); strings.Contains(got, "```") {
+		t.Fatalf("did not expect a partial name match\n\n%s", got)
+	}
 }
 
 func TestOutputFlagWritesFile(t *testing.T) {
@@ -51,6 +172,58 @@ func TestOutputFlagWritesFile(t *testing.T) {
 	assertContains(t, string(content), "type Greeter")
 }
 
+// TestPipeHelperProcess is the command -o pipe tests run: the test binary
+// itself, so the tests need no platform tools. It copies its input to its
+// output, or with GO_DOCMD_PIPE_HELPER=fail exits non-zero.
+func TestPipeHelperProcess(t *testing.T) {
+	switch os.Getenv("GO_DOCMD_PIPE_HELPER") {
+	case "":
+		return
+	case "fail":
+		os.Exit(1)
+	}
+	io.Copy(os.Stdout, os.Stdin)
+	os.Exit(0)
+}
+
+func TestOutputPipeFeedsCommand(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("no test executable: %v", err)
+	}
+	if strings.ContainsAny(exe, " \t") {
+		t.Skipf("test executable path %q has white space", exe)
+	}
+	helper := "|" + exe + " -test.run=^TestPipeHelperProcess$"
+
+	t.Setenv("GO_DOCMD_PIPE_HELPER", "copy")
+	var out bytes.Buffer
+	if err := run([]string{"-o", helper, "./testdata/example.Greeter"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, out.String(), "type Greeter")
+	if _, err := os.Stat(helper); !os.IsNotExist(err) {
+		t.Fatalf("pipe output created a file or directory: %v", err)
+	}
+
+	out.Reset()
+	if err := run([]string{"-dry-run", "-o", helper, "./testdata/example.Greeter"}, &out); err != nil {
+		t.Fatalf("run -dry-run: %v", err)
+	}
+	if got, want := out.String(), "pipe "+strings.TrimPrefix(helper, "|")+"\n"; got != want {
+		t.Fatalf("expected -dry-run to plan the pipe without running it\n got: %q\nwant: %q", got, want)
+	}
+
+	t.Setenv("GO_DOCMD_PIPE_HELPER", "fail")
+	err = run([]string{"-o", helper, "./testdata/example.Greeter"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "exit status 1") {
+		t.Fatalf("expected the non-zero exit to fail the run, got %v", err)
+	}
+	if err := run([]string{"-o", "| ", "./testdata/example.Greeter"}, io.Discard); err == nil {
+		t.Fatal("expected an empty pipe command to be rejected")
+	}
+}
+
 func TestDirectoryOutputWritesTree(t *testing.T) {
 	tmp := t.TempDir()
 	if err := run([]string{"-mainvars", "-mainfuncs", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
@@ -75,98 +248,3096 @@ func TestDirectoryOutputWritesTree(t *testing.T) {
 	assertContains(t, string(subContent), "Message exposes a sample constant")
 }
 
-func TestInPlaceModeWritesPackageReadmes(t *testing.T) {
-	rootPattern := "./testdata/example"
-	rootDir := filepath.Clean(rootPattern)
-	rootReadme := filepath.Join(rootDir, "README.md")
-	subReadme := filepath.Join(rootDir, "subpkg", "README.md")
-	cleanup := func() {
-		_ = os.Remove(rootReadme)
-		_ = os.Remove(subReadme)
-	}
-	cleanup()
-	t.Cleanup(cleanup)
-	if err := run([]string{"-mainvars", "-mainfuncs", "-inplace", rootPattern}, io.Discard); err != nil {
+func TestOutputPatternWritesFilePerPackage(t *testing.T) {
+	tmp := t.TempDir()
+	pattern := filepath.Join(tmp, "{dir}", "{pkg}.md")
+	if err := run([]string{"-mainvars", "-mainfuncs", "-o", pattern, "./testdata/example"}, io.Discard); err != nil {
 		t.Fatalf("run: %v", err)
 	}
-	rootContent, err := os.ReadFile(rootReadme)
+	rootContent, err := os.ReadFile(filepath.Join(tmp, "example.md"))
 	if err != nil {
 		t.Fatalf("read root: %v", err)
 	}
-	rootStr := string(rootContent)
-	assertContains(t, rootStr, "# package example")
-	assertContains(t, rootStr, "## Packages")
-	assertContains(t, rootStr, "subpkg/README.md")
-	assertTOCAfterDoc(t, rootStr, "# package example", "## Packages")
-	subContent, err := os.ReadFile(subReadme)
+	assertContains(t, string(rootContent), "# package example")
+	subContent, err := os.ReadFile(filepath.Join(tmp, "subpkg", "subpkg.md"))
 	if err != nil {
-		t.Fatalf("read subpkg readme: %v", err)
+		t.Fatalf("read subpkg: %v", err)
 	}
 	assertContains(t, string(subContent), "# package subpkg")
-	assertContains(t, string(subContent), "Message exposes a sample constant")
+
+	err = run([]string{"-o", filepath.Join(tmp, "{dir}", "{name}.md"), "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "{name}") {
+		t.Fatalf("expected unknown placeholder error, got %v", err)
+	}
+
+	literal := filepath.Join(tmp, "{draft}", "API.md")
+	if err := run([]string{"-o", literal, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, err := os.ReadFile(literal); err != nil || !strings.Contains(string(data), "# package example") {
+		t.Fatalf("expected braces without a placeholder to stay in the path: %v", err)
+	}
 }
 
-func assertContains(t *testing.T, haystack, needle string) {
-	t.Helper()
-	if !strings.Contains(haystack, needle) {
-		t.Fatalf("expected output to contain %q\n\n%s", needle, haystack)
+func TestFlattenInterfacesListsMethodOrigins(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-flatten-interfaces", "./testdata/streams"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "### Method Set\n\n- `Close() error`\n- `Read(p []byte) (n int, err error)` (from `Reader`)\n- `Write(p []byte) (n int, err error)` (from `Writer`)\n\n")
+	if strings.Count(out, "### Method Set") != 2 {
+		t.Fatalf("expected method sets only for the embedding interfaces\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/streams"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "### Method Set") {
+		t.Fatalf("did not expect a method set without -flatten-interfaces\n\n%s", buf.String())
 	}
 }
 
-func assertTOCAfterDoc(t *testing.T, text, docHeading, tocHeading string) {
-	t.Helper()
-	docIdx := strings.Index(text, docHeading)
-	tocIdx := strings.Index(text, tocHeading)
-	if docIdx == -1 || tocIdx == -1 {
-		t.Fatalf("missing doc heading %q or toc heading %q", docHeading, tocHeading)
+func TestTOCSummaryLenTruncatesAtWord(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-toc-summary-len", "30", "-o", tmp, "./testdata/nested/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
 	}
-	if tocIdx <= docIdx {
-		t.Fatalf("expected %q to appear after %q\n\n%s", tocHeading, docHeading, text)
+	content, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(content), "(inner/README.md) — Package inner is the only…\n")
+	if got := truncateAtWord("Short summary.", 30); got != "Short summary." {
+		t.Fatalf("truncateAtWord kept %q, want it unchanged", got)
+	}
+	for limit := 1; limit <= 12; limit++ {
+		if got := truncateAtWord("Package inner is nested.", limit); utf8.RuneCountInString(got) > limit {
+			t.Fatalf("truncateAtWord(_, %d) = %q, longer than the limit", limit, got)
+		}
+	}
+	if err := run([]string{"-toc-summary-len", "-1", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected a negative -toc-summary-len to fail")
 	}
 }
 
-func TestHelpFlag(t *testing.T) {
+func TestNoteInterfacesInPackageSummary(t *testing.T) {
 	var buf bytes.Buffer
-	if err := run([]string{"--help"}, &buf); err != nil {
+	if err := run([]string{"-note-interfaces", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `type Greeter` — Greeter produces greeting messages. (io.Closer)\n")
+
+	src := "package p\n\ntype T struct{}\n\nfunc (T) Close() error { return nil }\nfunc (T) Error() string { return \"\" }\nfunc (T) Read(p []byte) (int, error) { return 0, nil }\nfunc (T) String() string { return \"\" }\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{file}, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := typeInterfacesNote(pkg.Types[0]); got != "(io.Closer, error, io.Reader, …)" {
+		t.Fatalf("typeInterfacesNote = %q", got)
+	}
+}
+
+func TestProgressReportsPackages(t *testing.T) {
+	var stderr bytes.Buffer
+	cmd := newRootCmd(io.Discard, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-progress", "-o", t.TempDir(), "./testdata/nested/..."}))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := stderr.String(); got != "[1/1] github.com/agentflare-ai/go-docmd/testdata/nested/inner\n" {
+		t.Fatalf("progress output = %q", got)
+	}
+}
+
+func TestIncludeIgnoredDocumentsTools(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-include-ignored", "./testdata/tools"}, &buf); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	out := buf.String()
-	assertContains(t, out, "go-docmd [flags] [package|[package.]symbol[.method]]")
-	assertContains(t, out, "--all")
-	assertContains(t, out, "completion  Generate shell completion scripts")
+	assertContains(t, out, "## Tools\n\n### gen.go\n\n```sh\ngo run gen.go\n```\n\nGen rewrites the Version constant")
+	if strings.Contains(out, "tools_windows.go") {
+		t.Fatalf("expected platform-excluded files to stay out of Tools\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"./testdata/tools"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "## Tools") {
+		t.Fatalf("did not expect Tools without -include-ignored\n\n%s", buf.String())
+	}
 }
 
-func TestCompletionCommand(t *testing.T) {
+func TestGenericMethodHeadingsKeepTypeParams(t *testing.T) {
 	var buf bytes.Buffer
-	if err := run([]string{"completion", "bash"}, &buf); err != nil {
+	if err := run([]string{"-all", "-index", "./testdata/generic"}, &buf); err != nil {
 		t.Fatalf("run: %v", err)
 	}
-	if buf.Len() == 0 {
-		t.Fatalf("expected completion output")
+	out := buf.String()
+	assertContains(t, out, "#### (\\*List[T]) Append\n")
+	assertContains(t, out, "#### (List[T]) Len\n")
+	assertContains(t, out, "#### (Pair[K, V]) First\n")
+	assertContains(t, out, "  - [func (l *List[T]) Append(v T)](#listt-append)\n")
+}
+
+func TestValidateLinksReportsDanglingTargets(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-validate-links", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("expected the generated tree to link cleanly: %v", err)
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":     "module example.com/guide\n\ngo 1.21\n",
+		"guide.go":   "// Package guide links to the [guide](GUIDE.md) and the [child](sub/README.md).\npackage guide\n",
+		"sub/sub.go": "// Package sub is documented.\npackage sub\n",
+	})
+	t.Chdir(dir)
+	var stderr bytes.Buffer
+	cmd := newRootCmd(io.Discard, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-validate-links", "-inplace", "./..."}))
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "1 dangling link(s)") {
+		t.Fatalf("expected one dangling link, got %v", err)
+	}
+	assertContains(t, stderr.String(), "README.md: dangling link to GUIDE.md")
+	if err := os.WriteFile("GUIDE.md", []byte("# Guide\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-validate-links", "-inplace", "./..."}, io.Discard); err != nil {
+		t.Fatalf("expected links to resolve once GUIDE.md exists: %v", err)
 	}
-	assertContains(t, buf.String(), "__start_go-docmd")
 }
 
-func TestGenDocsCommand(t *testing.T) {
+func TestSentenceTerminatorsSummaries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/i18n"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "- `func Bonjour() string` — Bonjour renvoie la salutation « bonjour » de fr.Locale.\n")
+	assertContains(t, out, "- `func Attention()` — Attention ! Cette fonction efface tout.\n")
+
+	if got := firstSentence("Package i18n はドキュメントの要約を検証します。二文目は要約に含まれません。", ""); got != "Package i18n はドキュメントの要約を検証します。" {
+		t.Fatalf("firstSentence = %q, want the first Japanese sentence", got)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "X does A.Y does B.", want: "X does A."},
+		{text: "Run starts the Server.Then it waits.", want: "Run starts the Server."},
+		{text: "Read wraps io.Reader for callers.", want: "Read wraps io.Reader for callers."},
+		{text: "Use a prefix, e.g.Foo, for names.", want: "Use a prefix, e.g.Foo, for names."},
+		{text: "Since v1.2 it retries. Later text.", want: "Since v1.2 it retries."},
+		{text: "Greet calls [Greeter.Greet] once.", want: "Greet calls [Greeter.Greet] once."},
+	}
+	for _, tt := range tests {
+		if got := firstSentence(tt.text, ""); got != tt.want {
+			t.Errorf("firstSentence(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	buf.Reset()
+	if err := run([]string{"-sentence-terminators", ".!", "./testdata/i18n"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `func Attention()` — Attention !\n")
+}
+
+func TestSignatureLinksListReferencedSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-signature-links", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "func CountMoods(greeters []*Greeter) (counts map[string]int, dominant Mood)\n```\n\n**References:** [Greeter](#type-greeter), [Mood](#type-mood)\n\n")
+	buf.Reset()
+	if err := run([]string{"-signature-links", "./testdata/example", "CountMoods"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "**References:** [Greeter](https://pkg.go.dev/github.com/agentflare-ai/go-docmd/testdata/example#Greeter), [Mood](https://pkg.go.dev/github.com/agentflare-ai/go-docmd/testdata/example#Mood)\n")
+	buf.Reset()
+	if err := run([]string{"-signature-links", "./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "**References:** [Mood](#type-mood)\n")
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "**References:**") {
+		t.Fatalf("did not expect references without -signature-links\n\n%s", buf.String())
+	}
+}
+
+func TestNoSignaturesRendersProseOnly(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-no-signatures", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### NewGreeter\n\nNewGreeter constructs a Greeter.\n\n")
+	assertContains(t, out, "## type Greeter\n\nGreeter produces greeting messages.")
+	if strings.Contains(out, "```go\nfunc ") || strings.Contains(out, "```go\ntype ") {
+		t.Fatalf("expected no signature code blocks\n\n%s", out)
+	}
+	if err := run([]string{"-no-signatures", "-src", "./testdata/example", "NewGreeter"}, io.Discard); err == nil {
+		t.Fatal("expected -no-signatures with -src to fail")
+	}
+}
+
+func TestUseExportDataChoosesLoadMode(t *testing.T) {
+	if got := loadModeFor(options{}, false); got != packageLoadMode {
+		t.Fatalf("default mode = %v, want packageLoadMode", got)
+	}
+	if got := loadModeFor(options{useExportData: true}, false); got&packages.NeedTypesInfo != 0 || got&packages.NeedSyntax == 0 {
+		t.Fatalf("plain -use-export-data mode = %v, want syntax without types", got)
+	}
+	if got := loadModeFor(options{useExportData: true, enumTable: true}, false); got&packages.NeedTypesInfo == 0 {
+		t.Fatalf("-enum-table mode = %v, want type information", got)
+	}
+	if got := loadModeFor(options{useExportData: true, deps: true}, false); got&packages.NeedImports == 0 {
+		t.Fatalf("-deps mode = %v, want imports", got)
+	}
+
+	var full, light bytes.Buffer
+	if err := run([]string{"-all", "./testdata/example"}, &full); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if err := run([]string{"-all", "-use-export-data", "./testdata/example"}, &light); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if full.String() != light.String() {
+		t.Fatalf("-use-export-data changed the output\n\n%s", light.String())
+	}
+
+	// A member lookup spelled as one compound argument needs types as much
+	// as the two-argument form does.
+	if !requestsSymbol([]string{"./testdata/embed.Record.ID"}) || requestsSymbol([]string{"./testdata/embed"}) {
+		t.Fatal("requestsSymbol misread the compound and package arguments")
+	}
+	light.Reset()
+	if err := run([]string{"-use-export-data", "./testdata/embed.Record.ID"}, &light); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, light.String(), "*Promoted from embedded `Base`.*")
+}
+
+func TestCoverageBadgesInPackageList(t *testing.T) {
 	tmp := t.TempDir()
-	if err := run([]string{"gen-docs", tmp}, io.Discard); err != nil {
+	if err := run([]string{"-coverage-badges", "-o", tmp, "./testdata/nested/..."}, io.Discard); err != nil {
 		t.Fatalf("run: %v", err)
 	}
-	files, err := os.ReadDir(tmp)
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
 	if err != nil {
-		t.Fatalf("readdir: %v", err)
+		t.Fatalf("read root: %v", err)
 	}
-	if len(files) == 0 {
-		t.Fatalf("expected CLI docs to be written")
+	assertContains(t, string(root), "below testdata/nested. *(100% documented)*\n")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "testdata/undocumented/undocumented.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
 	}
-	var foundRoot bool
-	for _, f := range files {
-		if f.Name() == "go-docmd.md" {
-			foundRoot = true
-			break
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{file}, "undocumented")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := coverageBadge(pkg); got != "25% documented" {
+		t.Fatalf("coverageBadge = %q, want 25%% documented", got)
+	}
+}
+
+func TestMaxMethodsListsOverflow(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-max-methods", "2", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### (\\*Greeter) Close")
+	assertContains(t, out, "#### (\\*Greeter) Farewell")
+	assertContains(t, out, "### Additional Methods (1 more)\n\n- `func (g *Greeter) Greet() string` — Greet returns a friendly message.\n\n")
+	if strings.Contains(out, "#### (\\*Greeter) Greet") {
+		t.Fatalf("expected Greet only in the overflow list\n\n%s", out)
+	}
+	if err := run([]string{"-max-methods", "-1", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected a negative -max-methods to fail")
+	}
+}
+
+func TestBuildDocPackageLeavesCachedSyntaxAlone(t *testing.T) {
+	ctx := withLoadCache(context.Background())
+	pkgInfo, err := resolvePackage(ctx, "./testdata/example")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if _, err := buildDocPackage(pkgInfo, options{}); err != nil {
+		t.Fatalf("buildDocPackage: %v", err)
+	}
+	cached, err := resolvePackage(ctx, "./testdata/example")
+	if err != nil {
+		t.Fatalf("resolve again: %v", err)
+	}
+	docPkg, err := buildDocPackage(cached, options{unexported: true})
+	if err != nil {
+		t.Fatalf("buildDocPackage -u: %v", err)
+	}
+	var names []string
+	for _, v := range docPkg.Consts {
+		names = append(names, v.Names...)
+	}
+	if !slices.Contains(names, "internalConstant") {
+		t.Fatalf("a second rendering from the cache lost unexported constants: %v", names)
+	}
+	for _, file := range cached.Syntax {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body == nil {
+				t.Fatalf("go/doc dropped the body of %s from the cached syntax", fn.Name.Name)
+			}
 		}
 	}
-	if !foundRoot {
-		t.Fatalf("expected go-docmd.md in docs output, got %v", files)
+}
+
+func TestCallersListsSamePackageUsers(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-callers", "./testdata/callers", "Normalize"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "##### Used By\n\n- `Store.Add`\n\n")
+	buf.Reset()
+	if err := run([]string{"-u", "-callers", "./testdata/callers", "Normalize"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `Store.Add`\n- `cache.Warm`\n- `normalizeAll`\n\n")
+	buf.Reset()
+	if err := run([]string{"-callers", "./testdata/callers", "Lookup"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Used By") {
+		t.Fatalf("did not expect callers for an unused function\n\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := run([]string{"-all", "-callers", "./testdata/callers"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Used By") {
+		t.Fatalf("-callers should only apply to single symbols\n\n%s", buf.String())
+	}
+}
+
+func TestRefLinksCollectsDefinitions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-ref-links", "-external-doc-links", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "[io.Reader][1], and\n[the docs][2].")
+	if !strings.HasSuffix(out, "\n\n[1]: https://pkg.go.dev/io#Reader\n[2]: https://example.com/docs\n") {
+		t.Fatalf("expected definitions at the end\n\n%s", out)
+	}
+
+	in := "See [a](https://a.example) and [`b[0]`](https://a.example), [top](#top), ![img](i.png).\n\n```go\n// [x](https://x.example)\n```\n\n`[y](https://y.example)`\n"
+	want := "See [a][1] and [`b[0]`][1], [top](#top), ![img](i.png).\n\n```go\n// [x](https://x.example)\n```\n\n`[y](https://y.example)`\n\n[1]: https://a.example\n"
+	if got := string(referenceLinks([]byte(in))); got != want {
+		t.Fatalf("referenceLinks =\n%s\nwant\n%s", got, want)
+	}
+
+	in = "Read about [Go](https://en.wikipedia.org/wiki/Go_(programming_language)) (the language).\n"
+	want = "Read about [Go][1] (the language).\n\n[1]: https://en.wikipedia.org/wiki/Go_(programming_language)\n"
+	if got := string(referenceLinks([]byte(in))); got != want {
+		t.Fatalf("referenceLinks with parentheses in the URL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNoTOCLeavesRootDocument(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-no-toc", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(root), "# package example")
+	if strings.Contains(string(root), "## Packages") {
+		t.Fatalf("expected no package list with -no-toc\n\n%s", root)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg", "README.md")); err != nil {
+		t.Fatalf("expected the subpackage document: %v", err)
+	}
+	if err := run([]string{"-no-toc", "-summary-only", "-o", tmp, "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected -no-toc with -summary-only to fail")
+	}
+}
+
+func TestSummaryOnlyWritesRootIndex(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-summary-only", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	out := string(content)
+	assertContains(t, out, "## Packages\n\n- [subpkg](subpkg/README.md)")
+	if strings.Contains(out, "# package example") {
+		t.Fatalf("expected only the index\n\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg")); !os.IsNotExist(err) {
+		t.Fatalf("expected no per-package output, stat err = %v", err)
+	}
+	if err := run([]string{"-summary-only", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected -summary-only to require tree output")
+	}
+}
+
+func TestInPlaceModeWritesPackageReadmes(t *testing.T) {
+	rootPattern := "./testdata/example"
+	rootDir := filepath.Clean(rootPattern)
+	rootReadme := filepath.Join(rootDir, "README.md")
+	subReadme := filepath.Join(rootDir, "subpkg", "README.md")
+	cleanup := func() {
+		_ = os.Remove(rootReadme)
+		_ = os.Remove(subReadme)
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+	if err := run([]string{"-mainvars", "-mainfuncs", "-inplace", rootPattern}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	rootContent, err := os.ReadFile(rootReadme)
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	rootStr := string(rootContent)
+	assertContains(t, rootStr, "# package example")
+	assertContains(t, rootStr, "## Packages")
+	assertContains(t, rootStr, "subpkg/README.md")
+	assertTOCAfterDoc(t, rootStr, "# package example", "## Packages")
+	subContent, err := os.ReadFile(subReadme)
+	if err != nil {
+		t.Fatalf("read subpkg readme: %v", err)
+	}
+	assertContains(t, string(subContent), "# package subpkg")
+	assertContains(t, string(subContent), "Message exposes a sample constant")
+}
+
+// writeTree writes files, keyed by slash-separated path, under dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+// runGit runs git in dir as a fixed test identity.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func assertContains(t *testing.T, haystack, needle string) {
+	t.Helper()
+	if !strings.Contains(haystack, needle) {
+		t.Fatalf("expected output to contain %q\n\n%s", needle, haystack)
+	}
+}
+
+func assertTOCAfterDoc(t *testing.T, text, docHeading, tocHeading string) {
+	t.Helper()
+	docIdx := strings.Index(text, docHeading)
+	tocIdx := strings.Index(text, tocHeading)
+	if docIdx == -1 || tocIdx == -1 {
+		t.Fatalf("missing doc heading %q or toc heading %q", docHeading, tocHeading)
+	}
+	if tocIdx <= docIdx {
+		t.Fatalf("expected %q to appear after %q\n\n%s", tocHeading, docHeading, text)
+	}
+}
+
+func TestHelpFlag(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"--help"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "go-docmd [flags] [package|[package.]symbol[.method]]")
+	assertContains(t, out, "--all")
+	assertContains(t, out, "completion  Generate shell completion scripts")
+}
+
+func TestCompletionCommand(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"completion", "bash"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatalf("expected completion output")
+	}
+	assertContains(t, buf.String(), "__start_go-docmd")
+}
+
+func TestGenDocsCommand(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"gen-docs", tmp}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	files, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("expected CLI docs to be written")
+	}
+	var foundRoot bool
+	for _, f := range files {
+		if f.Name() == "go-docmd.md" {
+			foundRoot = true
+			break
+		}
+	}
+	if !foundRoot {
+		t.Fatalf("expected go-docmd.md in docs output, got %v", files)
+	}
+}
+
+func TestWriteFileAtomicReplacesContent(t *testing.T) {
+	tmp := t.TempDir()
+	target := filepath.Join(tmp, "README.md")
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := writeFileAtomic(target, []byte("new"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if string(content) != "new" {
+		t.Fatalf("expected replaced content, got %q", content)
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temp files to be cleaned up, got %v", entries)
+	}
+}
+
+func TestWriteFileAtomicKeepsModeAndSymlinks(t *testing.T) {
+	tmp := t.TempDir()
+	// A new file gets the mode os.WriteFile would give it under the umask.
+	ref := filepath.Join(tmp, "ref.md")
+	if err := os.WriteFile(ref, nil, 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	fresh := filepath.Join(tmp, "fresh.md")
+	if err := writeFileAtomic(fresh, []byte("new"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	refInfo, _ := os.Stat(ref)
+	freshInfo, err := os.Stat(fresh)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if freshInfo.Mode() != refInfo.Mode() {
+		t.Fatalf("expected new file mode %v, got %v", refInfo.Mode(), freshInfo.Mode())
+	}
+
+	existing := filepath.Join(tmp, "existing.md")
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := os.Chmod(existing, 0o640); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := writeFileAtomic(existing, []byte("new"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if info, _ := os.Stat(existing); runtime.GOOS != "windows" && info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected the existing mode to be kept, got %v", info.Mode())
+	}
+
+	link := filepath.Join(tmp, "README.md")
+	if err := os.Symlink("existing.md", link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := writeFileAtomic(link, []byte("through"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected README.md to stay a symlink, got %v, %v", info, err)
+	}
+	if content, _ := os.ReadFile(existing); string(content) != "through" {
+		t.Fatalf("expected the write to go through the link, got %q", content)
+	}
+}
+
+func TestHeadingOffsetShiftsHeadings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-heading-offset", "2", "-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "### package example")
+	assertContains(t, out, "#### type Greeter")
+	assertContains(t, out, "###### (\\*Greeter) Greet")
+
+	if err := run([]string{"-heading-offset", "-3", "./testdata/example"}, io.Discard); err == nil || !strings.Contains(err.Error(), "invalid -heading-offset -3") {
+		t.Fatalf("expected a negative -heading-offset to be rejected, got %v", err)
+	}
+}
+
+func TestPkgHeadingFormat(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"-heading-offset", "1", "-pkg-heading-format", "Package {name} ({import})", "./testdata/example"}
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "## Package example (github.com/agentflare-ai/go-docmd/testdata/example)\n")
+
+	err := run([]string{"-pkg-heading-format", "{title}", "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "{title}") {
+		t.Fatalf("expected unknown placeholder error, got %v", err)
+	}
+}
+
+func TestLintUndocumentedReportsSymbols(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCmd(&stdout, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-lint-undocumented", "./testdata/undocumented"}))
+	if err := cmd.Execute(); err == nil {
+		t.Fatalf("expected lint failure")
+	}
+	out := stderr.String()
+	assertContains(t, out, "undocumented.go:7: exported Bare is undocumented")
+	assertContains(t, out, "exported Widget is undocumented")
+	assertContains(t, out, "exported Widget.Spin is undocumented")
+	if strings.Contains(out, "Documented is undocumented") {
+		t.Fatalf("did not expect documented symbol to be reported\n\n%s", out)
+	}
+	if err := run([]string{"-lint-undocumented", "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("expected fully documented package to pass: %v", err)
+	}
+}
+
+func TestExampleOutputLabels(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "##### Example\n\n```go\ng := example.NewGreeter(\"gopher\")")
+	assertContains(t, out, "Output:\n\n```text\nhello gopher\n```")
+	assertContains(t, out, "##### Example (unordered)")
+	assertContains(t, out, "Unordered Output:\n\n```text\na\nb\n```")
+}
+
+func TestModuleRootAnchorsDirectoryOutput(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-module-root", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	subReadme := filepath.Join(tmp, "testdata", "example", "subpkg", "README.md")
+	if _, err := os.Stat(subReadme); err != nil {
+		t.Fatalf("expected module-relative output at %s: %v", subReadme, err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(content), "[testdata/example/subpkg](testdata/example/subpkg/README.md)")
+}
+
+func TestTimingReportsBreakdown(t *testing.T) {
+	var stderr bytes.Buffer
+	cmd := newRootCmd(io.Discard, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-timing", "-o", t.TempDir(), "./testdata/example"}))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	out := stderr.String()
+	assertContains(t, out, "timing: 2 packages in")
+	assertContains(t, out, "render:")
+	assertContains(t, out, "slowest packages by render time:")
+}
+
+func TestMethodsGroupedByDocGroup(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example.Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "### Lifecycle\n\n#### (\\*Greeter) Close")
+	assertContains(t, out, "### Methods\n\n#### (\\*Greeter) Farewell")
+	if strings.Contains(out, "docgroup:") {
+		t.Fatalf("expected docgroup directive to be stripped\n\n%s", out)
+	}
+}
+
+func TestDetectFenceLang(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "x := 1\nfmt.Println(x)", want: "go"},
+		{code: "go run ./cmd", want: "sh"},
+		{code: "SELECT id FROM users", want: "sql"},
+		{code: "syntax = \"proto3\";", want: "protobuf"},
+		{code: "{\"a\": 1}", want: "json"},
+		{code: "GODOCMD_FLAGS='-cmd -heading-offset 1' go run ./go-docmd ./...", want: "sh"},
+		{code: "$ go-docmd ./...", want: "sh"},
+		{code: "type T struct{ N int }", want: "go"},
+		{code: "# generated bindings\nzz_*\nClient.Reset", want: ""},
+	}
+	for _, tt := range tests {
+		if got := detectFenceLang(tt.code); got != tt.want {
+			t.Errorf("detectFenceLang(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFenceLangOverride(t *testing.T) {
+	r := markdownRenderer{options: options{fenceLang: "text"}}
+	got := r.docMarkdown("Usage:\n\n\tSELECT 1\n\nDone.")
+	assertContains(t, got, "```text\nSELECT 1\n```\n\nDone.")
+	r.options.fenceLang = ""
+	assertContains(t, r.docMarkdown("Usage:\n\n\tSELECT 1\n"), "```sql\nSELECT 1\n```")
+}
+
+func TestRawDocEmitsCommentsVerbatim(t *testing.T) {
+	r := markdownRenderer{
+		options: options{rawDoc: "example.com/legacy/..."},
+		pkg:     &doc.Package{ImportPath: "example.com/legacy/conf"},
+	}
+	text := "Loads settings.\n\n.. note::\n\n\tValues are cached.\n"
+	if got, want := r.docMarkdown(text), "Loads settings.\n\n.. note::\n\n\tValues are cached."; got != want {
+		t.Fatalf("docMarkdown() = %q, want %q", got, want)
+	}
+	r.pkg.ImportPath = "example.com/other"
+	assertContains(t, r.docMarkdown(text), "```")
+}
+
+func TestAllMatchesRendersEveryPackage(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":           "module example.com/matches\n\ngo 1.21\n",
+		"http/client.go":   "// Package http talks HTTP.\npackage http\n\n// Client sends HTTP requests.\ntype Client struct{}\n",
+		"rpc/client.go":    "// Package rpc talks RPC.\npackage rpc\n\n// Client sends RPC calls.\ntype Client struct{}\n",
+		"other/nothing.go": "// Package other has no Client.\npackage other\n",
+	})
+	t.Chdir(dir)
+	if err := run([]string{"-all-matches", "-short", "./...", "Client"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## from example.com/matches/http\n")
+	assertContains(t, out, "Client sends HTTP requests.")
+	assertContains(t, out, "## from example.com/matches/rpc\n")
+	assertContains(t, out, "Client sends RPC calls.")
+	if strings.Contains(out, "example.com/matches/other") {
+		t.Fatalf("did not expect a section for a package without Client\n\n%s", out)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		format  string
+		heading string
+		want    string
+	}{
+		{format: anchorGitHub, heading: "type Greeter", want: "type-greeter"},
+		{format: anchorGitHub, heading: "func (g *Greeter) Greet() string", want: "func-g-greeter-greet-string"},
+		{format: anchorGitHub, heading: "Greeter.Greet", want: "greetergreet"},
+		{format: anchorGitHub, heading: "A -- B", want: "a----b"},
+		{format: anchorGitLab, heading: "A -- B", want: "a-b"},
+		{format: anchorGitLab, heading: "snake_case Name", want: "snake_case-name"},
+		{format: anchorBitbucket, heading: "type Greeter", want: "markdown-header-type-greeter"},
+		{format: anchorBitbucket, heading: "A -- B!", want: "markdown-header-a-b"},
+		{format: "", heading: "Packages", want: "packages"},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.format, tt.heading); got != tt.want {
+			t.Errorf("slugify(%q, %q) = %q, want %q", tt.format, tt.heading, got, tt.want)
+		}
+	}
+
+	if err := run([]string{"-anchor-format", "gitlba", "./testdata/example"}, io.Discard); err == nil || !strings.Contains(err.Error(), `invalid -anchor-format "gitlba"`) {
+		t.Fatalf("expected an unknown -anchor-format to be rejected, got %v", err)
+	}
+}
+
+func TestMaxGoVersionOmitsNewerSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example.Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "#### (\\*Greeter) Farewell")
+	if strings.Contains(buf.String(), "availableSince:") {
+		t.Fatalf("expected availableSince directive to be stripped\n\n%s", buf.String())
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCmd(&stdout, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-max-go-version", "1.18", "./testdata/example.Greeter"}))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if strings.Contains(stdout.String(), "Farewell") {
+		t.Fatalf("expected Farewell to be omitted\n\n%s", stdout.String())
+	}
+	assertContains(t, stderr.String(), "omitted 1 symbol(s) newer than go1.18: Greeter.Farewell")
+}
+
+func TestFailOnWarningTurnsWarningsIntoErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCmd(&stdout, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-fail-on-warning", "-max-go-version", "1.18", "./testdata/example.Greeter"}))
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 warning(s)") {
+		t.Fatalf("expected a warning count error, got %v", err)
+	}
+	if got, want := stderr.String(), "github.com/agentflare-ai/go-docmd/testdata/example: omitted 1 symbol(s) newer than go1.18: Greeter.Farewell\n"; got != want {
+		t.Fatalf("stderr = %q, want %q", got, want)
+	}
+	assertContains(t, stdout.String(), "type Greeter")
+
+	if err := run([]string{"-fail-on-warning", "./testdata/example.Greeter"}, io.Discard); err != nil {
+		t.Fatalf("expected a clean run to succeed: %v", err)
+	}
+}
+
+func TestLooseDirectoryOutsideModule(t *testing.T) {
+	dir := t.TempDir()
+	if insideModule(dir) {
+		t.Skip("temporary directory is inside a module")
+	}
+	files := map[string]string{
+		"loose.go":       "// Package loose has no go.mod.\npackage loose\n\n// Hello greets.\nfunc Hello() string { return undefined }\n",
+		"loose_other.go": "//go:build ignore\n\npackage main\n\nfunc main() {}\n",
+	}
+	writeTree(t, dir, files)
+	var buf bytes.Buffer
+	if err := run([]string{"-all", dir}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "# package loose\n\nPackage loose has no go.mod.")
+	assertContains(t, out, "```go\nfunc Hello() string\n```\n\nHello greets.")
+	if strings.Contains(out, "import \"") || strings.Contains(out, "func main") {
+		t.Fatalf("unexpected import line or excluded file\n\n%s", out)
+	}
+}
+
+func TestOutputEncodingTranscodesDocuments(t *testing.T) {
+	opts := options{outputEncoding: "ISO-8859-1"}
+	var err error
+	if opts.outputEncoder, err = lookupOutputEncoding(opts.outputEncoding); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := encodeOutput([]byte("# café\n"), opts); err != nil || string(got) != "# caf\xe9\n" {
+		t.Fatalf("encodeOutput() = %q, %v", got, err)
+	}
+
+	err = run([]string{"-output-encoding", "ISO-8859-1", "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "'—' cannot be represented in ISO-8859-1") {
+		t.Fatalf("expected an unmappable character error, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := run([]string{"-output-encoding", "ISO-8859-1", "-on-unmappable", "replace", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "` ? ")
+	if strings.Contains(buf.String(), "—") {
+		t.Fatalf("expected the em dash to be replaced\n\n%s", buf.String())
+	}
+	if err := run([]string{"-output-encoding", "EBCDIC-NOPE", "./testdata/example"}, io.Discard); err == nil || !strings.Contains(err.Error(), "unsupported -output-encoding") {
+		t.Fatalf("expected an unknown encoding to be rejected, got %v", err)
+	}
+}
+
+func TestEmbedSourceAppendsGoFiles(t *testing.T) {
+	outDir := t.TempDir()
+	if err := run([]string{"-embed-source", "-o", outDir + string(os.PathSeparator), "./testdata/platform"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(data), "<details><summary>Source</summary>\n\n`platform.go`:\n\n```go\n// Package platform exercises -goos variant merging.\n")
+	assertContains(t, string(data), "func Epoll() {}\n```\n\n`platform_unix.go`:")
+	if !strings.HasSuffix(strings.TrimSpace(string(data)), "</details>") {
+		t.Fatalf("expected the Source block to end the document\n\n%s", data)
+	}
+
+	if err := run([]string{"-embed-source", "./testdata/platform"}, io.Discard); err == nil || !strings.Contains(err.Error(), "-embed-source requires directory") {
+		t.Fatalf("expected stdout output to be rejected, got %v", err)
+	}
+	if err := run([]string{"-embed-source", "-format", "confluence", "-o", outDir + string(os.PathSeparator), "./testdata/platform"}, io.Discard); err == nil || !strings.Contains(err.Error(), "-format=markdown") {
+		t.Fatalf("expected -format=confluence to be rejected, got %v", err)
+	}
+
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.go")
+	small := filepath.Join(dir, "small.go")
+	if err := os.WriteFile(small, []byte("package big\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(big, []byte("package big\n\n// "+strings.Repeat("x", embedSourceLimit)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnings := &warningLog{}
+	files := packageSources(&packages.Package{PkgPath: "example.com/big", GoFiles: []string{small, big}}, options{warnings: warnings})
+	if len(files) != 1 || files[0].name != "small.go" {
+		t.Fatalf("expected only small.go under the limit, got %+v", files)
+	}
+	if len(warnings.messages) != 1 || !strings.Contains(warnings.messages[0], "left out 1 of 2 file(s) past the 64 KiB limit") {
+		t.Fatalf("unexpected warnings %q", warnings.messages)
+	}
+}
+
+func TestAliasTableReplacesAliasSections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-alias", "./testdata/aliases"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Aliases\n\n| Alias | Target | Description |\n| --- | --- | --- |\n"+
+		"| `Count` | `int` | Count is a plain int. |\n"+
+		"| `Degrees` | `Celsius` | Degrees is another name for Celsius. |\n"+
+		"| `Temp` | `Celsius` | Temp is the old name of Celsius, kept for compatibility. |\n")
+	assertContains(t, out, "### Functions\n\n#### NewTemp\n")
+	if strings.Contains(out, "type Temp") || strings.Contains(out, "type Degrees") {
+		t.Fatalf("did not expect type sections for aliases\n\n%s", out)
+	}
+
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/aliases"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "## type Temp\n")
+	if strings.Contains(buf.String(), "## Aliases") {
+		t.Fatalf("expected aliases to keep their sections without -alias\n\n%s", buf.String())
+	}
+}
+
+func TestSinglePackageTreeIsFlattened(t *testing.T) {
+	var single bytes.Buffer
+	if err := run([]string{"./testdata/nested/inner"}, &single); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, pattern := range []string{"./testdata/nested/inner", "./testdata/nested/..."} {
+		outDir := t.TempDir()
+		if err := run([]string{"-flatten-single-package", "-o", outDir + string(os.PathSeparator), pattern}, io.Discard); err != nil {
+			t.Fatalf("run %s: %v", pattern, err)
+		}
+		var files []string
+		filepath.WalkDir(outDir, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() {
+				rel, _ := filepath.Rel(outDir, path)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return err
+		})
+		if len(files) != 1 || files[0] != "README.md" {
+			t.Fatalf("%s: expected only README.md at the output root, got %v", pattern, files)
+		}
+		data, err := os.ReadFile(filepath.Join(outDir, "README.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != single.String() {
+			t.Fatalf("%s: expected the single-package document\n got: %q\nwant: %q", pattern, data, single.String())
+		}
+	}
+
+	combined := filepath.Join(t.TempDir(), "API.md")
+	if err := run([]string{"-flatten-single-package", "-o", combined, "./testdata/nested/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if data, _ := os.ReadFile(combined); string(data) != single.String() {
+		t.Fatalf("expected combined output without a package list\n\n%s", data)
+	}
+
+	nested := t.TempDir()
+	if err := run([]string{"-o", nested + string(os.PathSeparator), "./testdata/nested/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(nested, "inner", "README.md")); err != nil {
+		t.Fatalf("expected the package nested by default: %v", err)
+	}
+}
+
+func TestFieldDefaultFromConventionComment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/config", "Options.Level"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "#### Options.Level\n\n```go\nLevel string\n```\n\nLevel is the minimum log level.\n\n(default: `\"info\"`)\n\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("field doc mismatch\n got: %q\nwant: %q", got, want)
+	}
+	buf.Reset()
+	if err := run([]string{"-short", "./testdata/config", "Options.Port"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `Options.Port` — (default: `8080`)")
+	buf.Reset()
+	if err := run([]string{"-default-prefix", "", "./testdata/config", "Options.Level"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "Level is the minimum log level.\ndefault: \"info\"")
+}
+
+func TestInspectPrintsDocModel(t *testing.T) {
+	var stdout bytes.Buffer
+	cmd := newRootCmd(&stdout, io.Discard)
+	cmd.SetArgs([]string{"inspect", "./testdata/aliases"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	assertContains(t, stdout.String(), "package aliases \"github.com/agentflare-ai/go-docmd/testdata/aliases\"")
+	assertContains(t, stdout.String(), "  type [Temp] aliases.go:8 doc=57\n    func [NewTemp] aliases.go:17 doc=34\n")
+
+	stdout.Reset()
+	cmd = newRootCmd(&stdout, io.Discard)
+	cmd.SetArgs([]string{"inspect", "--json", "./testdata/aliases"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("inspect --json: %v", err)
+	}
+	var model inspectPackage
+	if err := json.Unmarshal(stdout.Bytes(), &model); err != nil {
+		t.Fatalf("decode: %v\n\n%s", err, stdout.String())
+	}
+	if model.Name != "aliases" || len(model.Decls) != 4 || model.Decls[3].Children[0].Names[0] != "NewTemp" {
+		t.Fatalf("unexpected model %+v", model)
+	}
+}
+
+func TestDocmdIgnoreDropsListedSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/ignored"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### (Client) Get\n")
+	for _, dropped := range []string{"Reset", "Generated"} {
+		if strings.Contains(out, dropped) {
+			t.Fatalf("expected %s to be ignored\n\n%s", dropped, out)
+		}
+	}
+	buf.Reset()
+	if err := run([]string{"-c", "./testdata/ignored"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `type Generated`")
+}
+
+func TestExcludeSymbolDropsTypesAndMethods(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-exclude-symbol", "registry", "-exclude-symbol", "Greeter.Fare*", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "- `type Greeter`")
+	assertContains(t, out, "(\\*Greeter) Greet")
+	for _, dropped := range []string{"Registry", "Farewell"} {
+		if strings.Contains(out, dropped) {
+			t.Fatalf("expected %s to be excluded\n\n%s", dropped, out)
+		}
+	}
+
+	buf.Reset()
+	if err := run([]string{"-c", "-exclude-symbol", "registry", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `type Registry`")
+
+	err := run([]string{"-exclude-symbol", "New[", "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `-exclude-symbol: invalid symbol pattern "New["`) {
+		t.Fatalf("expected a malformed -exclude-symbol glob to be rejected, got %v", err)
+	}
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":        "module example.com/bad\n\ngo 1.22\n",
+		"bad.go":        "// Package bad has a malformed ignore file.\npackage bad\n\n// New is kept.\nfunc New() {}\n",
+		docmdIgnoreName: "# generated\nNew[\n",
+	})
+	t.Chdir(dir)
+	err = run([]string{"."}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), docmdIgnoreName+`:2: invalid symbol pattern "New["`) {
+		t.Fatalf("expected a malformed %s glob to be rejected, got %v", docmdIgnoreName, err)
+	}
+}
+
+func TestPlaygroundLinksShareRunnableExamples(t *testing.T) {
+	var uploads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		uploads = append(uploads, string(body))
+		io.WriteString(w, "abc123")
+	}))
+	defer server.Close()
+	defer func(old string) { playgroundShareURL = old }(playgroundShareURL)
+	playgroundShareURL = server.URL
+
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-playground-links", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "```text\nhello gopher\n```\n\n[Run in Playground](https://go.dev/play/p/abc123)\n\n")
+	if len(uploads) == 0 || !strings.Contains(uploads[0], "func main() {") {
+		t.Fatalf("expected a complete program upload, got %q", uploads)
+	}
+
+	uploads = nil
+	buf.Reset()
+	if err := run([]string{"-all", "-playground-links", "-no-network", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(uploads) != 0 || strings.Contains(buf.String(), "Run in Playground") {
+		t.Fatalf("-no-network should disable sharing\n\n%s", buf.String())
+	}
+
+	server.Close()
+	buf.Reset()
+	if err := run([]string{"-all", "-playground-links", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("an unreachable playground should not fail the run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Run in Playground") {
+		t.Fatalf("expected no link without the playground\n\n%s", buf.String())
+	}
+}
+
+func TestGoVersionNoteOnModuleRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":     "module example.com/lib\n\ngo 1.21\n",
+		"lib.go":     "// Package lib is a module root.\npackage lib\n",
+		"sub/sub.go": "// Package sub is below the root.\npackage sub\n",
+	})
+	t.Chdir(dir)
+
+	var buf bytes.Buffer
+	if err := run([]string{"-go-version-note", "."}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "`import \"example.com/lib\"`\n\n*Requires Go 1.21 or later.*\n\nPackage lib is a module root.")
+	buf.Reset()
+	if err := run([]string{"-go-version-note", "./sub"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Requires Go") {
+		t.Fatalf("did not expect a note below the module root\n\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := run([]string{"."}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Requires Go") {
+		t.Fatalf("did not expect a note without -go-version-note\n\n%s", buf.String())
+	}
+}
+
+func TestTimeoutFailsCleanly(t *testing.T) {
+	err := run([]string{"-timeout", "1ns", "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "timed out loading packages after 1ns") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if err := run([]string{"-timeout", "-1s", "./testdata/example"}, io.Discard); err == nil || !strings.Contains(err.Error(), "invalid -timeout") {
+		t.Fatalf("expected a negative -timeout to be rejected, got %v", err)
+	}
+	if err := run([]string{"-timeout", "1m", "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("expected a generous -timeout to succeed: %v", err)
+	}
+
+	// A timed-out load is not cached, and the deadline ends with the load.
+	ctx := withLoadCache(context.Background())
+	if _, err := loadPackage(withLoadTimeout(ctx, time.Nanosecond), "./testdata/example"); !isLoadTimeout(err) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if _, err := loadPackage(ctx, "./testdata/example"); err != nil {
+		t.Fatalf("expected the load to succeed after a timed-out attempt: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("the load timeout leaked into the caller's context: %v", ctx.Err())
+	}
+}
+
+func TestErrorFormatJSON(t *testing.T) {
+	report := func(args ...string) (errorReport, error) {
+		t.Helper()
+		var stderr bytes.Buffer
+		cmd := newRootCmd(io.Discard, &stderr)
+		cmd.SetArgs(normalizeLegacyArgs(args))
+		err := reportError(cmd, &stderr, cmd.Execute())
+		var got errorReport
+		if stderr.Len() > 0 {
+			if jerr := json.Unmarshal(stderr.Bytes(), &got); jerr != nil {
+				t.Fatalf("stderr is not a JSON object: %v\n%s", jerr, stderr.String())
+			}
+		}
+		return got, err
+	}
+
+	got, err := report("-error-format=json", "-timeout", "1ns", "./testdata/example")
+	var reported *reportedError
+	if !errors.As(err, &reported) {
+		t.Fatalf("expected the error to be marked as reported, got %v", err)
+	}
+	if got.Kind != kindTimeout || got.Error != err.Error() || got.Package != "" {
+		t.Fatalf("unexpected report for a timeout: %+v", got)
+	}
+	if got, _ := report("-error-format=json", "-no-such-flag"); got.Kind != kindUsage {
+		t.Fatalf("expected a usage report for an unknown flag, got %+v", got)
+	}
+	got, err = report("-timeout", "1ns", "./testdata/example")
+	if errors.As(err, &reported) || got != (errorReport{}) {
+		t.Fatalf("expected text mode to leave reporting to main, got %v and %+v", err, got)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/empty\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	got, _ = report("-error-format", "json", "example.com/empty/missing")
+	if got.Kind != kindPackageNotFound || got.Package == "" {
+		t.Fatalf("expected a package-not-found report naming the package, got %+v", got)
+	}
+}
+
+func TestCombinedOutputForTreePattern(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "MODULE.md")
+	if err := run([]string{"-o", target, "./testdata/example/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	out := string(content)
+	assertContains(t, out, "(#package-example)")
+	assertContains(t, out, "(#package-subpkg)")
+	assertTOCAfterDoc(t, out, "## Packages", "# package example")
+	assertTOCAfterDoc(t, out, "# package example", "# package subpkg")
+}
+
+func TestNonStructTypeKindNotes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example", "Handler"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "**Signature:** `func(name string) string`")
+	buf.Reset()
+	if err := run([]string{"./testdata/example", "Registry"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "**Underlying type:** map — `map[string]*Greeter`")
+	buf.Reset()
+	if err := run([]string{"./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "**Underlying type:**") {
+		t.Fatalf("did not expect a kind note for struct types\n\n%s", buf.String())
+	}
+}
+
+func TestCompletePackageArgs(t *testing.T) {
+	timeout := packageCompleteTimeout
+	packageCompleteTimeout = time.Hour
+	t.Cleanup(func() { packageCompleteTimeout = timeout })
+	cmd := newRootCmd(io.Discard, io.Discard)
+	matches, directive := completePackageArgs(cmd, nil, "strco")
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("unexpected directive %v", directive)
+	}
+	if len(matches) == 0 || matches[0] != "strconv" {
+		t.Fatalf("expected strconv completion, got %v", matches)
+	}
+	if _, directive := completePackageArgs(cmd, nil, "./test"); directive != cobra.ShellCompDirectiveFilterDirs {
+		t.Fatalf("expected directory completion for local paths, got %v", directive)
+	}
+
+	// A load cut short falls back to directory completion.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd.SetContext(ctx)
+	if matches, directive := completePackageArgs(cmd, nil, "strco"); directive != cobra.ShellCompDirectiveFilterDirs || len(matches) != 0 {
+		t.Fatalf("expected directory completion after cancellation, got %v %v", matches, directive)
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "-short -all", want: []string{"-short", "-all"}},
+		{in: `-o "my docs/out.md"`, want: []string{"-o", "my docs/out.md"}},
+		{in: `-fence-lang 'text'  -c`, want: []string{"-fence-lang", "text", "-c"}},
+		{in: `a\ b "c\"d"`, want: []string{"a b", `c"d`}},
+	}
+	for _, tt := range tests {
+		got, err := splitShellWords(tt.in)
+		if err != nil {
+			t.Fatalf("splitShellWords(%q): %v", tt.in, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitShellWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := splitShellWords(`-o "unterminated`); err == nil {
+		t.Fatalf("expected error for unterminated quote")
+	}
+}
+
+func TestEnvFlagsApplyWithCommandLinePrecedence(t *testing.T) {
+	t.Setenv(envFlagsVar, "-heading-offset 2")
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "#### type Greeter")
+	buf.Reset()
+	if err := run([]string{"-heading-offset", "0", "./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "## type Greeter") {
+		t.Fatalf("expected command-line flag to override %s\n\n%s", envFlagsVar, buf.String())
+	}
+	buf.Reset()
+	if err := run([]string{"completion", "bash"}, &buf); err != nil {
+		t.Fatalf("subcommand should ignore %s: %v", envFlagsVar, err)
+	}
+	argv := []string{"-c", "version"}
+	if got, err := withEnvFlags(newRootCmd(io.Discard, io.Discard), argv); err != nil || !slices.Equal(got, argv) {
+		t.Fatalf("subcommand after a root flag should ignore %s, got %q, %v", envFlagsVar, got, err)
+	}
+	if got := firstPositional(newRootCmd(io.Discard, io.Discard), []string{"-heading-offset", "1", "-all", "./testdata/example"}); got != "./testdata/example" {
+		t.Fatalf("firstPositional skipped to %q, want the package", got)
+	}
+}
+
+func TestEnumTableRendersIotaBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-enum-table", "./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "| Name | Value | Description |")
+	assertContains(t, out, "| `Neutral` | `1` | Neutral greetings are plain. |")
+	assertContains(t, out, "| `Grumpy` | `2` | Grumpy greetings are terse. |")
+	buf.Reset()
+	if err := run([]string{"./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "| Name | Value |") {
+		t.Fatalf("expected enum table to be opt-in\n\n%s", buf.String())
+	}
+}
+
+func TestGroupedValueEntryComments(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "Supported moods.\n\n- `Cheerful` — Cheerful greetings use exclamation marks.\n- `Neutral` — Neutral greetings are plain.\n- `Grumpy` — Grumpy greetings are terse.\n")
+	buf.Reset()
+	if err := run([]string{"-enum-table", "./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "- `Neutral`") {
+		t.Fatalf("did not expect entry notes next to an enum table\n\n%s", buf.String())
+	}
+}
+
+func TestSummaryFormatTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-summary-format", "table", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "| Symbol | Kind | Description |\n| --- | --- | --- |\n| `Answer` | const |  |\n")
+	assertContains(t, out, "| `Greeter` | type | Greeter produces greeting messages. |\n")
+	if strings.Contains(out, "- `type Greeter`") {
+		t.Fatalf("did not expect summary bullets with -summary-format table\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "- `type Greeter` — Greeter produces greeting messages.\n")
+	if err := run([]string{"-summary-format", "grid", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected an unknown -summary-format to be rejected")
+	}
+}
+
+func TestIndexNameAndExtension(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-index-name", "_index", "-out-ext", ".markdown", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "_index.markdown"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(content), "[subpkg](subpkg/_index.markdown)")
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg", "_index.markdown")); err != nil {
+		t.Fatalf("expected subpackage index: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "README.md")); !os.IsNotExist(err) {
+		t.Fatalf("did not expect README.md to be written")
+	}
+}
+
+func TestReadmeNameKeepsHandWrittenReadmes(t *testing.T) {
+	tmp := t.TempDir()
+	curated := filepath.Join(tmp, "README.md")
+	if err := os.WriteFile(curated, []byte("# Curated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-readme-name", "DOCS.md", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "DOCS.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(content), "[subpkg](subpkg/DOCS.md)")
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg", "DOCS.md")); err != nil {
+		t.Fatalf("expected subpackage docs: %v", err)
+	}
+	if kept, err := os.ReadFile(curated); err != nil || string(kept) != "# Curated\n" {
+		t.Fatalf("expected the hand-written README to be left alone, got %q (%v)", kept, err)
+	}
+
+	if err := run([]string{"-readme-name", "DOCS.md", "-index-name", "_index", "-o", tmp, "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected -readme-name with -index-name to be rejected")
+	}
+	if err := run([]string{"-readme-name", "DOCS.md", "-index-name", "README", "-o", tmp, "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected -readme-name with an explicit default -index-name to be rejected")
+	}
+	confluence := t.TempDir()
+	if err := run([]string{"-format", "confluence", "-readme-name", "PAGE.xhtml", "-o", confluence, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run -format confluence -readme-name: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(confluence, "PAGE.xhtml")); err != nil {
+		t.Fatalf("expected the -readme-name file with -format confluence: %v", err)
+	}
+	if err := run([]string{"-readme-name", "docs/DOCS.md", "-o", tmp, "./testdata/example"}, io.Discard); err == nil {
+		t.Fatal("expected a -readme-name path to be rejected")
+	}
+}
+
+func TestConstructorsAndHelpersSplit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example", "Mood"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "### Constructors\n\n#### ParseMood")
+	assertContains(t, out, "### Helpers\n\n#### CountMoods")
+	buf.Reset()
+	if err := run([]string{"./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "### Constructors\n\n#### NewGreeter")
+	if strings.Contains(buf.String(), "### Helpers") {
+		t.Fatalf("did not expect helpers for Greeter\n\n%s", buf.String())
+	}
+}
+
+func TestExcludeUnexportedFields(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-u", "./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "greetings int")
+	buf.Reset()
+	if err := run([]string{"-u", "-exclude-unexported-fields", "./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "greetings int") {
+		t.Fatalf("expected unexported field to be hidden\n\n%s", out)
+	}
+	assertContains(t, out, "Name string")
+	assertContains(t, out, "// contains filtered or unexported fields")
+}
+
+func TestVersionSubcommandMatchesFlag(t *testing.T) {
+	var flagOut, cmdOut bytes.Buffer
+	if err := run([]string{"--version"}, &flagOut); err != nil {
+		t.Fatalf("run --version: %v", err)
+	}
+	if err := run([]string{"version"}, &cmdOut); err != nil {
+		t.Fatalf("run version: %v", err)
+	}
+	if flagOut.String() != cmdOut.String() {
+		t.Fatalf("expected version subcommand %q to match --version %q", cmdOut.String(), flagOut.String())
+	}
+	var jsonOut bytes.Buffer
+	if err := run([]string{"version", "--json"}, &jsonOut); err != nil {
+		t.Fatalf("run version --json: %v", err)
+	}
+	assertContains(t, jsonOut.String(), `"version": "`+resolveVersion()+`"`)
+}
+
+func TestPipeTablesPassThrough(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/example", "Registry"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	table := "| Key | Result |\n| --- | :---: |\n| known | the greeter |\n| a\\|b | nil |\n"
+	assertContains(t, buf.String(), "Lookups behave as follows:\n\n"+table+"\nMissing keys never panic.")
+}
+
+func TestOnlySelectorRestrictsSections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-only", "types", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "- `type Greeter`")
+	assertContains(t, out, "## type Greeter")
+	if strings.Contains(out, "- `Answer`") || strings.Contains(out, "\n### Constants\n\n#### Answer") {
+		t.Fatalf("expected constants to be excluded\n\n%s", out)
+	}
+	if err := run([]string{"-only", "types,widgets", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatalf("expected invalid -only kind to fail")
+	}
+}
+
+func TestAPISchemaWritesSchemasDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-apischema", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "schemas", "CreateGreeterRequest.json"))
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	out := string(content)
+	assertContains(t, out, `"title": "CreateGreeterRequest"`)
+	assertContains(t, out, "\"name\": {\n      \"description\": \"Name identifies the greeter.\",\n      \"type\": \"string\"")
+	assertContains(t, out, "\"tags\": {\n      \"items\": {\n        \"type\": \"string\"")
+	assertContains(t, out, "\"required\": [\n    \"name\",\n    \"options\"\n  ]")
+	if strings.Contains(out, `"Secret"`) || strings.Contains(out, `"draft"`) {
+		t.Fatalf("expected skipped and unexported fields to be omitted\n\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "schemas", "Greeter.json")); !os.IsNotExist(err) {
+		t.Fatalf("did not expect schema for untagged type")
+	}
+	if err := run([]string{"-apischema", "./testdata/example"}, io.Discard); err == nil {
+		t.Fatalf("expected -apischema without tree output to fail")
+	}
+}
+
+func TestStrictLinksReportsUnresolvedTargets(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCmd(&stdout, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-strict-links", "./testdata/links"}))
+	if err := cmd.Execute(); err == nil {
+		t.Fatalf("expected unresolved links to fail")
+	}
+	out := stderr.String()
+	assertContains(t, out, "links.go:8: unresolved doc link [Widget.Spinn] in Widget")
+	assertContains(t, out, "links.go:11: unresolved doc link [Gadget] in Widget.Spin")
+	if strings.Contains(out, "io.Reader") || strings.Contains(out, "[Widget]") {
+		t.Fatalf("did not expect valid links to be reported\n\n%s", out)
+	}
+	assertContains(t, stdout.String(), "# package links")
+	if err := run([]string{"-strict-links", "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("expected package without broken links to pass: %v", err)
+	}
+}
+
+func TestExamplesFileDivertsExamples(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-all", "-examples-file", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	readme, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read readme: %v", err)
+	}
+	if strings.Contains(string(readme), "Unordered Output:") {
+		t.Fatalf("expected examples to move out of the README\n\n%s", readme)
+	}
+	assertContains(t, string(readme), "[EXAMPLES.md](EXAMPLES.md)")
+	examples, err := os.ReadFile(filepath.Join(tmp, "EXAMPLES.md"))
+	if err != nil {
+		t.Fatalf("read examples: %v", err)
+	}
+	assertContains(t, string(examples), "# Examples for package example")
+	assertContains(t, string(examples), "## Greeter.Greet\n\n### Example")
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg", "EXAMPLES.md")); !os.IsNotExist(err) {
+		t.Fatalf("did not expect an examples file for a package without examples")
+	}
+}
+
+func TestSymbolGlobWritesIndexedDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-o", tmp, "./testdata/example", "*Mood*"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, name := range []string{"Mood.md", "ParseMood.md", "CountMoods.md"} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	index, err := os.ReadFile(filepath.Join(tmp, "index.md"))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	assertContains(t, string(index), "- [Mood](Mood.md) — Mood selects the tone of a greeting.")
+	assertContains(t, string(index), "- [ParseMood](ParseMood.md)")
+	mood, err := os.ReadFile(filepath.Join(tmp, "Mood.md"))
+	if err != nil {
+		t.Fatalf("read Mood.md: %v", err)
+	}
+	if !strings.HasPrefix(string(mood), "## type Mood") {
+		t.Fatalf("expected Mood.md to document the type\n\n%s", mood)
+	}
+
+	confluence := t.TempDir()
+	if err := run([]string{"-format", "confluence", "-o", confluence, "./testdata/example", "*Mood*"}, io.Discard); err != nil {
+		t.Fatalf("run -format confluence: %v", err)
+	}
+	for _, name := range []string{"Mood.xml", "index.xml"} {
+		if _, err := os.Stat(filepath.Join(confluence, name)); err != nil {
+			t.Fatalf("expected %s with -format confluence: %v", name, err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(confluence, "*.md")); len(matches) > 0 {
+		t.Fatalf("did not expect Markdown file names for Confluence pages: %v", matches)
+	}
+}
+
+func TestBuildDocPackageReparsesCgoSources(t *testing.T) {
+	source := filepath.Join("testdata", "cgo", "cgo.go")
+	src, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("read source: %v", err)
+	}
+	// go/packages hands back cgo-generated files named after their build
+	// cache entries, which go/doc rejects.
+	fset := token.NewFileSet()
+	generated, err := parser.ParseFile(fset, filepath.Join(t.TempDir(), "0123abcd-d"), src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	pkgInfo := &packages.Package{
+		Name:    "cgo",
+		PkgPath: "github.com/agentflare-ai/go-docmd/testdata/cgo",
+		Fset:    fset,
+		GoFiles: []string{source},
+		Syntax:  []*ast.File{generated},
+	}
+	docPkg, err := buildDocPackage(pkgInfo, options{all: true})
+	if err != nil {
+		t.Fatalf("buildDocPackage: %v", err)
+	}
+	var buf bytes.Buffer
+	renderer := markdownRenderer{options: options{all: true}, pkg: docPkg, fileset: fset}
+	renderer.renderPackage(&buf)
+	out := buf.String()
+	assertContains(t, out, "`func Twice(v int) int` — Twice doubles v using a C helper.")
+	assertContains(t, out, "## type Buffer")
+	if strings.Contains(out, "stdlib.h") {
+		t.Fatalf("expected the cgo preamble to stay out of the output\n\n%s", out)
+	}
+}
+
+func TestStabilityDirectiveRendersBelowTitle(t *testing.T) {
+	var text bytes.Buffer
+	if err := run([]string{"./testdata/stability"}, &text); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, text.String(), "`import \"github.com/agentflare-ai/go-docmd/testdata/stability\"`\n\n**Stability: experimental**\n\n")
+	if strings.Contains(text.String(), "+stability") {
+		t.Fatalf("expected the directive to be stripped\n\n%s", text.String())
+	}
+	var badge bytes.Buffer
+	if err := run([]string{"-stability-style", "badge", "./testdata/stability"}, &badge); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, badge.String(), "![Stability: experimental](https://img.shields.io/badge/stability-experimental-orange)")
+	if err := run([]string{"-stability-style", "shiny", "./testdata/stability"}, io.Discard); err == nil {
+		t.Fatalf("expected an unknown style to be rejected")
+	}
+}
+
+func TestNoRecurseDocumentsOnlyTheRootPackage(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-no-recurse", "-o", tmp, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "README.md")); err != nil {
+		t.Fatalf("expected root README: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg")); !os.IsNotExist(err) {
+		t.Fatalf("did not expect subpackage output with -no-recurse")
+	}
+}
+
+func TestHeaderPrependsToEveryDocument(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-header", "<!-- DO NOT EDIT -->", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<!-- DO NOT EDIT -->\n\n# package example") {
+		t.Fatalf("expected the inline header before the title\n\n%s", buf.String())
+	}
+	tmp := t.TempDir()
+	headerFile := filepath.Join(tmp, "HEADER.md")
+	if err := os.WriteFile(headerFile, []byte("Licensed under MIT.\n"), 0o644); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	outDir := filepath.Join(tmp, "docs")
+	if err := run([]string{"-header", headerFile, "-o", outDir, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, rel := range []string{"README.md", filepath.Join("subpkg", "README.md")} {
+		content, err := os.ReadFile(filepath.Join(outDir, rel))
+		if err != nil {
+			t.Fatalf("read %s: %v", rel, err)
+		}
+		if !strings.HasPrefix(string(content), "Licensed under MIT.\n\n# package") {
+			t.Fatalf("expected %s to start with the header file\n\n%s", rel, content)
+		}
+	}
+}
+
+func TestImplementsListsRelationshipsAcrossTree(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-all", "-implements", "-o", tmp, "./testdata/shapes"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(root), "### Implemented By\n\n- [Square](#type-square)\n- [circle.Circle](circle/README.md#type-circle)\n")
+	assertContains(t, string(root), "### Implements\n\n- [Shape](#type-shape)\n")
+	sub, err := os.ReadFile(filepath.Join(tmp, "circle", "README.md"))
+	if err != nil {
+		t.Fatalf("read circle: %v", err)
+	}
+	assertContains(t, string(sub), "- [shapes.Shape](../README.md#type-shape)")
+
+	var plain bytes.Buffer
+	if err := run([]string{"-all", "./testdata/shapes"}, &plain); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(plain.String(), "Implemented By") {
+		t.Fatalf("expected relationships only with -implements\n\n%s", plain.String())
+	}
+}
+
+func TestInPlaceDirsCompareResolved(t *testing.T) {
+	root := t.TempDir()
+	realDir := filepath.Join(root, "real")
+	if err := os.MkdirAll(filepath.Join(realDir, "pkg"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(realDir, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if !sameDir(link, realDir) {
+		t.Fatalf("sameDir(%q, %q) = false, want true", link, realDir)
+	}
+	t.Chdir(root)
+	if !sameDir("real", realDir) {
+		t.Fatalf("sameDir of relative and absolute forms = false, want true")
+	}
+	pkgs := []*packages.Package{
+		{GoFiles: []string{filepath.Join(link, "doc.go")}},
+		{GoFiles: []string{filepath.Join(realDir, "pkg", "doc.go")}},
+	}
+	if got, want := commonPackageDir(pkgs), canonicalPath(realDir); got != want {
+		t.Fatalf("commonPackageDir = %q, want %q", got, want)
+	}
+}
+
+func TestCommonPackageDirIsOrderIndependent(t *testing.T) {
+	root := t.TempDir()
+	pkg := func(rel string) *packages.Package {
+		return &packages.Package{GoFiles: []string{filepath.Join(root, rel, "doc.go")}}
+	}
+	nested := []*packages.Package{pkg("a/b"), pkg("a"), pkg("a/c")}
+	reversed := []*packages.Package{nested[2], nested[1], nested[0]}
+	want := filepath.Join(root, "a")
+	for _, pkgs := range [][]*packages.Package{nested, reversed} {
+		if got := commonPackageDir(pkgs); got != want {
+			t.Fatalf("commonPackageDir = %q, want %q", got, want)
+		}
+	}
+	siblings := []*packages.Package{pkg("x/one"), pkg("x/two")}
+	if got, want := commonPackageDir(siblings), filepath.Join(root, "x"); got != want {
+		t.Fatalf("commonPackageDir = %q, want %q", got, want)
+	}
+}
+
+func TestDeriveRelativeDirResolvesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	real := filepath.Join(root, "real")
+	if err := os.MkdirAll(filepath.Join(real, "pkg", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	pkg := &packages.Package{PkgPath: "example.com/mod/pkg/sub"}
+	if got := deriveRelativeDir(pkg, link, filepath.Join(real, "pkg", "sub")); got != "pkg/sub" {
+		t.Fatalf("symlinked base: got %q, want pkg/sub", got)
+	}
+	if got := deriveRelativeDir(pkg, real, filepath.Join(link, "pkg", "sub")); got != "pkg/sub" {
+		t.Fatalf("symlinked package dir: got %q, want pkg/sub", got)
+	}
+}
+
+func TestParamDocsRenderTables(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-param-docs", "./testdata/params", "Join"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "| Parameter | Description |\n| --- | --- |\n| `parts` | the strings to join, in order. |\n| `sep` | the separator placed between elements. |\n")
+	assertContains(t, out, "| `joined` | the concatenated result. |")
+	if strings.Contains(out, "sep: the separator") {
+		t.Fatalf("expected parameter lines to leave the prose\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"./testdata/params", "Join"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "sep: the separator placed between elements.")
+}
+
+func TestNoImportLine(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-no-import-line", "-header", "DO NOT EDIT", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "DO NOT EDIT\n\n# package example\n\nPackage example demonstrates") {
+		t.Fatalf("expected the title to run straight into the package doc\n\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "`import ") {
+		t.Fatalf("expected no import line\n\n%s", buf.String())
+	}
+}
+
+func TestSeeAlsoLinksResolvedSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/params", "Split"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "**See also:** [Join](https://pkg.go.dev/github.com/agentflare-ai/go-docmd/testdata/params#Join), `strings.Split`\n")
+	if strings.Contains(out, "See also: Join") {
+		t.Fatalf("expected the See also line to leave the prose\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/params"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "**See also:** [Join](#join), `strings.Split`\n")
+}
+
+func TestArchiveOutputBundlesTree(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "docs.tar.gz")
+	if err := run([]string{"-o", target, "./testdata/example"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", hdr.Name, err)
+		}
+		files[hdr.Name] = string(data)
+	}
+	assertContains(t, files["README.md"], "[subpkg](subpkg/README.md)")
+	assertContains(t, files["subpkg/README.md"], "# package subpkg")
+}
+
+func TestZipOutputBundlesSymbolSet(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "api.zip")
+	if err := run([]string{"-o", target, "./testdata/example", "New*"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	zr, err := zip.OpenReader(target)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+	}
+	assertContains(t, files["index.md"], "[NewGreeter](NewGreeter.md)")
+	assertContains(t, files["NewGreeter.md"], "NewGreeter")
+
+	err = run([]string{"-o", target, "./testdata/example", "Greeter"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "single symbol") {
+		t.Fatalf("expected guidance for a single symbol, got %v", err)
+	}
+}
+
+func TestParseCompoundSplitsOnlyAfterPathSeparator(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want []invocation
+	}{
+		{"net/http.Client.Do", []invocation{
+			{pkgExpr: "net/http", symbol: "Client", method: "Do"},
+			{pkgExpr: "net/http.Client", symbol: "Do"},
+		}},
+		{"github.com/Foo/Bar.Baz", []invocation{{pkgExpr: "github.com/Foo/Bar", symbol: "Baz"}}},
+		{"example.com/pkg.Type", []invocation{{pkgExpr: "example.com/pkg", symbol: "Type"}}},
+		{"gopkg.in/yaml.v3.Node", []invocation{
+			{pkgExpr: "gopkg.in/yaml", symbol: "v3", method: "Node"},
+			{pkgExpr: "gopkg.in/yaml.v3", symbol: "Node"},
+		}},
+		{"json.Decoder", []invocation{{pkgExpr: "json", symbol: "Decoder"}}},
+	}
+	for _, tt := range tests {
+		got := parseCompound(tt.arg)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCompound(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCacheReusesResultsWithinRun(t *testing.T) {
+	ctx := withLoadCache(context.Background())
+	first, err := loadPackage(ctx, "./testdata/example")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	second, err := loadPackage(ctx, "./testdata/example")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected the second load to come from the cache")
+	}
+	fresh, err := loadPackage(withLoadCache(context.Background()), "./testdata/example")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if fresh == first {
+		t.Fatalf("expected a new run to load again")
+	}
+}
+
+func TestNoteInterfacesMarksWellKnownMethods(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-note-interfaces", "./testdata/example", "Greeter.Close"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "*Implements io.Closer.*")
+	buf.Reset()
+	if err := run([]string{"-note-interfaces", "./testdata/example", "Greeter.Greet"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "*Implements") {
+		t.Fatalf("did not expect a note for Greet\n\n%s", buf.String())
+	}
+}
+
+func TestDiffBaseKeepsOnlyChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		"go.mod": "module example.com/diffbase\n\ngo 1.21\n",
+		"old.go": "// Package diffbase is a fixture.\npackage diffbase\n\n// Old is unchanged.\nfunc Old() {}\n",
+		"new.go": "package diffbase\n\n// New is edited.\nfunc New() {}\n",
+	})
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	writeTree(t, repo, map[string]string{
+		"new.go": "package diffbase\n\n// New was edited after the base commit.\nfunc New() {}\n",
+	})
+	t.Chdir(repo)
+
+	var buf bytes.Buffer
+	if err := run([]string{"-diff-base", "HEAD", "."}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "New was edited after the base commit.")
+	if strings.Contains(buf.String(), "func Old()") {
+		t.Fatalf("expected unchanged symbols to be omitted\n\n%s", buf.String())
+	}
+}
+
+func TestAddedSinceListsNewExportedSymbols(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		"go.mod": "module example.com/added\n\ngo 1.21\n",
+		"old.go": "// Package added is a fixture.\npackage added\n\n// Old predates the tag.\nfunc Old() {}\n\n// Config predates the tag.\ntype Config struct{}\n",
+	})
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	runGit(t, repo, "tag", "v1.0.0")
+	writeTree(t, repo, map[string]string{
+		"new.go": "package added\n\n// Limit is new.\nconst Limit = 3\n\n// NewConfig is new.\nfunc NewConfig() *Config { return nil }\n\n// Reset is a new method.\nfunc (c *Config) Reset() {}\n",
+	})
+	t.Chdir(repo)
+
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-added-since", "v1.0.0", "."}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Added since v1.0.0\n\n- [`const Limit`](#limit)\n- [`func NewConfig`](#newconfig)\n\n")
+	section := out[strings.Index(out, "## Added since"):]
+	if strings.Contains(section, "Old") || strings.Contains(section, "type Config") || strings.Contains(section, "Reset") {
+		t.Fatalf("expected only new top-level symbols\n\n%s", section)
+	}
+
+	if err := run([]string{"-added-since", "v9.9.9", "."}, io.Discard); err == nil {
+		t.Fatal("expected an error for an unknown ref")
+	}
+}
+
+func TestDocMarkdownSeparatesParagraphs(t *testing.T) {
+	// Doc text as go/doc returns it for a comment gofmt has not touched.
+	text := "First paragraph.\n\n+stability: stable\n\nA forced break ends this line \\\nand continues here.\n\nAn indented block follows:\n\tcode()\nProse resumes after it.\n"
+	r := markdownRenderer{}
+	got := r.docMarkdown(text)
+	want := "First paragraph.\n\nA forced break ends this line \\\nand continues here.\n\nAn indented block follows:\n\n```go\ncode()\n```\n\nProse resumes after it."
+	if got != want {
+		t.Fatalf("docMarkdown mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestDocMarkdownRendersAdmonitions(t *testing.T) {
+	kinds, err := parseAdmonitions(defaultAdmonitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "Close releases the handle.\n\nWarning: calling Close twice\npanics.\n\nnote:\nSafe for concurrent use.\n\nNotes: are not alerts.\n"
+	r := markdownRenderer{options: options{admonitionKinds: kinds}}
+	got := r.docMarkdown(text)
+	want := "Close releases the handle.\n\n> [!WARNING]\n> calling Close twice\n> panics.\n\n> [!NOTE]\n> Safe for concurrent use.\n\nNotes: are not alerts."
+	if got != want {
+		t.Fatalf("docMarkdown mismatch\n got: %q\nwant: %q", got, want)
+	}
+	if _, err := parseAdmonitions("Note,Danger"); err == nil {
+		t.Fatal("expected an error for a keyword that is not a GitHub alert type")
+	}
+}
+
+func TestGOOSVariantsGroupPlatformSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-goos", "linux,windows", "./testdata/platform"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "- `func Name() string` — Name reports the platform name.\n\n### Linux-only\n\n- `func Epoll()`")
+	assertContains(t, out, "### Windows-only\n\n- `func IOCP()`")
+	if strings.Contains(out, "Kqueue") {
+		t.Fatalf("did not expect symbols from an unrequested GOOS\n\n%s", out)
+	}
+}
+
+func TestGOOSVariantsApplyStrictLinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":           "module example.com/ports\n\ngo 1.21\n",
+		"ports.go":         "// Package ports opens ports.\npackage ports\n\n// Open opens a port; see [Opne].\nfunc Open() {}\n",
+		"ports_linux.go":   "package ports\n\n// Epoll waits; see [Pol].\nfunc Epoll() {}\n",
+		"ports_windows.go": "package ports\n\n// IOCP waits.\nfunc IOCP() {}\n",
+	})
+	t.Chdir(dir)
+	var stdout, stderr bytes.Buffer
+	cmd := newRootCmd(&stdout, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-strict-links", "-goos", "linux,windows", "."}))
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "2 unresolved doc link(s)") {
+		t.Fatalf("expected both broken links to fail the run once each, got %v\n%s", err, stderr.String())
+	}
+	assertContains(t, stderr.String(), "unresolved doc link [Opne] in Open")
+	assertContains(t, stderr.String(), "unresolved doc link [Pol] in Epoll")
+	assertContains(t, stdout.String(), "### Linux-only")
+}
+
+func TestBuildNotesNameConstrainedSymbols(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-build-notes", "./testdata/platform", "Poll"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "Poll waits on file descriptors on Unix systems.\n\n*Available on: linux, darwin*")
+	buf.Reset()
+	if err := run([]string{"-build-notes", "./testdata/platform", "Name"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "Available on") {
+		t.Fatalf("did not expect a note for an unconstrained file\n\n%s", buf.String())
+	}
+
+	dir := t.TempDir()
+	for name, want := range map[string]string{
+		"poll_linux.go":         "linux",
+		"poll_windows_amd64.go": "windows && amd64",
+		"linux.go":              "",
+		"tagged_linux.go":       "linux && !cgo",
+		"legacy.go":             "darwin && cgo",
+	} {
+		src := "package demo\n"
+		switch name {
+		case "tagged_linux.go":
+			src = "//go:build !cgo\n\n" + src
+		case "legacy.go":
+			src = "// +build darwin\n// +build cgo\n\n" + src
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := buildConstraintText(path); got != want {
+			t.Errorf("buildConstraintText(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPlatformMatrixMarksAvailability(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-goos", "linux,windows", "-platform-matrix", "./testdata/platform"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "## Platform Availability\n\n| Symbol | linux | windows |\n| --- | :-: | :-: |\n"+
+		"| `func Epoll` | ✓ |  |\n| `func IOCP` |  | ✓ |\n| `func Name` | ✓ | ✓ |\n| `func Poll` | ✓ |  |\n\n")
+	if err := run([]string{"-platform-matrix", "./testdata/platform"}, io.Discard); err == nil || !strings.Contains(err.Error(), "requires -goos") {
+		t.Fatalf("expected -platform-matrix without -goos to fail, got %v", err)
+	}
+}
+
+func TestDropEmptySections(t *testing.T) {
+	// A type whose only methods were filtered out keeps its declaration but
+	// loses the empty Methods heading.
+	in := "## type Widget\n\n```go\n# not a heading\ntype Widget struct{}\n```\n\n### Methods\n\n## type Gadget\n\n### Methods\n\n#### Gadget.Spin\n\nSpins.\n\n### Variables\n"
+	want := "## type Widget\n\n```go\n# not a heading\ntype Widget struct{}\n```\n\n## type Gadget\n\n### Methods\n\n#### Gadget.Spin\n\nSpins.\n\n"
+	if got := string(dropEmptySections([]byte(in))); got != want {
+		t.Fatalf("dropEmptySections mismatch\n got: %q\nwant: %q", got, want)
+	}
+
+	// Blank lines inside a fence are source and survive the collapse.
+	in = "## Constants\n\n```go\nconst S = `top\n\n\nbottom`\n```\n\n\n### Variables\n\n## type T\n\nT is a type.\n"
+	want = "## Constants\n\n```go\nconst S = `top\n\n\nbottom`\n```\n\n## type T\n\nT is a type.\n"
+	if got := string(dropEmptySections([]byte(in))); got != want {
+		t.Fatalf("dropEmptySections mismatch\n got: %q\nwant: %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := run([]string{"-no-empty-sections", "-goos", "linux,windows", "-only", "consts", "./testdata/platform"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "-only") {
+		t.Fatalf("expected empty platform sections to be dropped\n\n%s", buf.String())
+	}
+
+	// A function template that renders nothing for methods leaves
+	// Greeter's method groups without content.
+	tmplDir := t.TempDir()
+	writeTree(t, tmplDir, map[string]string{
+		"func.tmpl": "{{if not .Receiver}}{{.Heading}}{{.Doc}}\n\n{{end}}",
+	})
+	buf.Reset()
+	if err := run([]string{"-all", "-template-dir", tmplDir, "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "### Lifecycle\n\n\n### Methods\n\n\n## type Handler")
+	buf.Reset()
+	if err := run([]string{"-all", "-no-empty-sections", "-template-dir", tmplDir, "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### NewGreeter\n\nNewGreeter constructs a Greeter.\n\n## type Handler")
+	if strings.Contains(out, "### Lifecycle") || strings.Contains(out, "### Methods") {
+		t.Fatalf("expected the empty method groups to be dropped\n\n%s", out)
+	}
+}
+
+func TestStdlibVersionPinsDocLinks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "pkg.go.dev") {
+		t.Fatalf("did not expect pkg.go.dev links without -external-doc-links\n\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := run([]string{"-external-doc-links", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "[io.Reader](https://pkg.go.dev/io#Reader)")
+	assertContains(t, buf.String(), "[Widget], [Widget.Spin]")
+	buf.Reset()
+	if err := run([]string{"-external-doc-links", "-stdlib-version", "go1.22", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "[io.Reader](https://pkg.go.dev/io@go1.22#Reader)")
+	if err := run([]string{"-stdlib-version", "newest", "./testdata/links"}, io.Discard); err == nil {
+		t.Fatalf("expected an invalid version to be rejected")
+	}
+}
+
+func TestColorPreview(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-color=always", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, ansiBold+"package links"+ansiReset+"\n") {
+		t.Fatalf("expected a bold title without heading markers\n\n%q", out)
+	}
+	assertContains(t, out, ansiUnderline+"the docs"+ansiReset)
+	buf.Reset()
+	if err := run([]string{"-color", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatalf("expected auto mode to skip colors for a non-terminal writer")
+	}
+
+	buf.Reset()
+	if err := run([]string{"-color=always", "-all", "-number-headings", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out = buf.String()
+	assertContains(t, out, ansiBold+"3.2.1. (*Greeter) Close"+ansiReset+"\n")
+	if strings.Contains(out, "\\*") || strings.Contains(out, "<a id=") {
+		t.Fatalf("expected Markdown escapes and HTML anchors to be removed\n\n%q", out)
+	}
+
+	// A code span inside link text keeps the link underlined after it.
+	got := string(colorizeMarkdown([]byte("[`Spin` it](https://example.com/(x)) now")))
+	want := ansiUnderline + ansiDim + "Spin" + ansiReset + ansiUnderline + " it" + ansiReset + " now"
+	if got != want {
+		t.Fatalf("colorizeMarkdown mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestNoColorDisablesPreview(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-color=always", "-no-color", "./testdata/links"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatalf("expected -no-color to override -color=always")
+	}
+	err := run([]string{"-color", "always", "./testdata/links"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "write -color=always") {
+		t.Fatalf("expected a bare -color followed by a mode to be rejected, got %v", err)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if !wantsColor(colorAlways, false, io.Discard) {
+		t.Fatalf("expected -color=always to win over NO_COLOR")
+	}
+	if wantsColor(colorAuto, false, os.Stdout) {
+		t.Fatalf("expected NO_COLOR to disable auto mode")
+	}
+}
+
+func TestMaxDepthHeadingsUseBoldPastSixLevels(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-heading-offset", "3", "-max-depth-headings", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "##### type Greeter\n\n")
+	assertContains(t, out, "###### Methods\n\n")
+	assertContains(t, out, "**(\\*Greeter) Greet**\n\n")
+	if strings.Contains(out, "####### ") {
+		t.Fatalf("expected no headings deeper than six levels\n\n%s", out)
+	}
+}
+
+func TestDryRunPrintsWritePlan(t *testing.T) {
+	tmp := t.TempDir()
+	existing := filepath.Join(tmp, "README.md")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatalf("write existing: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := run([]string{"-dry-run", "-o", tmp, "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "overwrite "+existing+"\n")
+	assertContains(t, out, "create "+filepath.Join(tmp, "subpkg", "README.md")+"\n")
+	if content, _ := os.ReadFile(existing); string(content) != "old" {
+		t.Fatalf("expected dry run to leave existing file untouched, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "subpkg")); !os.IsNotExist(err) {
+		t.Fatalf("expected dry run not to create directories, stat err = %v", err)
+	}
+}
+
+func TestVarTableRendersTypes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-vartable", "./testdata/config"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "| Name | Type | Description |")
+	assertContains(t, out, "| <a id=\"name\"></a>`Name` | `string` | Name identifies the service. |")
+	assertContains(t, out, "| <a id=\"debug\"></a>`Debug` | `bool` | toggles extra logging |")
+	assertContains(t, out, "| <a id=\"timeout\"></a>`Timeout` | `int` | Timeout is how long to wait, in seconds. |")
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/config"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), "| Name | Type |") {
+		t.Fatalf("expected variable table to be opt-in\n\n%s", buf.String())
+	}
+}
+
+func TestImportLinePrefersCanonicalImportComment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/vanity"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "`import \"example.com/vanity\"`")
+}
+
+func TestGroupedValuesGetPerNameAnchors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/config"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### Name, Verbose, Debug\n\n<a id=\"name\"></a><a id=\"verbose\"></a><a id=\"debug\"></a>\n\n")
+	assertContains(t, out, "**See also:** [Debug](#debug)\n")
+	if strings.Contains(out, "#### Timeout\n\n<a id=") {
+		t.Fatalf("expected single-name blocks to rely on their heading anchor\n\n%s", out)
+	}
+}
+
+func TestListPackagesPrintsTree(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-list-packages", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "github.com/agentflare-ai/go-docmd/testdata/example\ttestdata/example\tpackage\n" +
+		"github.com/agentflare-ai/go-docmd/testdata/example/subpkg\ttestdata/example/subpkg\tpackage\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected package list\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestIndexGroupsByKind(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-index", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Index\n\n- [Constants](#constants)\n")
+	assertContains(t, out, "- [type Greeter](#type-greeter)\n  - [func NewGreeter(name string) *Greeter](#newgreeter)\n")
+	assertContains(t, out, "  - [func (g *Greeter) Greet() string](#greeter-greet)\n")
+	if strings.Contains(out, "- `type Greeter` — ") {
+		t.Fatalf("expected the index to replace the flat summary\n\n%s", out)
+	}
+}
+
+func TestPromotedFieldsResolveThroughEmbedding(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/embed", "Record.ID"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### Record.ID\n\n```go\nID string\n```\n\n*Promoted from embedded `Base`.*\n\nID uniquely identifies the record.")
+	buf.Reset()
+	if err := run([]string{"./testdata/embed", "Record.Created"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "*Promoted from embedded `Base.meta`.*")
+}
+
+func TestTemplateDirOverridesOneKind(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-template-dir", "./testdata/templates", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### (\\*Greeter) Greet\n\n`func (g *Greeter) Greet() string`\n\nGreet returns a friendly message.\n")
+	// Types have no template in the directory and keep the built-in layout.
+	assertContains(t, out, "## type Greeter\n\n```go\ntype Greeter struct {")
+	if strings.Contains(out, "```go\nfunc (g *Greeter) Greet() string\n```") {
+		t.Fatalf("expected func.tmpl to replace the built-in function layout\n\n%s", out)
+	}
+}
+
+func TestTemplateDirReportsExecutionErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "value.tmpl"), []byte("{{.Missing}}"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	err := run([]string{"-all", "-template-dir", dir, "./testdata/example"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "-template-dir") {
+		t.Fatalf("expected a -template-dir error, got %v", err)
+	}
+}
+
+func TestNavJSONDescribesTree(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-nav-json", "-o", tmp, "./testdata/shapes"}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmp, "nav.json"))
+	if err != nil {
+		t.Fatalf("read nav.json: %v", err)
+	}
+	var nav navNode
+	if err := json.Unmarshal(data, &nav); err != nil {
+		t.Fatalf("decode nav.json: %v", err)
+	}
+	if nav.Path != "README.md" || len(nav.Children) != 1 {
+		t.Fatalf("unexpected root node: %+v", nav)
+	}
+	child := nav.Children[0]
+	if child.Title != "circle" || child.Path != "circle/README.md" || child.Summary == "" {
+		t.Fatalf("unexpected child node: %+v", child)
+	}
+}
+
+func TestWorkspaceNestsModulesInTree(t *testing.T) {
+	ws := t.TempDir()
+	writeTree(t, ws, map[string]string{
+		"go.work":      "go 1.21\n\nuse (\n\t./api\n\t./cli\n)\n",
+		"api/go.mod":   "module example.com/api\n\ngo 1.21\n",
+		"api/api.go":   "// Package api serves requests.\npackage api\n",
+		"api/v2/v2.go": "// Package v2 is the next API.\npackage v2\n",
+		"cli/go.mod":   "module example.com/cli\n\ngo 1.21\n",
+		"cli/cli.go":   "// Package cli parses flags.\npackage cli\n",
+	})
+	t.Chdir(ws)
+	// Workspace mode rejects -mod=mod, which some environments set globally.
+	t.Setenv("GOFLAGS", "")
+
+	out := filepath.Join(ws, "docs")
+	if err := run([]string{"-o", out, "example.com/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, rel := range []string{"api/README.md", "api/v2/README.md", "cli/README.md"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(rel))); err != nil {
+			t.Fatalf("expected %s: %v", rel, err)
+		}
+	}
+	root, err := os.ReadFile(filepath.Join(out, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(root), "[api/v2](api/v2/README.md)")
+	assertContains(t, string(root), "[cli](cli/README.md)")
+}
+
+func TestMarkGeneratedProtectsHandWrittenFiles(t *testing.T) {
+	tmp := t.TempDir()
+	target := filepath.Join(tmp, "README.md")
+	if err := os.WriteFile(target, []byte("# Hand-written\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	args := []string{"-mark-generated", "-o", target, "./testdata/example"}
+	if err := run(args, io.Discard); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("expected a refusal, got %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "# Hand-written\n" {
+		t.Fatalf("expected the hand-written file to survive, got %q", content)
+	}
+	if err := run(append([]string{"-force"}, args...), io.Discard); err != nil {
+		t.Fatalf("run -force: %v", err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.HasPrefix(string(content), generatedMarker+"\n\n# package example") {
+		t.Fatalf("expected the generated marker first\n\n%s", content)
+	}
+	// Files that already carry the marker are regenerated without -force.
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+}
+
+func TestEscapeStrayAsterisks(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"**Alpha**: bold stays.", "**Alpha**: bold stays."},
+		{"An *italic* word.", "An *italic* word."},
+		{"Returns a *Greeter.", `Returns a \*Greeter.`},
+		{"Computes a * b.", `Computes a \* b.`},
+		{"Use `*p` in code.", "Use `*p` in code."},
+		{"  - **Beta**: list item.", "  - **Beta**: list item."},
+		{"* bullet with *T", `* bullet with \*T`},
+		{"Code:\n\n\tx := *p", "Code:\n\n\tx := *p"},
+	}
+	for _, tt := range tests {
+		if got := escapeStrayAsterisks(tt.in); got != tt.want {
+			t.Errorf("escapeStrayAsterisks(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTypeSetInterfacesListPermittedTypes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/constraints", "Number"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "### Type Set\n\n- `~int` — any type whose underlying type is `int`\n- `~int64` — any type whose underlying type is `int64`\n- `float64` — exactly `float64`\n")
+}
+
+func TestGlossaryListsSymbolsAlphabetically(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-glossary", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Glossary\n\n")
+	glossary := out[strings.Index(out, "## Glossary"):]
+	assertContains(t, glossary, "- [Greeter.Greet](#greeter-greet) — Greet returns a friendly message.\n")
+	if strings.Index(glossary, "[Greeter]") > strings.Index(glossary, "[Greeter.Close]") ||
+		strings.Index(glossary, "[Greeter.Greet]") > strings.Index(glossary, "[NewGreeter]") {
+		t.Fatalf("expected alphabetical order\n\n%s", glossary)
+	}
+}
+
+func TestMethodIndexListsSignatures(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-method-index", "./testdata/example", "Greeter"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "### Method Index\n\n```go\nClose() error\nFarewell() string\nGreet() string\n```\n")
+	if strings.Index(out, "### Method Index") > strings.Index(out, "#### (\\*Greeter) Greet") {
+		t.Fatalf("expected the method index before the detailed methods\n\n%s", out)
+	}
+}
+
+func TestAbsoluteLinksUseBaseURL(t *testing.T) {
+	tmp := t.TempDir()
+	args := []string{"-relative-links=absolute", "-base-url", "https://docs.example.com/api/", "-o", tmp, "./testdata/example"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(root), "[subpkg](https://docs.example.com/api/subpkg/)")
+	if err := run([]string{"-relative-links=absolute", "-o", tmp, "./testdata/example"}, io.Discard); err == nil {
+		t.Fatalf("expected -relative-links=absolute without -base-url to fail")
+	}
+}
+
+func TestWrapProseKeepsInlineConstructsWhole(t *testing.T) {
+	in := "Call `Load(path string)` to read the file described at [the config docs](https://example.com/config) before starting.\n" +
+		"\n" +
+		"  - a list item that is long enough to need wrapping onto a second line\n" +
+		"\n" +
+		"```go\n" +
+		"x := strings.Repeat(\"a very long line of code that must never be wrapped\", 2)\n" +
+		"```"
+	want := "Call `Load(path string)` to read the\n" +
+		"file described at\n" +
+		"[the config docs](https://example.com/config)\n" +
+		"before starting.\n" +
+		"\n" +
+		"  - a list item that is long enough to\n" +
+		"    need wrapping onto a second line\n" +
+		"\n" +
+		"```go\n" +
+		"x := strings.Repeat(\"a very long line of code that must never be wrapped\", 2)\n" +
+		"```"
+	if got := wrapProse(in, 40); got != want {
+		t.Fatalf("wrapProse() =\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderImportsGroupsStandardLibrary(t *testing.T) {
+	r := markdownRenderer{imports: []string{"fmt", "github.com/spf13/cobra", "io"}}
+	var buf bytes.Buffer
+	r.renderImports(&buf)
+	want := "## Imports\n\nStandard library:\n\n- [fmt](https://pkg.go.dev/fmt)\n- [io](https://pkg.go.dev/io)\n\n" +
+		"External:\n\n- [github.com/spf13/cobra](https://pkg.go.dev/github.com/spf13/cobra)\n\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("renderImports() =\n%q\nwant\n%q", got, want)
+	}
+	docs := []treeDoc{
+		{pkgPath: "example.com/app", imports: []string{"example.com/app/store", "fmt", "github.com/spf13/cobra"}},
+		{pkgPath: "example.com/app/store", imports: []string{"github.com/spf13/cobra"}},
+	}
+	summary := string(dependencySummary(docs, options{}))
+	assertContains(t, summary, "- [github.com/spf13/cobra](https://pkg.go.dev/github.com/spf13/cobra) — imported by 2 packages\n")
+	if strings.Contains(summary, "example.com/app/store") || strings.Contains(summary, "fmt") {
+		t.Fatalf("expected only external dependencies from outside the tree\n\n%s", summary)
+	}
+}
+
+func TestSortImportsGroupedSplitsSameModule(t *testing.T) {
+	imports := make(map[string]*packages.Package)
+	for _, path := range []string{"io", "example.com/app/store", "github.com/spf13/cobra", "example.com/application", "fmt", "example.com/app/api"} {
+		imports[path] = nil
+	}
+	r := markdownRenderer{
+		options:    options{sortImportsGrouped: true},
+		modulePath: "example.com/app",
+		imports:    packageImports(&packages.Package{Imports: imports}),
+	}
+	var buf bytes.Buffer
+	r.renderImports(&buf)
+	want := "## Imports\n\nStandard library:\n\n- [fmt](https://pkg.go.dev/fmt)\n- [io](https://pkg.go.dev/io)\n\n" +
+		"Same module:\n\n- [example.com/app/api](https://pkg.go.dev/example.com/app/api)\n- [example.com/app/store](https://pkg.go.dev/example.com/app/store)\n\n" +
+		"Third party:\n\n- [example.com/application](https://pkg.go.dev/example.com/application)\n- [github.com/spf13/cobra](https://pkg.go.dev/github.com/spf13/cobra)\n\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("renderImports() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSortTypesOrders(t *testing.T) {
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"Mid", "Zeta", "alpha"}},
+		{[]string{"-sort-types=name"}, []string{"alpha", "Mid", "Zeta"}},
+		{[]string{"-sort-types=name", "-c"}, []string{"Mid", "Zeta", "alpha"}},
+		{[]string{"-sort-types=source"}, []string{"Zeta", "Mid", "alpha"}},
+		{[]string{"-sort-types=methods"}, []string{"Mid", "alpha", "Zeta"}},
+	}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		args := append([]string{"-all", "-u"}, tc.args...)
+		if err := run(append(args, "./testdata/sorting"), &buf); err != nil {
+			t.Fatalf("run %v: %v", tc.args, err)
+		}
+		out := buf.String()
+		last := -1
+		for _, name := range tc.want {
+			idx := strings.Index(out, "## type "+name+"\n")
+			if idx <= last {
+				t.Fatalf("%v: expected type sections in order %v\n\n%s", tc.args, tc.want, out)
+			}
+			last = idx
+		}
+	}
+	if err := run([]string{"-sort-types=size", "./testdata/sorting"}, io.Discard); err == nil {
+		t.Fatal("expected an unknown -sort-types value to be rejected")
+	}
+}
+
+func TestPackageWithoutExportsNote(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"./testdata/sparse/hidden"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "> This package has no exported symbols.")
+
+	buf.Reset()
+	if err := run([]string{"-u", "./testdata/sparse/hidden"}, &buf); err != nil {
+		t.Fatalf("run -u: %v", err)
+	}
+	if strings.Contains(buf.String(), "no exported symbols") {
+		t.Fatalf("-u output should not carry the note\n\n%s", buf.String())
+	}
+
+	tmp := t.TempDir()
+	if err := run([]string{"-skip-empty", "-o", tmp, "./testdata/sparse/..."}, io.Discard); err != nil {
+		t.Fatalf("run -skip-empty: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "hidden", "README.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected -skip-empty to leave out the hidden package: %v", err)
+	}
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	if strings.Contains(string(root), "hidden") {
+		t.Fatalf("expected the package list to omit the skipped package\n\n%s", root)
+	}
+}
+
+func TestCLIFlagsTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-cli-flags", "./testdata/cliflags"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "## Flags\n\n| Name | Default | Usage |\n| --- | --- | --- |\n")
+	assertContains(t, out, "| `-out` | `\"docs\"` | write files under `dir` |\n")
+	assertContains(t, out, "| `-depth` | `3` | heading depth (1-6) |\n")
+	assertContains(t, out, "| `-verbose`, `-v` | `false` | log \\| progress |\n")
+	assertContains(t, out, "| `-tag` |  | add a tag |\n")
+
+	buf.Reset()
+	if err := run([]string{"./testdata/cliflags"}, &buf); err != nil {
+		t.Fatalf("run without -cli-flags: %v", err)
+	}
+	if strings.Contains(buf.String(), "## Flags") {
+		t.Fatalf("expected no Flags table without -cli-flags\n\n%s", buf.String())
+	}
+}
+
+func TestExcludeDeprecated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "-exclude-deprecated", "./testdata/deprecated"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "type Client")
+	assertContains(t, out, "Limit")
+	for _, name := range []string{"DoLegacy", "OldClient", "MaxRetries", "<details>"} {
+		if strings.Contains(out, name) {
+			t.Fatalf("expected %s to be left out\n\n%s", name, out)
+		}
+	}
+
+	buf.Reset()
+	if err := run([]string{"-exclude-deprecated", "-show-deprecated-list", "./testdata/deprecated"}, &buf); err != nil {
+		t.Fatalf("run -show-deprecated-list: %v", err)
+	}
+	assertContains(t, buf.String(), "<details><summary>Deprecated (3)</summary>\n\n- `MaxRetries`\n- `Client.DoLegacy`\n- `OldClient`\n\n</details>\n")
+
+	if err := run([]string{"-show-deprecated-list", "./testdata/deprecated"}, io.Discard); err == nil {
+		t.Fatal("expected -show-deprecated-list without -exclude-deprecated to be rejected")
+	}
+}
+
+func TestHoistExampleQuickStart(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-hoist-example", "./testdata/quickstart"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	quick := "## Quick Start\n\n```go\ng := quickstart.New(\"Hello\")\nfmt.Println(g.Greet(\"Gopher\"))\n```\n"
+	assertContains(t, out, quick)
+	idx := strings.Index(out, quick)
+	if doc := strings.Index(out, "Package quickstart greets people."); doc < idx {
+		t.Fatalf("expected Quick Start before the package doc\n\n%s", out)
+	}
+	if strings.Count(out, "g := quickstart.New") != 2 {
+		t.Fatalf("expected the code block to stay in the doc as well\n\n%s", out)
+	}
+	if strings.Count(out, "## Quick Start") != 1 || strings.Index(out, `quickstart.New("Hi")`) < idx+len(quick) {
+		t.Fatalf("expected only the first code block to be hoisted\n\n%s", out)
+	}
+
+	buf.Reset()
+	if err := run([]string{"./testdata/quickstart"}, &buf); err != nil {
+		t.Fatalf("run without -hoist-example: %v", err)
+	}
+	if strings.Contains(buf.String(), "Quick Start") {
+		t.Fatalf("expected no Quick Start without -hoist-example\n\n%s", buf.String())
+	}
+}
+
+func TestGroupPackagesByDirNestsTOC(t *testing.T) {
+	tmp := t.TempDir()
+	if err := run([]string{"-group-packages-by-dir", "-o", tmp, "./testdata/layout/..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	root, err := os.ReadFile(filepath.Join(tmp, "README.md"))
+	if err != nil {
+		t.Fatalf("read root: %v", err)
+	}
+	assertContains(t, string(root), "## Packages\n\n"+
+		"- [api](api/README.md) — Package api serves requests.\n"+
+		"  - [v1](api/v1/README.md) — Package v1 is the first API version.\n"+
+		"- storage/\n"+
+		"  - [sql](storage/sql/README.md) — Package sql stores data in a database.\n\n")
+}
+
+func TestMaxCodeLineWarnsOnWideDeclarations(t *testing.T) {
+	var stderr bytes.Buffer
+	cmd := newRootCmd(io.Discard, &stderr)
+	cmd.SetArgs(normalizeLegacyArgs([]string{"-all", "-max-code-line", "40", "./testdata/quickstart"}))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, stderr.String(), "quickstart: Greeter.Greet: code line is 42 columns wide (-max-code-line 40)")
+	if strings.Contains(stderr.String(), ": Greeter:") {
+		t.Fatalf("expected declarations within the limit to pass\n\n%s", stderr.String())
+	}
+
+	if err := run([]string{"-all", "-max-code-line", "40", "-fail-on-warning", "./testdata/quickstart"}, io.Discard); err == nil {
+		t.Fatal("expected -fail-on-warning to turn the warning into an error")
+	}
+	if err := run([]string{"-all", "-max-code-line", "80", "-fail-on-warning", "./testdata/quickstart"}, io.Discard); err != nil {
+		t.Fatalf("expected declarations within the limit to pass: %v", err)
+	}
+}
+
+func TestAssemblyFunctionNote(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/asm"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "Add returns x + y.\n\n*Implemented in assembly.*\n")
+	if strings.Count(out, assemblyNote) != 1 {
+		t.Fatalf("expected only the bodyless Add to carry the note\n\n%s", out)
+	}
+	buf.Reset()
+	if err := run([]string{"-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Contains(buf.String(), assemblyNote) {
+		t.Fatalf("did not expect an assembly note without .s files\n\n%s", buf.String())
+	}
+}
+
+func TestCompilerDirectiveBadges(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/directives"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "#### Add\n\n`//go:noinline` `//go:nosplit`\n\n```go\nfunc Add(a, b int) int\n```")
+	if strings.Count(out, "//go:") != 2 {
+		t.Fatalf("expected the directives only as badges on Add\n\n%s", out)
+	}
+}
+
+func TestAllowNetworkFetchesRemotePackage(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	proxy := t.TempDir()
+	versions := filepath.Join(proxy, "example.com", "remote", "@v")
+	if err := os.MkdirAll(versions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	goMod := "module example.com/remote\n\ngo 1.21\n"
+	files := map[string]string{
+		"list":        "v1.0.0\n",
+		"v1.0.0.info": `{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`,
+		"v1.0.0.mod":  goMod,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(versions, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"go.mod":    goMod,
+		"remote.go": "// Package remote is fetched through the module proxy.\npackage remote\n\n// Answer is the answer.\nconst Answer = 42\n",
+	} {
+		f, err := zw.Create("example.com/remote@v1.0.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(versions, "v1.0.0.zip"), archive.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "-modcacherw")
+	work := t.TempDir()
+	if err := os.WriteFile(filepath.Join(work, "go.mod"), []byte("module example.com/work\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(work)
+
+	err := run([]string{"example.com/remote"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "-allow-network") {
+		t.Fatalf("expected a hint to pass -allow-network, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := run([]string{"-allow-network", "example.com/remote"}, &buf); err != nil {
+		t.Fatalf("run -allow-network: %v", err)
+	}
+	assertContains(t, buf.String(), "Package remote is fetched through the module proxy.")
+	assertContains(t, buf.String(), "Answer")
+
+	err = run([]string{"-allow-network", "example.com/missing"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "fetch example.com/missing") {
+		t.Fatalf("expected a fetch error for an unknown module, got %v", err)
+	}
+	if err := run([]string{"-allow-network", "-no-network", "example.com/remote"}, io.Discard); err == nil {
+		t.Fatal("expected -allow-network with -no-network to be rejected")
+	}
+}
+
+func TestNumberHeadings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-number-headings", "-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "# package example\n")
+	// Headings keep the level the renderer gives them; only the number is
+	// added.
+	assertContains(t, out, "\n### 1. Constants <a id=\"constants\"></a>\n")
+	assertContains(t, out, "\n#### 1.1. Answer, internalConstant <a id=\"answer-internalconstant\"></a>\n")
+	assertContains(t, out, "\n## 3. type Greeter <a id=\"type-greeter\"></a>\n")
+	assertContains(t, out, "\n### 3.1. Constructors <a id=\"constructors\"></a>\n")
+	assertContains(t, out, "\n#### 3.1.1. NewGreeter <a id=\"newgreeter\"></a>\n")
+	// A heading repeated in the document gets a distinct anchor.
+	assertContains(t, out, "\n### 5.1. Constants <a id=\"constants-1\"></a>\n")
+	assertContains(t, out, "\n### 5.2. Constructors <a id=\"constructors-1\"></a>\n")
+
+	buf.Reset()
+	if err := run([]string{"-number-headings", "-index", "-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// The package's Constants follow the Index as a peer, not a child.
+	assertContains(t, buf.String(), "\n## 1. Index <a id=\"index\"></a>\n")
+	assertContains(t, buf.String(), "\n### 2. Constants <a id=\"constants\"></a>\n")
+
+	buf.Reset()
+	if err := run([]string{"-number-headings", "-heading-offset", "1", "-all", "./testdata/example"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	assertContains(t, buf.String(), "## package example\n")
+	assertContains(t, buf.String(), "\n#### 1. Constants <a id=\"constants\"></a>\n")
+	assertContains(t, buf.String(), "\n##### 3.1.1. NewGreeter <a id=\"newgreeter\"></a>\n")
+
+	buf.Reset()
+	if err := run([]string{"-number-headings", "-all", "./testdata/widgets"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, id := range []string{"example", "example-1", "example-2"} {
+		if n := strings.Count(buf.String(), "<a id=\""+id+"\">"); n != 1 {
+			t.Fatalf("expected one anchor %q, got %d\n\n%s", id, n, buf.String())
+		}
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":     "module example.com/numbered\n\ngo 1.21\n",
+		"one/one.go": "// Package one is first.\npackage one\n\n// A is a type.\ntype A struct{}\n\n// B is a type.\ntype B struct{}\n",
+		"two/two.go": "// Package two is second.\npackage two\n\n// C is a type.\ntype C struct{}\n",
+	})
+	t.Chdir(dir)
+	outDir := filepath.Join(dir, "docs")
+	if err := run([]string{"-number-headings", "-all", "-o", outDir, "./..."}, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	one, err := os.ReadFile(filepath.Join(outDir, "one", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(one), "## 2. type B <a id=\"type-b\"></a>\n")
+	two, err := os.ReadFile(filepath.Join(outDir, "two", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(two), "## 1. type C <a id=\"type-c\"></a>\n")
+}
+
+func TestCompactDropsOptionalBlankLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-compact", "-short", "-all", "./testdata/quickstart"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	assertContains(t, out, "# package quickstart\n`import ")
+	assertContains(t, out, "Names are trimmed before greeting.\n\n```go\n")
+	assertContains(t, out, "- `type Greeter` — Greeter greets people.\n## type Greeter\n```go\n")
+	assertContains(t, out, "### Constructors\n- `func New(prefix string) Greeter`")
+
+	in := "<a id=\"x\"></a>\n\n## X\n\nText.\n\n\n\n- a\n\n- b\n\n```\nkeep\n\n\nthis\n```\n\n| A |\n| --- |\n\nEnd.\n"
+	want := "<a id=\"x\"></a>\n\n## X\nText.\n\n- a\n- b\n\n```\nkeep\n\n\nthis\n```\n\n| A |\n| --- |\n\nEnd.\n"
+	if got := string(compactMarkdown([]byte(in))); got != want {
+		t.Fatalf("compactMarkdown mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestExamplesRenderWithinTheirTypeSections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := run([]string{"-all", "./testdata/widgets"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	out := buf.String()
+	order := []string{
+		"## type Gadget\n",
+		"## type Widget\n",
+		"### Example\n\n```go\nfmt.Println(widgets.Widget{Size: 1}.Size)",
+		"#### (\\*Widget) Resize\n",
+		"##### Example\n\n```go\nvar w widgets.Widget",
+		"## Example\n\n```go\nfmt.Println(\"widgets\")",
+	}
+	last := -1
+	for _, want := range order {
+		idx := strings.Index(out, want)
+		if idx <= last {
+			t.Fatalf("expected %q after the previous section\n\n%s", want, out)
+		}
+		last = idx
+	}
+	gadget := out[strings.Index(out, "## type Gadget"):strings.Index(out, "## type Widget")]
+	if strings.Contains(gadget, "Example") {
+		t.Fatalf("expected no examples under Gadget\n\n%s", gadget)
 	}
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+)
+
+// limitMethodGroups keeps the first max methods of groups, in rendering
+// order, and returns the rest for the -max-methods overflow list.
+func limitMethodGroups(groups []methodGroup, max int) ([]methodGroup, []*doc.Func) {
+	var kept []methodGroup
+	var rest []*doc.Func
+	for _, group := range groups {
+		n := len(group.funcs)
+		if n > max {
+			n = max
+		}
+		if n > 0 {
+			kept = append(kept, methodGroup{title: group.title, funcs: group.funcs[:n]})
+		}
+		rest = append(rest, group.funcs[n:]...)
+		max -= n
+	}
+	return kept, rest
+}
+
+// renderAdditionalMethods lists the methods -max-methods left out of the
+// full rendering as signatures with their summaries.
+func (r *markdownRenderer) renderAdditionalMethods(w io.Writer, methods []*doc.Func) {
+	if len(methods) == 0 {
+		return
+	}
+	r.heading(w, 3, "Additional Methods (%d more)", len(methods))
+	for _, m := range methods {
+		fmt.Fprintf(w, "%s\n", bulletLine(r.signature(m.Decl), r.summaryText(m.Doc)))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const navFileName = "nav.json"
+
+// navNode is one entry of nav.json. Directories that hold no package of their
+// own have no path.
+type navNode struct {
+	Title    string     `json:"title"`
+	Path     string     `json:"path,omitempty"`
+	Summary  string     `json:"summary,omitempty"`
+	Children []*navNode `json:"children,omitempty"`
+}
+
+// writeNavJSON writes nav.json into dir, nesting the TOC entries by the path
+// segments of their titles beneath the root document. Children are sorted by
+// title so the file diffs cleanly between runs.
+func writeNavJSON(fw docWriter, dir string, rootDoc *treeDoc, rootLink string, entries []tocEntry) error {
+	root := &navNode{Title: "."}
+	if rootDoc != nil {
+		root.Title = linkTitle(rootDoc)
+		root.Path = rootLink
+		root.Summary = strings.TrimSpace(rootDoc.summary)
+	}
+	for _, entry := range entries {
+		node := root
+		for _, segment := range strings.Split(entry.title, "/") {
+			node = node.child(segment)
+		}
+		node.Path = entry.link
+		node.Summary = entry.summary
+	}
+	root.sortChildren()
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fw.writeFile(filepath.Join(dir, navFileName), append(data, '\n'))
+}
+
+func (n *navNode) child(title string) *navNode {
+	for _, c := range n.Children {
+		if c.Title == title {
+			return c
+		}
+	}
+	c := &navNode{Title: title}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *navNode) sortChildren() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Title < n.Children[j].Title
+	})
+	for _, c := range n.Children {
+		c.sortChildren()
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// headingNumbers hands out the -number-headings section numbers of one
+// document as the renderer emits its headings.
+type headingNumbers struct {
+	// open holds the levels, -heading-offset applied, of the numbered
+	// headings enclosing the next one, outermost first.
+	open []int
+	// counts[d] is the number of the latest heading at depth d+1.
+	counts []int
+	// anchors counts the anchors handed out so far, keyed by slug, so a
+	// repeated heading such as Example gets a distinct id.
+	anchors map[string]int
+}
+
+// next numbers a heading at level, nesting it under the open headings of a
+// lower level and closing the rest, and returns its depth and number.
+func (n *headingNumbers) next(level int) (int, string) {
+	for len(n.open) > 0 && n.open[len(n.open)-1] >= level {
+		n.open = n.open[:len(n.open)-1]
+	}
+	n.open = append(n.open, level)
+	depth := len(n.open)
+	if len(n.counts) > depth {
+		n.counts = n.counts[:depth]
+	}
+	for len(n.counts) < depth {
+		n.counts = append(n.counts, 0)
+	}
+	n.counts[depth-1]++
+	parts := make([]string, depth)
+	for i, c := range n.counts {
+		parts[i] = strconv.Itoa(c)
+	}
+	return depth, strings.Join(parts, ".") + "."
+}
+
+// anchor returns slug, or slug with GitHub's -1, -2, ... suffix when the
+// document already holds an anchor of that name.
+func (n *headingNumbers) anchor(slug string) string {
+	if n.anchors == nil {
+		n.anchors = make(map[string]int)
+	}
+	id := slug
+	for n.anchors[id] > 0 {
+		id = fmt.Sprintf("%s-%d", slug, n.anchors[slug])
+		n.anchors[slug]++
+	}
+	n.anchors[id]++
+	return id
+}
+
+// close ends every open section deeper than depth, so the next heading is
+// numbered at depth+1 whatever its level.
+func (n *headingNumbers) close(depth int) {
+	if len(n.open) > depth {
+		n.open = n.open[:depth]
+	}
+}
+
+// headingLine returns text as a heading at level, numbered for
+// -number-headings. The title is left unnumbered and restarts the count.
+// Other headings keep their level and are numbered by the section they
+// belong to. Each numbered heading keeps its unnumbered anchor as an empty
+// HTML anchor, so links to the plain text still resolve; repeats of an
+// anchor in the document are suffixed the way GitHub suffixes them.
+func (r *markdownRenderer) headingLine(w io.Writer, level int, text string) string {
+	if !r.options.numberHeadings {
+		return headingText(level, r.options, text)
+	}
+	numbers := &r.numbers
+	if r.examples != nil && w == io.Writer(r.examples) {
+		numbers = &r.exampleNumbers
+	}
+	if *numbers == nil || level == 1 {
+		*numbers = &headingNumbers{}
+	}
+	if level == 1 {
+		return headingText(level, r.options, text)
+	}
+	n := *numbers
+	_, number := n.next(level + r.options.headingOffset)
+	id := n.anchor(slugify(r.options.anchorFormat, text))
+	return headingText(level, r.options, fmt.Sprintf("%s %s <a id=\"%s\"></a>", number, text, id))
+}
+
+// closeSections ends the numbered sections opened inside this renderer's
+// part of the document, before sections that start a new top-level run at a
+// deeper heading level, as the package's ### Constants do after an Index.
+func (r *markdownRenderer) closeSections() {
+	if r.numbers != nil {
+		r.numbers.close(r.numberDepth)
+	}
+}
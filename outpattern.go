@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var outputPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// outputPlaceholders are the -o placeholders: {pkg} is the package name and
+// {dir} its directory relative to the tree root.
+var outputPlaceholders = []string{"{pkg}", "{dir}"}
+
+// hasOutputPlaceholder reports whether -o is a per-package path pattern such
+// as docs/{pkg}.md. Other braces, as in docs/{draft}/API.md, are part of a
+// plain path.
+func hasOutputPlaceholder(path string) bool {
+	if isPipeOutput(path) {
+		return false
+	}
+	for _, p := range outputPlaceholders {
+		if strings.Contains(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOutputPattern rejects -o patterns with unknown or malformed
+// placeholders next to the supported ones.
+func validateOutputPattern(path string) error {
+	return checkPlaceholders("-o", path, outputPlaceholders...)
+}
+
+// checkPlaceholders rejects placeholders in value, the argument of flag, that
+// are not listed in allowed, and braces that do not pair up.
+func checkPlaceholders(flag, value string, allowed ...string) error {
+	for _, p := range outputPlaceholder.FindAllString(value, -1) {
+		if !contains(allowed, p) {
+			return fmt.Errorf("unknown placeholder %s in %s (want %s)", p, flag, strings.Join(allowed, ", "))
+		}
+	}
+	if strings.ContainsAny(outputPlaceholder.ReplaceAllString(value, ""), "{}") {
+		return fmt.Errorf("unbalanced braces in %s %q", flag, value)
+	}
+	return nil
+}
+
+// expandOutputPattern substitutes doc's placeholders into pattern.
+func expandOutputPattern(pattern string, doc *treeDoc) string {
+	dir := doc.relDir
+	if dir == "." {
+		dir = ""
+	}
+	expanded := strings.NewReplacer("{pkg}", doc.pkgName, "{dir}", filepath.ToSlash(dir)).Replace(pattern)
+	return filepath.Clean(filepath.FromSlash(expanded))
+}
+
+// writePackageDocsToPattern writes each package to its own file named by
+// expanding the -o pattern. Two packages must not expand to the same file.
+func writePackageDocsToPattern(fw docWriter, pattern string, docs []treeDoc, opts options) error {
+	owners := make(map[string]string, len(docs))
+	for i := range docs {
+		doc := &docs[i]
+		target := expandOutputPattern(pattern, doc)
+		if other, ok := owners[target]; ok {
+			return fmt.Errorf("-o %s maps both %s and %s to %s", pattern, other, doc.pkgPath, target)
+		}
+		owners[target] = doc.pkgPath
+	}
+	for i := range docs {
+		doc := &docs[i]
+		if err := writeDocument(fw, expandOutputPattern(pattern, doc), doc.markdown, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"strings"
+)
+
+type paramDoc struct {
+	name string
+	desc string
+}
+
+// splitParamDocs pulls `name: description` lines naming one of decl's
+// parameters or named results out of text. Docs are returned in declaration
+// order along with the remaining prose.
+func splitParamDocs(decl *ast.FuncDecl, text string) (params, results []paramDoc, rest string) {
+	if decl == nil || decl.Type == nil {
+		return nil, nil, text
+	}
+	paramNames := fieldNames(decl.Type.Params)
+	resultNames := fieldNames(decl.Type.Results)
+	if len(paramNames) == 0 && len(resultNames) == 0 {
+		return nil, nil, text
+	}
+	found := make(map[string]string)
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		name, desc, ok := strings.Cut(strings.TrimSpace(line), ":")
+		desc = strings.TrimSpace(desc)
+		if ok && desc != "" && (contains(paramNames, name) || contains(resultNames, name)) {
+			if _, dup := found[name]; !dup {
+				found[name] = desc
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	if len(found) == 0 {
+		return nil, nil, text
+	}
+	collect := func(names []string) []paramDoc {
+		var docs []paramDoc
+		for _, name := range names {
+			if desc, ok := found[name]; ok {
+				docs = append(docs, paramDoc{name: name, desc: desc})
+			}
+		}
+		return docs
+	}
+	return collect(paramNames), collect(resultNames), strings.Join(kept, "\n")
+}
+
+func fieldNames(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range fields.List {
+		for _, ident := range field.Names {
+			if ident.Name != "_" {
+				names = append(names, ident.Name)
+			}
+		}
+	}
+	return names
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *markdownRenderer) renderParamTable(w io.Writer, title string, docs []paramDoc) {
+	if len(docs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "| %s | Description |\n", title)
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, d := range docs {
+		fmt.Fprintf(w, "| `%s` | %s |\n", d.name, escapeTableCell(d.desc))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// isPipeOutput reports whether -o names a command to pipe the document into,
+// written with a leading pipe as in -o '|pandoc -o api.pdf'.
+func isPipeOutput(path string) bool {
+	return strings.HasPrefix(path, "|")
+}
+
+// pipeCommand splits a pipe -o value into the program and its arguments.
+// Arguments are split on white space; there is no shell quoting, so wrap the
+// command in a script when it needs any.
+func pipeCommand(path string) ([]string, error) {
+	args := strings.Fields(strings.TrimPrefix(path, "|"))
+	if len(args) == 0 {
+		return nil, errors.New("-o pipe is missing a command")
+	}
+	return args, nil
+}
+
+// writePipe runs the -o pipe command with data on its standard input. The
+// command's own output and errors pass through; a failure to start it or a
+// non-zero exit is returned as an error.
+func writePipe(ctx context.Context, path string, stdout, stderr io.Writer, data []byte) error {
+	args, err := pipeCommand(path)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("-o pipe %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultPkgHeadingFormat is the package title line go-docmd has always
+// written.
+const defaultPkgHeadingFormat = "package {name}"
+
+// renderGoVersionNote writes, for -go-version-note, the go directive of the
+// module whose root package is being rendered. Other packages, and packages
+// loaded without module information, render nothing.
+func (r *markdownRenderer) renderGoVersionNote(w io.Writer) {
+	if !r.options.goVersionNote || r.goVersion == "" || r.modulePath == "" || r.pkg.ImportPath != r.modulePath {
+		return
+	}
+	fmt.Fprintf(w, "*Requires Go %s or later.*\n\n", r.goVersion)
+}
+
+// packageTitle expands -pkg-heading-format for the documented package:
+// {name} is the package name, {import} its import path, and {module} the
+// path of the module that contains it.
+func (r *markdownRenderer) packageTitle() string {
+	format := r.options.pkgHeadingFormat
+	if format == "" {
+		format = defaultPkgHeadingFormat
+	}
+	return strings.NewReplacer(
+		"{name}", r.pkg.Name,
+		"{import}", r.displayImportPath(),
+		"{module}", r.modulePath,
+	).Replace(format)
+}
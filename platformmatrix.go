@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// renderPlatformMatrix writes the -platform-matrix table: one row per
+// exported top-level symbol of any variant and one column per GOOS, in -goos
+// order, with ✓ where the platform declares the symbol under that name.
+func (r *markdownRenderer) renderPlatformMatrix(w io.Writer, variants []*goosVariant) {
+	type row struct {
+		sym addedSymbol
+		on  map[string]bool
+	}
+	index := make(map[addedSymbol]*row)
+	var rows []*row
+	for _, v := range variants {
+		for _, sym := range addedSymbols(v.docPkg, nil) {
+			if index[sym] == nil {
+				index[sym] = &row{sym: sym, on: make(map[string]bool)}
+				rows = append(rows, index[sym])
+			}
+			index[sym].on[v.goos] = true
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].sym.name != rows[j].sym.name {
+			return rows[i].sym.name < rows[j].sym.name
+		}
+		return rows[i].sym.kind < rows[j].sym.kind
+	})
+	r.heading(w, 2, "Platform Availability")
+	header, rule := "| Symbol |", "| --- |"
+	for _, v := range variants {
+		header += " " + v.goos + " |"
+		rule += " :-: |"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, rule)
+	for _, row := range rows {
+		line := "| `" + row.sym.kind + " " + row.sym.name + "` |"
+		for _, v := range variants {
+			if row.on[v.goos] {
+				line += " ✓ |"
+			} else {
+				line += "  |"
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/doc"
+	"go/format"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// playgroundShareURL is the Go Playground endpoint that stores a program and
+// answers with its snippet ID; tests point it at a local server.
+var playgroundShareURL = "https://play.golang.org/share"
+
+const playgroundTimeout = 10 * time.Second
+
+// playgroundSharer uploads runnable examples for -playground-links and
+// remembers each program's link, so an example rendered twice (as with
+// -examples-file) is shared once.
+type playgroundSharer struct {
+	ctx    context.Context
+	client *http.Client
+	mu     sync.Mutex
+	links  map[string]string
+}
+
+func newPlaygroundSharer(ctx context.Context) *playgroundSharer {
+	return &playgroundSharer{
+		ctx:    ctx,
+		client: &http.Client{Timeout: playgroundTimeout},
+		links:  make(map[string]string),
+	}
+}
+
+// share returns the playground URL of src, or "" when the upload fails.
+// Failures are remembered so an unreachable playground costs one timeout.
+func (s *playgroundSharer) share(src []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if link, ok := s.links[string(src)]; ok {
+		return link
+	}
+	link := s.upload(src)
+	s.links[string(src)] = link
+	if link == "" {
+		s.client = nil
+	}
+	return link
+}
+
+func (s *playgroundSharer) upload(src []byte) string {
+	if s.client == nil {
+		return ""
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, playgroundShareURL, bytes.NewReader(src))
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	id := strings.TrimSpace(string(body))
+	if id == "" || strings.ContainsAny(id, " /?#") {
+		return ""
+	}
+	return "https://go.dev/play/p/" + id
+}
+
+// renderPlaygroundLink writes a "Run in Playground" link for an example that
+// go/doc could turn into a complete program. Examples without one, and
+// examples the playground did not accept, render nothing.
+func (r *markdownRenderer) renderPlaygroundLink(w io.Writer, ex *doc.Example) {
+	if r.options.playground == nil || ex.Play == nil || r.fileset == nil {
+		return
+	}
+	var src bytes.Buffer
+	if err := format.Node(&src, r.fileset, ex.Play); err != nil {
+		return
+	}
+	if link := r.options.playground.share(src.Bytes()); link != "" {
+		fmt.Fprintf(w, "[Run in Playground](%s)\n\n", link)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// treeProgress reports -progress while a tree renders. On a terminal it
+// redraws one "[42/200] example/subpkg" line; elsewhere, such as CI logs, it
+// prints a line every tenth of the way. A nil *treeProgress records nothing.
+type treeProgress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tty   bool
+	total int
+	done  int
+	step  int
+}
+
+func newTreeProgress(w io.Writer) *treeProgress {
+	p := &treeProgress{w: w}
+	if f, ok := w.(*os.File); ok {
+		info, err := f.Stat()
+		p.tty = err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return p
+}
+
+// start sets the number of packages the run will render.
+func (p *treeProgress) start(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+	p.step = total / 10
+	if p.step < 1 {
+		p.step = 1
+	}
+}
+
+// finished counts one rendered package. It is safe for concurrent use.
+func (p *treeProgress) finished(pkgPath string) {
+	if p == nil || p.w == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	switch {
+	case p.tty:
+		fmt.Fprintf(p.w, "\r\x1b[K[%d/%d] %s", p.done, p.total, pkgPath)
+		if p.done == p.total {
+			fmt.Fprintln(p.w)
+		}
+	case p.done%p.step == 0 || p.done == p.total:
+		fmt.Fprintf(p.w, "[%d/%d] %s\n", p.done, p.total, pkgPath)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// promotedField is a field reachable through one or more embedded structs.
+type promotedField struct {
+	field *types.Var
+	// via lists the embedded fields walked to reach field, outermost first.
+	via []string
+}
+
+// renderPromotedFieldDoc renders fields of t that match fieldName and are
+// promoted from embedded structs, noting which embedding they come from. It
+// needs type information and reports false without it.
+func (r *markdownRenderer) renderPromotedFieldDoc(w io.Writer, t *doc.Type, fieldName string) bool {
+	spec := findTypeSpec(t.Decl, t.Name)
+	if spec == nil || r.typesInfo == nil {
+		return false
+	}
+	obj := r.typesInfo.Defs[spec.Name]
+	if obj == nil {
+		return false
+	}
+	var rendered bool
+	for _, p := range r.promotedFields(obj.Type(), fieldName) {
+		name := p.field.Name()
+		// Skip fields shadowed by a shallower one or made ambiguous by
+		// embedding the same name twice at one depth.
+		if found, _, _ := types.LookupFieldOrMethod(obj.Type(), true, p.field.Pkg(), name); found != p.field {
+			continue
+		}
+		decl, docText := r.fieldSource(p.field)
+		if decl == "" {
+			decl = name + " " + types.TypeString(p.field.Type(), types.RelativeTo(obj.Pkg()))
+		}
+		note := fmt.Sprintf("*Promoted from embedded `%s`.*", strings.Join(p.via, "."))
+		if r.options.short {
+			summary := r.summaryText(docText)
+			if summary == "" {
+				summary = note
+			}
+			fmt.Fprintf(w, "%s\n", bulletLine(t.Name+"."+name, summary))
+		} else {
+			r.heading(w, 4, "%s.%s", t.Name, name)
+			r.writeDeclBlock(w, t.Name+"."+name, decl)
+			fmt.Fprintf(w, "%s\n\n", note)
+			if doc := r.docMarkdown(docText); doc != "" {
+				fmt.Fprintln(w, doc)
+				fmt.Fprintln(w)
+			}
+		}
+		rendered = true
+	}
+	return rendered
+}
+
+// promotedFields walks the embedded structs of typ breadth-first and returns
+// every non-embedded field matching fieldName below the top level.
+func (r *markdownRenderer) promotedFields(typ types.Type, fieldName string) []promotedField {
+	type step struct {
+		typ types.Type
+		via []string
+	}
+	var found []promotedField
+	seen := make(map[types.Type]bool)
+	queue := []step{{typ: typ}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if ptr, ok := cur.typ.(*types.Pointer); ok {
+			cur.typ = ptr.Elem()
+		}
+		if seen[cur.typ] {
+			continue
+		}
+		seen[cur.typ] = true
+		st, ok := cur.typ.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			via := append(append([]string{}, cur.via...), field.Name())
+			if field.Embedded() {
+				queue = append(queue, step{typ: field.Type(), via: via})
+				continue
+			}
+			if len(cur.via) > 0 && r.matchName(field.Name(), fieldName) {
+				found = append(found, promotedField{field: field, via: cur.via})
+			}
+		}
+	}
+	return found
+}
+
+// fieldSource returns the declaration and doc comment of field when it is
+// declared in one of the package's documented types.
+func (r *markdownRenderer) fieldSource(field *types.Var) (string, string) {
+	for _, t := range r.pkg.Types {
+		spec := findTypeSpec(t.Decl, t.Name)
+		if spec == nil {
+			continue
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok || st.Fields == nil {
+			continue
+		}
+		for _, f := range st.Fields.List {
+			for _, name := range f.Names {
+				if name.Pos() != field.Pos() {
+					continue
+				}
+				docText := ""
+				if f.Doc != nil {
+					docText = f.Doc.Text()
+				}
+				return r.formatField(f), docText
+			}
+		}
+	}
+	return "", ""
+}
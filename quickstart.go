@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"io"
+)
+
+// renderQuickStart writes, for -hoist-example, the first code block of the
+// package doc under a Quick Start heading right after the title. The block
+// also stays where it is in the doc below.
+func (r *markdownRenderer) renderQuickStart(w io.Writer) {
+	if !r.options.hoistExample {
+		return
+	}
+	text := r.pkg.Doc
+	for _, name := range docDirectives {
+		_, text = docDirective(text, name)
+	}
+	for _, block := range r.pkg.Parser().Parse(text).Content {
+		code, ok := block.(*comment.Code)
+		if !ok {
+			continue
+		}
+		r.heading(w, 2, "Quick Start")
+		fmt.Fprintf(w, "%s\n\n", fencedBlock(r.fenceLang(code.Text), code.Text))
+		return
+	}
+}
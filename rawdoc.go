@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+// wantsRawDoc reports whether -raw-doc names the package being rendered, in
+// which case its doc comments are treated as finished Markdown.
+func (r *markdownRenderer) wantsRawDoc() bool {
+	if r.options.rawDoc == "" || r.pkg == nil {
+		return false
+	}
+	for _, pattern := range strings.Split(r.options.rawDoc, ",") {
+		if matchImportPattern(strings.TrimSpace(pattern), r.pkg.ImportPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchImportPattern matches an import path against pattern, where a trailing
+// "/..." also matches every path beneath the prefix.
+func matchImportPattern(pattern, importPath string) bool {
+	if pattern == "" {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+	return pattern == importPath
+}
+
+// rawDocMarkdown strips directives from text and dedents it, leaving the
+// markup exactly as written.
+func rawDocMarkdown(text string) string {
+	for _, name := range docDirectives {
+		_, text = docDirective(text, name)
+	}
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return ""
+	}
+	return dedentMarkdown(trimmed)
+}
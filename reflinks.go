@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// referenceLinks rewrites the inline links of a rendered document,
+// [text](url), as reference links, [text][1], and appends the numbered
+// definitions at the end for -ref-links. Repeated URLs share a number.
+// Links inside code, images, and in-page #anchor links stay inline.
+func referenceLinks(md []byte) []byte {
+	var (
+		out   strings.Builder
+		urls  []string
+		index = make(map[string]int)
+		fence string
+	)
+	ref := func(url string) int {
+		if n, ok := index[url]; ok {
+			return n
+		}
+		urls = append(urls, url)
+		index[url] = len(urls)
+		return len(urls)
+	}
+	lines := strings.SplitAfter(string(md), "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, "`") == "" {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "`"))]
+			out.WriteString(line)
+			continue
+		}
+		out.WriteString(referenceLine(line, ref))
+	}
+	if len(urls) == 0 {
+		return md
+	}
+	doc := strings.TrimRight(out.String(), "\n")
+	out.Reset()
+	out.WriteString(doc)
+	out.WriteString("\n\n")
+	for i, url := range urls {
+		fmt.Fprintf(&out, "[%d]: %s\n", i+1, url)
+	}
+	return []byte(out.String())
+}
+
+// referenceLine rewrites the inline links of one line outside code fences.
+func referenceLine(line string, ref func(string) int) string {
+	var out strings.Builder
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case '`':
+			end := codeSpanEnd(line, i)
+			out.WriteString(line[i:end])
+			i = end
+			continue
+		case '[':
+			if i > 0 && line[i-1] == '!' {
+				break
+			}
+			textEnd := closingBracket(line, i)
+			if textEnd < 0 || textEnd+1 >= len(line) || line[textEnd+1] != '(' {
+				break
+			}
+			urlEnd := closingParen(line, textEnd+1)
+			if urlEnd < 0 {
+				break
+			}
+			url := line[textEnd+2 : urlEnd]
+			if url == "" || strings.HasPrefix(url, "#") || strings.ContainsAny(url, " \t") {
+				break
+			}
+			fmt.Fprintf(&out, "%s[%d]", referenceLine(line[i:textEnd+1], ref), ref(url))
+			i = urlEnd + 1
+			continue
+		}
+		out.WriteByte(line[i])
+		i++
+	}
+	return out.String()
+}
+
+// closingBracket returns the index of the ] matching the [ at start, skipping
+// nested brackets and code spans, or -1.
+func closingBracket(line string, start int) int {
+	depth := 0
+	for i := start; i < len(line); {
+		switch line[i] {
+		case '`':
+			i = codeSpanEnd(line, i)
+			continue
+		case '\\':
+			i += 2
+			continue
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// closingParen returns the index of the ) matching the ( at start, so a URL
+// may hold balanced parentheses as Wikipedia's do, or -1.
+func closingParen(line string, start int) int {
+	depth := 0
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type remoteFetchKey struct{}
+
+// remoteError is why a package outside the module's dependencies did not
+// resolve: -allow-network is off, or fetching it failed.
+type remoteError struct{ err error }
+
+func (e *remoteError) Error() string { return e.err.Error() }
+func (e *remoteError) Unwrap() error { return e.err }
+
+// withRemoteFetch returns a context in which resolvePackage may fetch
+// packages outside the current module's dependency graph (-allow-network).
+func withRemoteFetch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, remoteFetchKey{}, true)
+}
+
+func remoteFetchAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(remoteFetchKey{}).(bool)
+	return allowed
+}
+
+// isRemoteImportPath reports whether expr looks like the import path of a
+// package in some module, such as golang.org/x/time/rate: a path whose
+// first element is a domain name, with no relative prefix or pattern.
+func isRemoteImportPath(expr string) bool {
+	if expr == "" || strings.Contains(expr, "...") || strings.HasPrefix(expr, ".") || filepath.IsAbs(expr) {
+		return false
+	}
+	first, _, _ := strings.Cut(expr, "/")
+	return strings.Contains(first, ".")
+}
+
+// loadRemotePackage is fetchRemotePackage with its errors marked as
+// remoteError.
+func loadRemotePackage(ctx context.Context, importPath string) (*packages.Package, error) {
+	pkg, err := fetchRemotePackage(ctx, importPath)
+	if err != nil {
+		return nil, &remoteError{withKind(kindPackageNotFound, importPath, err)}
+	}
+	return pkg, nil
+}
+
+// fetchRemotePackage documents a package the current module does not depend
+// on, as go doc can: it adds the package's module to a scratch module with
+// go get, which downloads it through the module proxy into the module
+// cache, and loads the package from there.
+func fetchRemotePackage(ctx context.Context, importPath string) (*packages.Package, error) {
+	dir, err := os.MkdirTemp("", "go-docmd-remote-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module go-docmd-remote\n"), 0o644); err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "get", importPath+"@latest")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("fetch %s: %s", importPath, msg)
+		}
+		return nil, fmt.Errorf("fetch %s: go get: %w", importPath, err)
+	}
+	cfg := &packages.Config{
+		Mode: loadMode(ctx),
+		Dir:  dir,
+	}
+	pkgs, err := loadPackages(ctx, cfg, importPath)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("fetch %s: %w", importPath, err)
+	case len(pkgs) == 0:
+		return nil, fmt.Errorf("fetch %s: no Go packages matched", importPath)
+	case len(pkgs[0].Errors) > 0:
+		return nil, fmt.Errorf("fetch %s: %s", importPath, pkgs[0].Errors[0])
+	}
+	return pkgs[0], nil
+}
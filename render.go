@@ -6,48 +6,186 @@ import (
 	"go/ast"
 	"go/doc"
 	"go/format"
+	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"sort"
 	"strings"
 )
 
 type markdownRenderer struct {
-	options options
-	pkg     *doc.Package
-	fileset *token.FileSet
+	options   options
+	pkg       *doc.Package
+	fileset   *token.FileSet
+	typesInfo *types.Info
+	// examples, when set, receives example rendering instead of the main
+	// document (see -examples-file).
+	examples *bytes.Buffer
+	// implements, when set, supplies the candidate types for -implements.
+	implements *implementsIndex
+	// goVersion is the go directive of the documented package's module.
+	goVersion string
+	// modulePath is the path of the documented package's module.
+	modulePath string
+	// importPath, when set, overrides pkg.ImportPath in the import line; it
+	// holds the package's canonical import comment.
+	importPath string
+	// templateErr records the first -template-dir execution failure.
+	templateErr error
+	// imports lists the package's direct imports for -deps.
+	imports []string
+	// addedSince lists the symbols missing at the -added-since ref.
+	addedSince []addedSymbol
+	// benchmarks lists the package's benchmark functions for -benchmarks.
+	benchmarks []benchmarkFunc
+	// embeds holds the package's //go:embed files for -embed-examples.
+	embeds []embeddedFile
+	// buildConstraints caches the -build-notes text of each source file.
+	buildConstraints map[string]string
+	// sources holds the package's Go files for -embed-source.
+	sources []embeddedFile
+	// aliases holds the type aliases -alias lists in a table instead of
+	// type sections.
+	aliases []*doc.Type
+	// syntax holds the package's files for -callers when a single symbol
+	// is rendered.
+	syntax []*ast.File
+	// tools holds the package's build-ignored programs for -include-ignored.
+	tools []toolFile
+	// noExports is set for a library package that exports nothing.
+	noExports bool
+	// cliFlags holds a command's flag registrations for -cli-flags.
+	cliFlags []cliFlag
+	// deprecated names the symbols -exclude-deprecated removed.
+	deprecated []string
+	// directives holds the compiler directives of each function
+	// declaration.
+	directives map[string][]string
+	// assembly marks the bodyless function declarations of a package with
+	// .s files.
+	assembly map[string]bool
+	// focus and focusMethod name the symbol, or Type and method, that a
+	// single-symbol document renders; both are empty for a whole package.
+	focus, focusMethod string
+	// numbers and exampleNumbers number the headings of the document and of
+	// the -examples-file document. numberDepth is how many sections deep
+	// this renderer's own sections start when it shares the numbers of the
+	// document it renders into.
+	numbers, exampleNumbers *headingNumbers
+	numberDepth             int
 }
 
 func (r *markdownRenderer) renderPackage(w io.Writer) {
+	if tmpls := r.activeTemplates(); tmpls != nil && tmpls.pkg != nil {
+		r.renderPackageTemplate(w, tmpls.pkg)
+		return
+	}
 	if r.pkg.Name != "main" {
-		fmt.Fprintf(w, "# package %s\n\n", r.pkg.Name)
-		if r.pkg.ImportPath != "" {
-			fmt.Fprintf(w, "`import \"%s\"`\n\n", r.pkg.ImportPath)
+		r.heading(w, 1, "%s", r.packageTitle())
+		if path := r.displayImportPath(); path != "" && !r.options.noImportLine {
+			fmt.Fprintf(w, "`import \"%s\"`\n\n", path)
 		}
 	}
+	r.renderQuickStart(w)
+	r.renderGoVersionNote(w)
+	r.renderStability(w)
 	if doc := r.docMarkdown(r.pkg.Doc); doc != "" {
 		fmt.Fprintln(w, doc)
 		fmt.Fprintln(w)
 	}
+	r.renderNoExportsNote(w)
+	r.renderPackageBody(w)
+	r.renderCLIFlags(w)
+	r.renderBenchmarks(w)
+	r.renderAddedSince(w)
+	if r.options.deps {
+		r.renderImports(w)
+	}
+	if r.examples != nil && r.examples.Len() > 0 {
+		name := examplesFileName(r.options)
+		fmt.Fprintf(w, "Examples are collected in [%s](%s).\n\n", name, name)
+	}
+	if r.options.glossary {
+		r.renderGlossary(w)
+	}
+	r.renderTools(w)
+	r.renderSource(w)
+	r.renderDeprecatedList(w)
+}
+
+// renderPackageBody writes the symbol summary and, with -all, the full
+// sections that follow the package doc.
+func (r *markdownRenderer) renderPackageBody(w io.Writer) {
+	r.closeSections()
 	if r.pkg.Name == "main" && !r.options.showCmd && !r.options.all {
 		r.renderPackageSummary(w)
-		if r.options.includeMainVars || r.options.all {
+		if (r.options.includeMainVars || r.options.all) && r.wantsKind(kindVars) {
 			r.renderValuesSection(w, "Variables", r.pkg.Vars)
 		}
-		if r.options.includeMainFuncs || r.options.all {
+		if (r.options.includeMainFuncs || r.options.all) && r.wantsKind(kindFuncs) {
 			r.renderFuncsSection(w, "Functions", r.pkg.Funcs, "")
 		}
 		return
 	}
-	r.renderPackageSummary(w)
+	if r.options.index {
+		r.renderIndex(w)
+	} else {
+		r.renderPackageSummary(w)
+	}
 	if r.options.all {
-		r.renderValuesSection(w, "Constants", r.pkg.Consts)
-		r.renderValuesSection(w, "Variables", r.pkg.Vars)
-		r.renderFuncsSection(w, "Functions", r.pkg.Funcs, "")
-		r.renderTypesSection(w, r.pkg.Types)
+		// The package's sections are peers of the Index, not part of it.
+		r.closeSections()
+		if r.wantsKind(kindConsts) {
+			r.renderValuesSection(w, "Constants", r.pkg.Consts)
+		}
+		if r.wantsKind(kindVars) {
+			r.renderValuesSection(w, "Variables", r.pkg.Vars)
+		}
+		if r.wantsKind(kindFuncs) {
+			r.renderFuncsSection(w, "Functions", r.pkg.Funcs, "")
+		}
+		if r.wantsKind(kindTypes) {
+			r.renderTypesSection(w, r.pkg.Types)
+		}
+		r.renderExamples(w, 2, "package "+r.pkg.Name, r.pkg.Examples)
+	}
+	if r.wantsKind(kindTypes) {
+		r.renderAliases(w)
 	}
 }
 
+const (
+	kindTypes  = "types"
+	kindFuncs  = "funcs"
+	kindConsts = "consts"
+	kindVars   = "vars"
+)
+
+// parseOnlyKinds parses the -only selector into a set of section kinds. An
+// empty selector yields a nil set, meaning every kind is rendered.
+func parseOnlyKinds(spec string) (map[string]bool, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	kinds := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		kind := strings.ToLower(strings.TrimSpace(part))
+		switch kind {
+		case kindTypes, kindFuncs, kindConsts, kindVars:
+			kinds[kind] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("invalid -only kind %q (want types, funcs, consts, or vars)", part)
+		}
+	}
+	return kinds, nil
+}
+
+func (r *markdownRenderer) wantsKind(kind string) bool {
+	return len(r.options.onlyKinds) == 0 || r.options.onlyKinds[kind]
+}
+
 func (r *markdownRenderer) renderSymbol(w io.Writer, symbol string) bool {
 	var rendered bool
 	for _, t := range r.pkg.Types {
@@ -111,63 +249,206 @@ func (r *markdownRenderer) renderMethod(w io.Writer, typeName, methodName string
 		}
 		if r.renderFieldDoc(w, t, methodName) {
 			rendered = true
+		} else if r.renderPromotedFieldDoc(w, t, methodName) {
+			rendered = true
 		}
 	}
 	return rendered
 }
 
 func (r *markdownRenderer) renderPackageSummary(w io.Writer) {
-	var entries []string
-	for _, v := range r.pkg.Consts {
-		entries = append(entries, bulletLine(r.valueTitle(v), r.summaryText(v.Doc)))
+	var entries []summaryEntry
+	if r.wantsKind(kindConsts) {
+		for _, v := range r.pkg.Consts {
+			entries = append(entries, summaryEntry{r.valueTitle(v), "const", r.summaryText(v.Doc)})
+		}
 	}
-	if r.pkg.Name != "main" || r.options.includeMainVars || r.options.all {
+	if (r.pkg.Name != "main" || r.options.includeMainVars || r.options.all) && r.wantsKind(kindVars) {
 		for _, v := range r.pkg.Vars {
-			entries = append(entries, bulletLine(r.valueTitle(v), r.summaryText(v.Doc)))
+			entries = append(entries, summaryEntry{r.valueTitle(v), "var", r.summaryText(v.Doc)})
 		}
 	}
-	if r.pkg.Name != "main" || r.options.includeMainFuncs || r.options.all {
+	if (r.pkg.Name != "main" || r.options.includeMainFuncs || r.options.all) && r.wantsKind(kindFuncs) {
 		for _, f := range r.pkg.Funcs {
-			entries = append(entries, bulletLine(r.signature(f.Decl), r.summaryText(f.Doc)))
+			entries = append(entries, summaryEntry{r.signature(f.Decl), "func", r.summaryText(f.Doc)})
 		}
 	}
-	for _, t := range r.pkg.Types {
-		entries = append(entries, bulletLine("type "+t.Name, r.summaryText(t.Doc)))
+	if r.wantsKind(kindTypes) {
+		for _, t := range r.pkg.Types {
+			summary := r.summaryText(t.Doc)
+			if r.options.noteInterfaces {
+				summary = strings.TrimSpace(summary + " " + typeInterfacesNote(t))
+			}
+			entries = append(entries, summaryEntry{"type " + t.Name, "type", summary})
+		}
 	}
 	if len(entries) == 0 {
 		return
 	}
-	sort.Strings(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].bullet() < entries[j].bullet()
+	})
+	if r.options.summaryFormat == summaryTable {
+		renderSummaryTable(w, entries)
+		return
+	}
 	for _, entry := range entries {
-		fmt.Fprintln(w, entry)
+		fmt.Fprintln(w, entry.bullet())
 	}
 	fmt.Fprintln(w)
 }
 
 func (r *markdownRenderer) renderTypesSection(w io.Writer, types []*doc.Type) {
-	for _, t := range types {
+	for _, t := range r.sortTypes(types) {
 		r.renderTypeDoc(w, t)
 	}
 }
 
 func (r *markdownRenderer) renderTypeDoc(w io.Writer, t *doc.Type) {
-	fmt.Fprintf(w, "## type %s\n\n", t.Name)
-	r.writeCodeBlock(w, r.formatNode(t.Decl))
-	if doc := r.docMarkdown(t.Doc); doc != "" {
+	if tmpls := r.activeTemplates(); tmpls != nil && tmpls.typ != nil {
+		r.renderTypeTemplate(w, tmpls.typ, t)
+		return
+	}
+	r.heading(w, 2, "type %s", t.Name)
+	r.writeDeclBlock(w, t.Name, r.formatNode(t.Decl))
+	if note := r.typeKindNote(findTypeSpec(t.Decl, t.Name)); note != "" {
+		fmt.Fprintf(w, "%s\n\n", note)
+	}
+	seeAlso, text := docDirective(t.Doc, seeAlsoDirective)
+	if doc := r.docMarkdown(text); doc != "" {
 		fmt.Fprintln(w, doc)
 		fmt.Fprintln(w)
 	}
+	r.renderBuildNote(w, t.Decl)
+	r.renderSeeAlso(w, seeAlso)
+	r.renderTypeSet(w, findTypeSpec(t.Decl, t.Name))
+	r.renderMethodSet(w, findTypeSpec(t.Decl, t.Name))
+	r.renderImplements(w, t)
+	r.renderExamples(w, 3, t.Name, t.Examples)
+	r.renderTypeMembers(w, t)
+}
+
+// renderTypeMembers writes the constants, variables, functions, and methods
+// grouped under a type.
+func (r *markdownRenderer) renderTypeMembers(w io.Writer, t *doc.Type) {
 	r.renderValuesSection(w, "Constants", t.Consts)
 	r.renderValuesSection(w, "Variables", t.Vars)
-	r.renderFuncsSection(w, "Functions returning "+t.Name, t.Funcs, "")
-	r.renderFuncsSection(w, "Methods", t.Methods, t.Name)
+	constructors, helpers := splitConstructors(t)
+	r.renderFuncsSection(w, "Constructors", constructors, "")
+	r.renderFuncsSection(w, "Helpers", helpers, "")
+	if r.options.methodIndex {
+		r.renderMethodIndex(w, t)
+	}
+	groups := groupMethods(t.Methods)
+	var overflow []*doc.Func
+	if n := r.options.maxMethods; n > 0 && len(t.Methods) > n {
+		groups, overflow = limitMethodGroups(groups, n)
+	}
+	for _, group := range groups {
+		r.renderFuncsSection(w, group.title, group.funcs, t.Name)
+	}
+	r.renderAdditionalMethods(w, overflow)
+}
+
+// splitConstructors separates the functions go/doc associates with t into
+// those whose first result is t (or *t) and everything else.
+func splitConstructors(t *doc.Type) (constructors, helpers []*doc.Func) {
+	for _, f := range t.Funcs {
+		if firstResultIs(f.Decl, t.Name) {
+			constructors = append(constructors, f)
+		} else {
+			helpers = append(helpers, f)
+		}
+	}
+	return constructors, helpers
+}
+
+func firstResultIs(decl *ast.FuncDecl, typeName string) bool {
+	if decl == nil || decl.Type == nil || decl.Type.Results == nil || len(decl.Type.Results.List) == 0 {
+		return false
+	}
+	expr := decl.Type.Results.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == typeName
+}
+
+type methodGroup struct {
+	title string
+	funcs []*doc.Func
+}
+
+// groupMethods buckets methods by their `docgroup:` directive in order of
+// first appearance; methods without a directive land in "Methods".
+func groupMethods(methods []*doc.Func) []methodGroup {
+	var groups []methodGroup
+	index := make(map[string]int)
+	for _, m := range methods {
+		title, _ := docDirective(m.Doc, docGroupDirective)
+		if title == "" {
+			title = "Methods"
+		}
+		i, ok := index[title]
+		if !ok {
+			i = len(groups)
+			index[title] = i
+			groups = append(groups, methodGroup{title: title})
+		}
+		groups[i].funcs = append(groups[i].funcs, m)
+	}
+	return groups
+}
+
+const (
+	docGroupDirective       = "docgroup:"
+	availableSinceDirective = "availableSince:"
+)
+
+// docDirectives lists the convention comment lines that configure rendering
+// and are stripped from prose.
+var docDirectives = []string{docGroupDirective, availableSinceDirective, apiSchemaDirective, stabilityDirective}
+
+// docDirective returns the value of the first line in text starting with
+// name along with text with every such line removed.
+func docDirective(text, name string) (string, string) {
+	if !strings.Contains(text, name) {
+		return "", text
+	}
+	var value string
+	var found bool
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, name) {
+			if !found {
+				value = strings.TrimSpace(strings.TrimPrefix(trimmed, name))
+				found = true
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return value, strings.Join(kept, "\n")
 }
 
 func (r *markdownRenderer) renderValuesSection(w io.Writer, title string, values []*doc.Value) {
 	if len(values) == 0 {
 		return
 	}
-	fmt.Fprintf(w, "### %s\n\n", title)
+	r.heading(w, 3, "%s", title)
+	if r.options.varTable && !r.options.short && isVarGroup(values) {
+		r.renderVarTable(w, values)
+		return
+	}
 	for _, v := range values {
 		r.renderValueDoc(w, v)
 	}
@@ -179,19 +460,35 @@ func (r *markdownRenderer) renderValueDoc(w io.Writer, v *doc.Value) {
 		fmt.Fprintf(w, "%s\n", bulletLine(r.valueTitle(v), r.summaryText(v.Doc)))
 		return
 	}
-	fmt.Fprintf(w, "#### %s\n\n", r.valueTitle(v))
-	r.writeCodeBlock(w, r.formatNode(v.Decl))
+	if tmpls := r.activeTemplates(); tmpls != nil && tmpls.value != nil {
+		r.renderValueTemplate(w, tmpls.value, v)
+		return
+	}
+	r.heading(w, 4, "%s", r.valueTitle(v))
+	if len(v.Names) > 1 {
+		fmt.Fprintf(w, "%s\n\n", r.valueAnchors(v.Names))
+	}
+	enumTable := r.options.enumTable && isIotaBlock(v.Decl)
+	if enumTable {
+		r.renderEnumTable(w, v.Decl)
+	} else {
+		r.writeDeclBlock(w, strings.Join(v.Names, ", "), r.formatNode(v.Decl))
+	}
 	if doc := r.docMarkdown(v.Doc); doc != "" {
 		fmt.Fprintln(w, doc)
 		fmt.Fprintln(w)
 	}
+	if !enumTable {
+		r.renderSpecComments(w, v.Decl)
+	}
+	r.renderBuildNote(w, v.Decl)
 }
 
 func (r *markdownRenderer) renderFuncsSection(w io.Writer, title string, funcs []*doc.Func, receiver string) {
 	if len(funcs) == 0 {
 		return
 	}
-	fmt.Fprintf(w, "### %s\n\n", title)
+	r.heading(w, 3, "%s", title)
 	for _, f := range funcs {
 		r.renderFuncDoc(w, f, receiver)
 	}
@@ -203,20 +500,134 @@ func (r *markdownRenderer) renderFuncDoc(w io.Writer, f *doc.Func, receiver stri
 		fmt.Fprintf(w, "%s\n", bulletLine(r.signature(f.Decl), r.summaryText(f.Doc)))
 		return
 	}
+	if tmpls := r.activeTemplates(); tmpls != nil && tmpls.fn != nil {
+		r.renderFuncTemplate(w, tmpls.fn, f, receiver)
+		return
+	}
 	name := f.Name
 	if receiver != "" {
 		name = receiver + "." + f.Name
 	}
-	fmt.Fprintf(w, "#### %s\n\n", name)
+	r.heading(w, 4, "%s", funcHeading(receiver, f.Decl))
+	r.renderDirectiveBadges(w, f.Decl)
 	if r.options.showSource {
-		r.writeCodeBlock(w, r.formatNode(f.Decl))
+		r.writeCodeBlock(w, r.declSource(f.Decl))
 	} else {
-		fmt.Fprintf(w, "```go\n%s\n```\n\n", r.signature(f.Decl))
+		r.writeDeclBlock(w, name, r.signature(f.Decl))
+	}
+	r.renderSignatureLinks(w, f.Decl)
+	seeAlso, text := docDirective(f.Doc, seeAlsoDirective)
+	if r.options.paramDocs {
+		var params, results []paramDoc
+		params, results, text = splitParamDocs(f.Decl, text)
+		r.renderParamTable(w, "Parameter", params)
+		r.renderParamTable(w, "Result", results)
 	}
-	if doc := r.docMarkdown(f.Doc); doc != "" {
+	if doc := r.docMarkdown(text); doc != "" {
 		fmt.Fprintln(w, doc)
 		fmt.Fprintln(w)
 	}
+	if note := r.assemblyNoteFor(f.Decl); note != "" {
+		fmt.Fprintf(w, "%s\n\n", note)
+	}
+	if r.options.noteInterfaces && receiver != "" {
+		if note := interfaceNote(f.Decl); note != "" {
+			fmt.Fprintf(w, "%s\n\n", note)
+		}
+	}
+	r.renderBuildNote(w, f.Decl)
+	r.renderSeeAlso(w, seeAlso)
+	r.renderCallers(w, f)
+	r.renderExamples(w, 5, name, f.Examples)
+}
+
+// funcHeading is the heading text of a function or, when receiver is set, of
+// a method, which shows whether it has a pointer or value receiver:
+// "(\*T) Name" or "(T) Name". The star is escaped so it is not read as
+// emphasis. Methods of generic types keep the receiver's type parameters,
+// "(\*List[T]) Append". A constructor rendered on its own keeps the
+// "T.Name" form.
+func funcHeading(receiver string, decl *ast.FuncDecl) string {
+	switch {
+	case receiver == "":
+		return decl.Name.Name
+	case decl.Recv == nil || len(decl.Recv.List) == 0:
+		return receiver + "." + decl.Name.Name
+	}
+	typ := decl.Recv.List[0].Type
+	star, pointer := typ.(*ast.StarExpr)
+	if pointer {
+		typ = star.X
+	}
+	receiver += receiverTypeParams(typ)
+	if pointer {
+		return "(\\*" + receiver + ") " + decl.Name.Name
+	}
+	return "(" + receiver + ") " + decl.Name.Name
+}
+
+// receiverTypeParams returns the type parameter list of a generic method's
+// receiver type, "[T]" or "[K, V]", or "" for other receivers.
+func receiverTypeParams(typ ast.Expr) string {
+	var params []ast.Expr
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		params = []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		params = t.Indices
+	default:
+		return ""
+	}
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		if id, ok := p.(*ast.Ident); ok {
+			names = append(names, id.Name)
+		}
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func (r *markdownRenderer) renderExamples(w io.Writer, level int, owner string, examples []*doc.Example) {
+	if r.options.short || len(examples) == 0 {
+		return
+	}
+	if r.examples != nil {
+		w = r.examples
+		r.heading(w, 2, "%s", owner)
+		level = 3
+	}
+	for _, ex := range examples {
+		title := "Example"
+		if ex.Suffix != "" {
+			title += " (" + ex.Suffix + ")"
+		}
+		r.heading(w, level, "%s", title)
+		if doc := r.docMarkdown(ex.Doc); doc != "" {
+			fmt.Fprintln(w, doc)
+			fmt.Fprintln(w)
+		}
+		r.writeCodeBlock(w, r.exampleCode(ex))
+		switch {
+		case ex.EmptyOutput:
+			fmt.Fprint(w, "Output: _(none)_\n\n")
+		case ex.Output != "":
+			label := "Output:"
+			if ex.Unordered {
+				label = "Unordered Output:"
+			}
+			fmt.Fprintf(w, "%s\n\n```text\n%s\n```\n\n", label, strings.TrimRight(ex.Output, "\n"))
+		}
+		r.renderPlaygroundLink(w, ex)
+	}
+}
+
+func (r *markdownRenderer) exampleCode(ex *doc.Example) string {
+	code := r.formatNode(ex.Code)
+	if _, ok := ex.Code.(*ast.BlockStmt); ok {
+		code = strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}")
+		code = dedentMarkdown(strings.Trim(code, "\n"))
+	}
+	return code
 }
 
 func (r *markdownRenderer) renderFieldDoc(w io.Writer, t *doc.Type, fieldName string) bool {
@@ -234,17 +645,25 @@ func (r *markdownRenderer) renderFieldDoc(w io.Writer, t *doc.Type, fieldName st
 		if field.Doc != nil {
 			docText = field.Doc.Text()
 		}
+		defaultValue, docText := r.fieldDefault(field, docText)
 		for _, name := range field.Names {
 			if r.matchName(name.Name, fieldName) {
 				if r.options.short {
-					fmt.Fprintf(w, "%s\n", bulletLine(fmt.Sprintf("%s.%s", t.Name, name.Name), r.summaryText(docText)))
+					summary := r.summaryText(docText)
+					if defaultValue != "" {
+						summary = strings.TrimSpace(summary + " " + defaultNote(defaultValue))
+					}
+					fmt.Fprintf(w, "%s\n", bulletLine(fmt.Sprintf("%s.%s", t.Name, name.Name), summary))
 				} else {
-					fmt.Fprintf(w, "#### %s.%s\n\n", t.Name, name.Name)
-					r.writeCodeBlock(w, r.formatField(field))
+					r.heading(w, 4, "%s.%s", t.Name, name.Name)
+					r.writeDeclBlock(w, t.Name+"."+name.Name, r.formatField(field))
 					if doc := r.docMarkdown(docText); doc != "" {
 						fmt.Fprintln(w, doc)
 						fmt.Fprintln(w)
 					}
+					if defaultValue != "" {
+						fmt.Fprintf(w, "%s\n\n", defaultNote(defaultValue))
+					}
 				}
 				rendered = true
 			}
@@ -253,6 +672,31 @@ func (r *markdownRenderer) renderFieldDoc(w io.Writer, t *doc.Type, fieldName st
 	return rendered
 }
 
+// typeKindNote describes named function, map, slice, array, and channel types
+// so their docs don't read as if they were structs.
+func (r *markdownRenderer) typeKindNote(spec *ast.TypeSpec) string {
+	if spec == nil || spec.Assign.IsValid() {
+		return ""
+	}
+	var kind string
+	switch typ := spec.Type.(type) {
+	case *ast.FuncType:
+		return fmt.Sprintf("**Signature:** `%s`", r.formatNode(typ))
+	case *ast.MapType:
+		kind = "map"
+	case *ast.ArrayType:
+		kind = "slice"
+		if typ.Len != nil {
+			kind = "array"
+		}
+	case *ast.ChanType:
+		kind = "channel"
+	default:
+		return ""
+	}
+	return fmt.Sprintf("**Underlying type:** %s — `%s`", kind, r.formatNode(spec.Type))
+}
+
 func findTypeSpec(decl *ast.GenDecl, name string) *ast.TypeSpec {
 	if decl == nil {
 		return nil
@@ -269,6 +713,107 @@ func findTypeSpec(decl *ast.GenDecl, name string) *ast.TypeSpec {
 	return nil
 }
 
+func (r *markdownRenderer) heading(w io.Writer, level int, format string, args ...any) {
+	io.WriteString(w, r.headingLine(w, level, fmt.Sprintf(format, args...)))
+}
+
+// headingText returns text as a heading at level shifted by -heading-offset.
+// With -max-depth-headings, levels past ###### become bold lines instead of
+// being clamped, so deep nesting stays distinguishable.
+func headingText(level int, opts options, text string) string {
+	if opts.maxDepthHeadings && level+opts.headingOffset > 6 {
+		return fmt.Sprintf("**%s**\n\n", text)
+	}
+	return fmt.Sprintf("%s %s\n\n", headingMarker(level, opts.headingOffset), text)
+}
+
+// dropEmptySections removes headings that have no content before the next
+// heading of the same or a shallower level, or the end of the document.
+// Fenced code blocks are skipped so their contents are never mistaken for
+// headings. Removal repeats until nothing changes, so a section whose
+// subsections were all empty goes too.
+func dropEmptySections(md []byte) []byte {
+	lines := strings.Split(string(md), "\n")
+	for {
+		levels := make([]int, len(lines))
+		inFence := false
+		for i, line := range lines {
+			if strings.HasPrefix(line, "```") {
+				inFence = !inFence
+				continue
+			}
+			if !inFence {
+				levels[i] = headingLevel(line)
+			}
+		}
+		var kept []string
+		removed := false
+		for i, line := range lines {
+			if levels[i] > 0 && sectionIsEmpty(lines, levels, i) {
+				removed = true
+				continue
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+		if !removed {
+			break
+		}
+	}
+	return []byte(strings.Join(collapseBlankLines(lines), "\n"))
+}
+
+// collapseBlankLines drops the blank lines that follow another blank line,
+// leaving fenced code blocks as they are. The last line is what follows the
+// final newline and is always kept.
+func collapseBlankLines(lines []string) []string {
+	var kept []string
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence && line == "" && i < len(lines)-1 && len(kept) > 0 && kept[len(kept)-1] == "" {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+func sectionIsEmpty(lines []string, levels []int, at int) bool {
+	for j := at + 1; j < len(lines); j++ {
+		if levels[j] > 0 {
+			return levels[j] <= levels[at]
+		}
+		if strings.TrimSpace(lines[j]) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || !strings.HasPrefix(line[level:], " ") {
+		return 0
+	}
+	return level
+}
+
+// headingMarker returns the Markdown heading prefix for level shifted by
+// offset, clamped to the range Markdown supports.
+func headingMarker(level, offset int) string {
+	level += offset
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	return strings.Repeat("#", level)
+}
+
 func (r *markdownRenderer) writeCodeBlock(w io.Writer, code string) {
 	if code == "" {
 		return
@@ -276,6 +821,16 @@ func (r *markdownRenderer) writeCodeBlock(w io.Writer, code string) {
 	fmt.Fprintf(w, "```go\n%s\n```\n\n", strings.TrimSpace(code))
 }
 
+// writeDeclBlock writes a declaration or signature code block, which
+// -no-signatures leaves out.
+func (r *markdownRenderer) writeDeclBlock(w io.Writer, symbol, code string) {
+	if r.options.noSignatures {
+		return
+	}
+	r.checkCodeLines(symbol, code)
+	r.writeCodeBlock(w, code)
+}
+
 func (r *markdownRenderer) formatNode(node ast.Node) string {
 	if node == nil {
 		return ""
@@ -288,11 +843,20 @@ func (r *markdownRenderer) formatNode(node ast.Node) string {
 }
 
 func (r *markdownRenderer) formatField(field *ast.Field) string {
-	var buf bytes.Buffer
-	if err := format.Node(&buf, r.fileset, field); err != nil {
-		return ""
+	// format.Node rejects a bare *ast.Field, so its parts are joined here.
+	var parts []string
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+		parts = append(parts, strings.Join(names, ", "))
 	}
-	return strings.TrimSpace(buf.String())
+	parts = append(parts, r.formatNode(field.Type))
+	if field.Tag != nil {
+		parts = append(parts, field.Tag.Value)
+	}
+	return strings.Join(parts, " ")
 }
 
 func (r *markdownRenderer) signature(decl *ast.FuncDecl) string {
@@ -301,11 +865,16 @@ func (r *markdownRenderer) signature(decl *ast.FuncDecl) string {
 	}
 	var buf bytes.Buffer
 	buf.WriteString("func ")
-	if decl.Recv != nil {
-		var recv bytes.Buffer
-		_ = format.Node(&recv, r.fileset, decl.Recv)
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		// format.Node prints nothing for a bare *ast.FieldList, so the
+		// receiver's name and type are formatted separately.
+		field := decl.Recv.List[0]
 		buf.WriteString("(")
-		buf.WriteString(strings.TrimSpace(recv.String()))
+		if len(field.Names) > 0 {
+			buf.WriteString(field.Names[0].Name)
+			buf.WriteString(" ")
+		}
+		buf.WriteString(r.formatNode(field.Type))
 		buf.WriteString(") ")
 	}
 	buf.WriteString(decl.Name.Name)
@@ -321,12 +890,235 @@ func (r *markdownRenderer) valueTitle(v *doc.Value) string {
 	return strings.Join(v.Names, ", ")
 }
 
+// valueAnchors returns an HTML anchor per name so each member of a grouped
+// const or var block can be linked even though the block shares one heading.
+func (r *markdownRenderer) valueAnchors(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, `<a id="%s"></a>`, slugify(r.options.anchorFormat, name))
+	}
+	return b.String()
+}
+
 func (r *markdownRenderer) docMarkdown(text string) string {
+	if r.wantsRawDoc() {
+		return rawDocMarkdown(text)
+	}
+	md := r.docText(text)
+	if md == "" {
+		return ""
+	}
+	md = r.renderAdmonitions(wrapProse(r.fenceCodeBlocks(md), r.options.wrap))
+	if blocks := r.embeddedFileBlocks(text); blocks != "" {
+		md += "\n\n" + blocks
+	}
+	return md
+}
+
+func (r *markdownRenderer) docText(text string) string {
+	for _, name := range docDirectives {
+		_, text = docDirective(text, name)
+	}
 	trimmed := strings.TrimSpace(text)
 	if trimmed == "" {
 		return ""
 	}
-	return dedentMarkdown(trimmed)
+	return r.linkExternalDocLinks(escapeStrayAsterisks(separateBlocks(dedentMarkdown(trimmed))))
+}
+
+// separateBlocks makes the block structure go/doc sees explicit in Markdown.
+// Runs of blank lines, such as those left by stripped directives, collapse to
+// one, and indented blocks get a blank line on each side so a neighboring
+// unindented line is not folded into them as a paragraph continuation. Lines
+// ending in a backslash are left alone and render as hard line breaks.
+func separateBlocks(md string) string {
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1] != "" {
+			prevIndented := leadingWhitespace(out[len(out)-1]) > 0
+			indented := leadingWhitespace(line) > 0
+			if (indented && !prevIndented && !isListLine(line)) || (!indented && prevIndented) {
+				out = append(out, "")
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// isListLine reports whether line starts a bullet or numbered list item,
+// which Markdown lets interrupt a paragraph.
+func isListLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(trimmed, marker) {
+			return true
+		}
+	}
+	digits := len(trimmed) - len(strings.TrimLeft(trimmed, "0123456789"))
+	rest := trimmed[digits:]
+	return digits > 0 && (strings.HasPrefix(rest, ". ") || strings.HasPrefix(rest, ") "))
+}
+
+// fenceCodeBlocks rewrites tab-indented doc comment code blocks as fenced
+// blocks so the language can be declared. The language is inferred from the
+// block contents unless -fence-lang overrides it.
+func (r *markdownRenderer) fenceCodeBlocks(md string) string {
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if end := tableEnd(lines, i); end > i {
+			// Pipe tables pass through verbatim, separated from surrounding
+			// prose so Markdown renders them as tables.
+			if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+				out = append(out, "")
+			}
+			for _, l := range lines[i:end] {
+				out = append(out, strings.TrimSpace(l))
+			}
+			if end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+				out = append(out, "")
+			}
+			i = end
+			continue
+		}
+		startsBlock := strings.HasPrefix(line, "\t") && (i == 0 || strings.TrimSpace(lines[i-1]) == "")
+		if !startsBlock {
+			out = append(out, line)
+			i++
+			continue
+		}
+		end := i
+		for j := i; j < len(lines); j++ {
+			if strings.HasPrefix(lines[j], "\t") {
+				end = j + 1
+				continue
+			}
+			if strings.TrimSpace(lines[j]) != "" {
+				break
+			}
+		}
+		code := make([]string, 0, end-i)
+		for _, l := range lines[i:end] {
+			code = append(code, strings.TrimPrefix(l, "\t"))
+		}
+		body := strings.Join(code, "\n")
+		out = append(out, "```"+r.fenceLang(body))
+		out = append(out, code...)
+		out = append(out, "```")
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// tableEnd returns the index just past a pipe table starting at lines[start],
+// or start when no table begins there. A table is a header row followed by a
+// delimiter row, then any number of rows that start with a pipe.
+func tableEnd(lines []string, start int) int {
+	if start+1 >= len(lines) {
+		return start
+	}
+	header := strings.TrimSpace(lines[start])
+	if !strings.HasPrefix(header, "|") || !isTableDelimiter(strings.TrimSpace(lines[start+1])) {
+		return start
+	}
+	end := start + 2
+	for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "|") {
+		end++
+	}
+	return end
+}
+
+func isTableDelimiter(line string) bool {
+	line = strings.Trim(line, "|")
+	if line == "" {
+		return false
+	}
+	for _, cell := range strings.Split(line, "|") {
+		cell = strings.TrimSpace(cell)
+		cell = strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *markdownRenderer) fenceLang(code string) string {
+	switch lang := strings.TrimSpace(r.options.fenceLang); lang {
+	case "":
+		return detectFenceLang(code)
+	case "none":
+		return ""
+	default:
+		return lang
+	}
+}
+
+func detectFenceLang(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return ""
+	}
+	first := strings.TrimSpace(strings.SplitN(trimmed, "\n", 2)[0])
+	switch {
+	case hasAnyPrefix(first, "syntax = ", "message ", "service ", "option go_package"):
+		return "protobuf"
+	case hasAnyPrefix(first, "SELECT ", "INSERT ", "UPDATE ", "DELETE ", "CREATE ", "ALTER ", "DROP ", "WITH "):
+		return "sql"
+	case strings.HasPrefix(first, "{") || strings.HasPrefix(first, "["):
+		return "json"
+	case hasAnyPrefix(first, "$ ", "go ", "git ", "make ", "curl ", "export ", "docker ", "#!") || hasEnvAssignment(first):
+		return "sh"
+	case parsesAsGo(trimmed):
+		return "go"
+	default:
+		return ""
+	}
+}
+
+// hasEnvAssignment reports whether line starts with a shell environment
+// assignment such as GODOCMD_FLAGS=-all.
+func hasEnvAssignment(line string) bool {
+	name, _, ok := strings.Cut(line, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !('A' <= r && r <= 'Z') && (i == 0 || !('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsesAsGo reports whether code is Go source: a file, top-level
+// declarations, or statements.
+func parsesAsGo(code string) bool {
+	fset := token.NewFileSet()
+	for _, src := range []string{code, "package p\n" + code, "package p\nfunc _() {\n" + code + "\n}"} {
+		if _, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
 }
 
 func dedentMarkdown(src string) string {
@@ -365,15 +1157,11 @@ func leadingWhitespace(line string) int {
 }
 
 func (r *markdownRenderer) summaryText(text string) string {
-	md := r.docMarkdown(text)
+	md := r.docText(text)
 	if md == "" {
 		return ""
 	}
-	md = strings.ReplaceAll(md, "\n", " ")
-	if idx := strings.Index(md, ". "); idx >= 0 {
-		return strings.TrimSpace(md[:idx+1])
-	}
-	return strings.TrimSpace(md)
+	return firstSentence(strings.ReplaceAll(md, "\n", " "), r.options.sentenceTerminators)
 }
 
 func (r *markdownRenderer) packageSummary() string {
@@ -381,10 +1169,56 @@ func (r *markdownRenderer) packageSummary() string {
 }
 
 func (r *markdownRenderer) matchName(name, target string) bool {
-	if r.options.caseSensitive {
-		return name == target
+	return matchSymbolName(name, target, r.options.caseSensitive)
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+type symbolMatch struct {
+	name string
+	doc  string
+}
+
+// matchingSymbols lists the package-level symbols (and type-associated
+// constants, variables, and functions) whose names match pattern.
+func (r *markdownRenderer) matchingSymbols(pattern string) []symbolMatch {
+	var matches []symbolMatch
+	seen := make(map[string]bool)
+	add := func(name, text string) {
+		if seen[name] || !r.matchName(name, pattern) {
+			return
+		}
+		seen[name] = true
+		matches = append(matches, symbolMatch{name: name, doc: text})
+	}
+	addValues := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, name := range v.Names {
+				add(name, v.Doc)
+			}
+		}
+	}
+	for _, t := range r.pkg.Types {
+		add(t.Name, t.Doc)
+	}
+	for _, f := range r.pkg.Funcs {
+		add(f.Name, f.Doc)
+	}
+	addValues(r.pkg.Consts)
+	addValues(r.pkg.Vars)
+	for _, t := range r.pkg.Types {
+		addValues(t.Consts)
+		addValues(t.Vars)
+		for _, f := range t.Funcs {
+			add(f.Name, f.Doc)
+		}
 	}
-	return strings.EqualFold(name, target)
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].name < matches[j].name
+	})
+	return matches
 }
 
 func (r *markdownRenderer) valueHasName(v *doc.Value, name string) bool {
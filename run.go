@@ -5,30 +5,151 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go/ast"
 	"go/doc"
+	"go/parser"
+	"go/token"
 	"io"
+	"io/fs"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
+	"golang.org/x/text/encoding"
 	"golang.org/x/tools/go/packages"
 )
 
 type options struct {
-	all              bool
-	caseSensitive    bool
-	showCmd          bool
-	short            bool
-	showSource       bool
-	unexported       bool
-	outputPath       string
-	inplace          bool
-	includeMainVars  bool
-	includeMainFuncs bool
+	all                     bool
+	caseSensitive           bool
+	showCmd                 bool
+	short                   bool
+	showSource              bool
+	unexported              bool
+	outputPath              string
+	inplace                 bool
+	includeMainVars         bool
+	includeMainFuncs        bool
+	headingOffset           int
+	lintUndocumented        bool
+	moduleRoot              bool
+	timing                  bool
+	fenceLang               string
+	allMatches              bool
+	maxGoVersion            string
+	anchorFormat            string
+	enumTable               bool
+	indexName               string
+	outExt                  string
+	excludeUnexportedFields bool
+	only                    string
+	onlyKinds               map[string]bool
+	apiSchema               bool
+	strictLinks             bool
+	examplesFile            bool
+	stabilityStyle          string
+	noRecurse               bool
+	header                  string
+	headerText              string
+	implements              bool
+	implementsIndex         *implementsIndex
+	paramDocs               bool
+	noImportLine            bool
+	noteInterfaces          bool
+	diffBase                string
+	changedFiles            map[string]bool
+	goos                    string
+	noEmptySections         bool
+	externalDocLinks        bool
+	stdlibVersion           string
+	color                   string
+	maxDepthHeadings        bool
+	dryRun                  bool
+	varTable                bool
+	listPackages            bool
+	rawDoc                  string
+	index                   bool
+	templateDir             string
+	templates               *renderTemplates
+	navJSON                 bool
+	markGenerated           bool
+	force                   bool
+	noColor                 bool
+	methodIndex             bool
+	relativeLinks           string
+	baseURL                 string
+	wrap                    int
+	deps                    bool
+	addedSince              string
+	addedSinceBase          *addedSinceBase
+	pkgHeadingFormat        string
+	summaryOnly             bool
+	benchmarks              bool
+	failOnWarning           bool
+	admonitions             string
+	admonitionKinds         map[string]string
+	glossary                bool
+	stripCopyright          bool
+	format                  string
+	embedExamples           bool
+	timeout                 time.Duration
+	buildNotes              bool
+	sortImportsGrouped      bool
+	outputEncoding          string
+	outputEncoder           encoding.Encoding
+	onUnmappable            string
+	embedSource             bool
+	alias                   bool
+	flattenSinglePackage    bool
+	defaultPrefix           string
+	ignoreFiles             *ignoreFiles
+	platformMatrix          bool
+	excludeSymbols          []string
+	goVersionNote           bool
+	playgroundLinks         bool
+	noNetwork               bool
+	playground              *playgroundSharer
+	tocSummaryLen           int
+	flattenInterfaces       bool
+	noTOC                   bool
+	refLinks                bool
+	callers                 bool
+	maxMethods              int
+	coverageBadges          bool
+	useExportData           bool
+	noSignatures            bool
+	signatureLinks          bool
+	sentenceTerminators     string
+	validateLinks           bool
+	includeIgnored          bool
+	showProgress            bool
+	sortTypes               string
+	skipEmpty               bool
+	cliFlags                bool
+	excludeDeprecated       bool
+	showDeprecatedList      bool
+	hoistExample            bool
+	groupPackagesByDir      bool
+	maxCodeLine             int
+	allowNetwork            bool
+	compact                 bool
+	readmeName              string
+	indexNameSet            bool
+	outExtSet               bool
+	colorSet                bool
+	errorFormat             string
+	summaryFormat           string
+	numberHeadings          bool
+	progress                *treeProgress
+	warnings                *warningLog
 }
 
 type invocation struct {
@@ -40,27 +161,215 @@ type invocation struct {
 type docResult struct {
 	Markdown []byte
 	Summary  string
+	Omitted  []string
+	Schemas  []typeSchema
+	Broken   []string
+	Examples []byte
+	Imports  []string
+	Coverage string
+	Empty    bool
 }
 
 type cliApp struct {
 	stdout io.Writer
+	stderr io.Writer
 	opts   options
 }
 
 func run(argv []string, stdout io.Writer) error {
-	cmd := newRootCmd(stdout)
-	cmd.SetArgs(normalizeLegacyArgs(argv))
-	return cmd.Execute()
+	cmd := newRootCmd(stdout, os.Stderr)
+	args, err := withEnvFlags(cmd, argv)
+	if err != nil {
+		return err
+	}
+	cmd.SetArgs(normalizeLegacyArgs(args))
+	return reportError(cmd, os.Stderr, cmd.Execute())
 }
 
-func (app *cliApp) execute(ctx context.Context, positionals []string) error {
+func (app *cliApp) execute(ctx context.Context, positionals []string) (err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = withLoadCache(ctx)
 	opts := app.opts
+	opts.warnings = &warningLog{}
+	opts.ignoreFiles = &ignoreFiles{}
+	defer func() {
+		if werr := opts.warnings.flush(app.stderr, opts.failOnWarning); err == nil {
+			err = werr
+		}
+	}()
+	if !validErrorFormats[opts.errorFormat] {
+		return fmt.Errorf("invalid -error-format %q (want text or json)", opts.errorFormat)
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("invalid -timeout %s (want a positive duration, or 0 for none)", opts.timeout)
+	}
+	if opts.timeout > 0 {
+		ctx = withLoadTimeout(ctx, opts.timeout)
+	}
+	if opts.allowNetwork {
+		if opts.noNetwork {
+			return errors.New("-allow-network cannot be combined with -no-network")
+		}
+		ctx = withRemoteFetch(ctx)
+	}
+	if opts.playgroundLinks && !opts.noNetwork {
+		opts.playground = newPlaygroundSharer(ctx)
+	}
 	if opts.inplace && opts.outputPath != "" {
 		return errors.New("-o cannot be combined with -inplace")
 	}
+	if isPipeOutput(opts.outputPath) {
+		if _, err := pipeCommand(opts.outputPath); err != nil {
+			return err
+		}
+	}
+	if opts.summaryOnly && !opts.inplace && (!wantsDirectoryOutput(opts.outputPath) || hasOutputPlaceholder(opts.outputPath)) {
+		return errors.New("-summary-only requires -inplace or -o pointing to a directory")
+	}
+	if opts.summaryOnly && opts.noTOC {
+		return errors.New("-no-toc cannot be combined with -summary-only")
+	}
+	for _, pattern := range opts.excludeSymbols {
+		if err := checkSymbolPattern(pattern); err != nil {
+			return fmt.Errorf("-exclude-symbol: %w", err)
+		}
+	}
+	kinds, err := parseOnlyKinds(opts.only)
+	if err != nil {
+		return err
+	}
+	opts.onlyKinds = kinds
+	if opts.admonitionKinds, err = parseAdmonitions(opts.admonitions); err != nil {
+		return err
+	}
+	if opts.maxGoVersion != "" && normalizeGoVersion(opts.maxGoVersion) == "" {
+		return fmt.Errorf("invalid -max-go-version %q", opts.maxGoVersion)
+	}
+	if v := opts.stdlibVersion; v != "" && v != stdlibLatest && v != "module" && normalizeGoVersion(v) == "" {
+		return fmt.Errorf("invalid -stdlib-version %q (want latest, module, or a Go version)", v)
+	}
+	switch opts.anchorFormat {
+	case "", anchorGitHub, anchorGitLab, anchorBitbucket:
+	default:
+		return fmt.Errorf("invalid -anchor-format %q (want github, gitlab, or bitbucket)", opts.anchorFormat)
+	}
+	switch opts.color {
+	case "", colorNever, colorAuto, colorAlways:
+	default:
+		return fmt.Errorf("invalid -color %q (want auto, always, or never)", opts.color)
+	}
+	if opts.colorSet {
+		// A bare -color takes no value, so "-color always" leaves the mode
+		// behind as a package argument.
+		for _, arg := range positionals {
+			switch arg {
+			case colorNever, colorAuto, colorAlways:
+				return fmt.Errorf("%q after -color is read as a package argument; write -color=%s", arg, arg)
+			}
+		}
+	}
+	if opts.readmeName != "" {
+		if err := applyReadmeName(&opts); err != nil {
+			return err
+		}
+	}
+	switch opts.format {
+	case "", formatMarkdown:
+	case formatConfluence:
+		// Confluence pages default to .xml unless an extension was chosen.
+		if !opts.outExtSet && filepath.Ext(opts.readmeName) == "" {
+			opts.outExt = "xml"
+		}
+	default:
+		return fmt.Errorf("invalid -format %q (want markdown or confluence)", opts.format)
+	}
+	if opts.embedSource && opts.format == formatConfluence {
+		return errors.New("-embed-source requires -format=markdown")
+	}
+	if opts.noSignatures && opts.showSource {
+		return errors.New("-no-signatures cannot be combined with -src")
+	}
+	if opts.refLinks && opts.format == formatConfluence {
+		return errors.New("-ref-links requires -format=markdown")
+	}
+	if opts.outputEncoder, err = lookupOutputEncoding(opts.outputEncoding); err != nil {
+		return err
+	}
+	switch opts.onUnmappable {
+	case "", unmappableError, unmappableReplace:
+	default:
+		return fmt.Errorf("invalid -on-unmappable %q (want error or replace)", opts.onUnmappable)
+	}
+	if opts.wrap < 0 {
+		return fmt.Errorf("invalid -wrap %d (want a column count, or 0 to disable)", opts.wrap)
+	}
+	if opts.maxMethods < 0 {
+		return fmt.Errorf("invalid -max-methods %d (want a count, or 0 for no limit)", opts.maxMethods)
+	}
+	if opts.maxCodeLine < 0 {
+		return fmt.Errorf("invalid -max-code-line %d (want a column count, or 0 for no limit)", opts.maxCodeLine)
+	}
+	if opts.headingOffset < 0 {
+		return fmt.Errorf("invalid -heading-offset %d (want a level count, or 0 for none)", opts.headingOffset)
+	}
+	if opts.tocSummaryLen < 0 {
+		return fmt.Errorf("invalid -toc-summary-len %d (want a length, or 0 to disable)", opts.tocSummaryLen)
+	}
+	switch opts.relativeLinks {
+	case "", linksRelative:
+	case linksAbsolute:
+		if opts.baseURL == "" {
+			return errors.New("-relative-links=absolute requires -base-url")
+		}
+	default:
+		return fmt.Errorf("invalid -relative-links %q (want relative or absolute)", opts.relativeLinks)
+	}
+	if opts.showDeprecatedList && !opts.excludeDeprecated {
+		return errors.New("-show-deprecated-list requires -exclude-deprecated")
+	}
+	if opts.summaryFormat != summaryBullets && opts.summaryFormat != summaryTable {
+		return fmt.Errorf("invalid -summary-format %q (want bullets or table)", opts.summaryFormat)
+	}
+	if !validSortTypes(opts.sortTypes) {
+		return fmt.Errorf("invalid -sort-types %q (want name, source, or methods)", opts.sortTypes)
+	}
+	if opts.stabilityStyle != "" && !validStabilityStyle(opts.stabilityStyle) {
+		return fmt.Errorf("invalid -stability-style %q (want text, badge, or none)", opts.stabilityStyle)
+	}
+	if opts.headerText, err = loadHeader(opts.header); err != nil {
+		return err
+	}
+	if opts.templates, err = loadTemplateDir(opts.templateDir); err != nil {
+		return err
+	}
+	if err := checkPlaceholders("-pkg-heading-format", opts.pkgHeadingFormat, "{name}", "{import}", "{module}"); err != nil {
+		return err
+	}
+	if opts.addedSince != "" {
+		if opts.addedSinceBase, err = newAddedSinceBase(ctx, opts.addedSince); err != nil {
+			return err
+		}
+	}
+	if opts.diffBase != "" {
+		if opts.changedFiles, err = changedGoFiles(ctx, opts.diffBase); err != nil {
+			return err
+		}
+	}
+	ctx = withLoadMode(ctx, loadModeFor(opts, requestsSymbol(positionals)))
+	if opts.lintUndocumented {
+		return lintPackages(ctx, positionals, opts)
+	}
+	if opts.listPackages {
+		return listPackages(ctx, positionals, opts, app.stdout)
+	}
+	if opts.goos != "" {
+		return app.renderGOOSVariants(ctx, positionals, opts)
+	}
+	if opts.platformMatrix {
+		return errors.New("-platform-matrix requires -goos")
+	}
 	if opts.inplace {
 		if len(positionals) > 1 {
 			return errors.New("in-place mode accepts at most one package argument")
@@ -69,9 +378,28 @@ func (app *cliApp) execute(ctx context.Context, positionals []string) error {
 		if len(positionals) == 1 {
 			root = positionals[0]
 		}
-		return documentPackageTree(ctx, root, opts)
+		return documentPackageTree(ctx, root, opts, app.docWriter(opts), app.stderr)
+	}
+	if hasOutputPlaceholder(opts.outputPath) {
+		if err := validateOutputPattern(opts.outputPath); err != nil {
+			return err
+		}
+		if opts.examplesFile {
+			return errors.New("-examples-file cannot be combined with an -o pattern")
+		}
+		if len(positionals) > 1 {
+			return errors.New("-o patterns accept at most one package argument")
+		}
+		root := "."
+		if len(positionals) == 1 {
+			root = positionals[0]
+		}
+		return documentPackageTree(ctx, root, opts, app.docWriter(opts), app.stderr)
 	}
 	if wantsDirectoryOutput(opts.outputPath) {
+		if len(positionals) == 2 && hasGlobMeta(positionals[1]) {
+			return documentSymbolSet(ctx, positionals[0], positionals[1], opts, app.docWriter(opts))
+		}
 		if len(positionals) > 1 {
 			return errors.New("directory output accepts at most one package argument")
 		}
@@ -79,7 +407,41 @@ func (app *cliApp) execute(ctx context.Context, positionals []string) error {
 		if len(positionals) == 1 {
 			root = positionals[0]
 		}
-		return documentPackageTree(ctx, root, opts)
+		return documentPackageTree(ctx, root, opts, app.docWriter(opts), app.stderr)
+	}
+	if isArchiveOutput(opts.outputPath) {
+		if len(positionals) == 2 && hasGlobMeta(positionals[1]) {
+			return writeSymbolSetToArchive(ctx, positionals[0], positionals[1], opts, app.docWriter(opts))
+		}
+		if len(positionals) > 1 {
+			return errors.New("archive output takes a package tree or a package and a symbol glob; write a single symbol to a .md file or stdout instead")
+		}
+		root := "."
+		if len(positionals) == 1 {
+			root = positionals[0]
+		}
+		return documentPackageTree(ctx, root, opts, app.docWriter(opts), app.stderr)
+	}
+	if wantsCombinedOutput(opts.outputPath, positionals) {
+		if opts.examplesFile {
+			return errors.New("-examples-file requires directory or in-place output")
+		}
+		return documentPackageTree(ctx, positionals[0], opts, app.docWriter(opts), app.stderr)
+	}
+	if opts.apiSchema {
+		return errors.New("-apischema requires directory, combined, or in-place output")
+	}
+	if opts.embedSource {
+		return errors.New("-embed-source requires directory, combined, or in-place output")
+	}
+	if opts.validateLinks {
+		return errors.New("-validate-links requires directory, combined, or in-place output")
+	}
+	if opts.skipEmpty {
+		return errors.New("-skip-empty requires directory, combined, or in-place output")
+	}
+	if opts.examplesFile {
+		return errors.New("-examples-file requires directory or in-place output")
 	}
 	if opts.all && len(positionals) > 1 {
 		return errors.New("-all can only be used with a single package argument")
@@ -91,12 +453,31 @@ func (app *cliApp) execute(ctx context.Context, positionals []string) error {
 	if len(candidates) == 0 {
 		return errors.New("no arguments provided")
 	}
+	if opts.allMatches {
+		return app.renderAllMatches(ctx, candidates, opts)
+	}
 
-	var lastErr error
+	var (
+		lastErr  error
+		resolved []string
+	)
 	for _, cand := range candidates {
+		if extendsResolved(cand.pkgExpr, resolved) {
+			// The package already resolved at a shorter split, so the rest
+			// of the argument is a symbol, not more of the path.
+			continue
+		}
 		pkgInfo, err := resolvePackage(ctx, cand.pkgExpr)
+		if isLoadTimeout(err) {
+			return err
+		}
 		if err != nil {
-			lastErr = err
+			// Why a remote path failed outranks the failures of the
+			// fallback candidates tried after it.
+			var remote *remoteError
+			if !errors.As(lastErr, &remote) {
+				lastErr = err
+			}
 			continue
 		}
 		result, handled, err := documentTarget(pkgInfo, cand.symbol, cand.method, opts)
@@ -104,10 +485,15 @@ func (app *cliApp) execute(ctx context.Context, positionals []string) error {
 			return err
 		}
 		if !handled {
-			lastErr = fmt.Errorf("no matching symbol %q in %s", displaySymbol(cand.symbol, cand.method), pkgInfo.PkgPath)
+			resolved = append(resolved, cand.pkgExpr)
+			lastErr = withKind(kindSymbolNotFound, pkgInfo.PkgPath, fmt.Errorf("no matching symbol %q in %s", displaySymbol(cand.symbol, cand.method), pkgInfo.PkgPath))
 			continue
 		}
-		return writeOutput(opts.outputPath, app.stdout, result.Markdown)
+		reportOmitted(pkgInfo.PkgPath, result.Omitted, opts)
+		if err := app.writeResult(ctx, opts, result.Markdown); err != nil {
+			return err
+		}
+		return reportBrokenLinks(opts.warnings, result.Broken)
 	}
 	if lastErr != nil {
 		return lastErr
@@ -115,6 +501,89 @@ func (app *cliApp) execute(ctx context.Context, positionals []string) error {
 	return errors.New("unable to locate documentation target")
 }
 
+func (app *cliApp) renderAllMatches(ctx context.Context, candidates []invocation, opts options) error {
+	var buf bytes.Buffer
+	var lastErr error
+	var broken []string
+	seen := make(map[string]struct{})
+	for _, cand := range candidates {
+		pkgs, err := resolvePackages(ctx, cand.pkgExpr)
+		if isLoadTimeout(err) {
+			return err
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, pkgInfo := range pkgs {
+			key := pkgInfo.PkgPath + "|" + cand.symbol + "|" + cand.method
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result, handled, err := documentTarget(pkgInfo, cand.symbol, cand.method, opts)
+			if err != nil {
+				return err
+			}
+			if !handled {
+				lastErr = withKind(kindSymbolNotFound, pkgInfo.PkgPath, fmt.Errorf("no matching symbol %q in %s", displaySymbol(cand.symbol, cand.method), pkgInfo.PkgPath))
+				continue
+			}
+			if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) {
+				buf.WriteString("\n")
+			}
+			buf.WriteString(headingText(2, opts, "from "+pkgInfo.PkgPath))
+			buf.Write(result.Markdown)
+			reportOmitted(pkgInfo.PkgPath, result.Omitted, opts)
+			broken = append(broken, result.Broken...)
+		}
+	}
+	if buf.Len() == 0 {
+		if lastErr != nil {
+			return lastErr
+		}
+		return errors.New("unable to locate documentation target")
+	}
+	if err := app.writeResult(ctx, opts, buf.Bytes()); err != nil {
+		return err
+	}
+	return reportBrokenLinks(opts.warnings, broken)
+}
+
+func (app *cliApp) renderGOOSVariants(ctx context.Context, positionals []string, opts options) error {
+	if opts.inplace || wantsDirectoryOutput(opts.outputPath) || isArchiveOutput(opts.outputPath) {
+		return errors.New("-goos supports single-package output only")
+	}
+	if len(positionals) > 1 {
+		return errors.New("-goos accepts at most one package argument")
+	}
+	pkgExpr := "."
+	if len(positionals) == 1 {
+		pkgExpr = positionals[0]
+	}
+	result, err := documentGOOSVariants(ctx, pkgExpr, opts)
+	if err != nil {
+		return err
+	}
+	if err := app.writeResult(ctx, opts, result.Markdown); err != nil {
+		return err
+	}
+	return reportBrokenLinks(opts.warnings, result.Broken)
+}
+
+// reportBrokenLinks adds unresolved doc links collected by -strict-links to
+// the run's warnings and turns them into an error once output has been
+// written.
+func reportBrokenLinks(warnings *warningLog, broken []string) error {
+	if len(broken) == 0 {
+		return nil
+	}
+	for _, msg := range broken {
+		warnings.warnf("%s", msg)
+	}
+	return fmt.Errorf("%d unresolved doc link(s)", len(broken))
+}
+
 func displaySymbol(symbol, method string) string {
 	if symbol == "" {
 		return ""
@@ -125,27 +594,258 @@ func displaySymbol(symbol, method string) string {
 	return symbol + "." + method
 }
 
-func writeOutput(path string, stdout io.Writer, data []byte) error {
+// writeResult writes a single rendered document to -o or stdout, adding the
+// -header banner and, for terminal previews, -color highlighting.
+func (app *cliApp) writeResult(ctx context.Context, opts options, data []byte) error {
+	data = withHeader(data, opts)
+	if (opts.outputPath == "" || opts.outputPath == "-") && opts.format != formatConfluence && wantsColor(opts.color, opts.noColor, app.stdout) {
+		data = colorizeMarkdown(data)
+	}
+	data, err := encodeOutput(data, opts)
+	if err != nil {
+		return err
+	}
+	return writeOutput(ctx, opts.outputPath, app.stdout, app.stderr, app.docWriter(opts), data)
+}
+
+func writeOutput(ctx context.Context, path string, stdout, stderr io.Writer, fw docWriter, data []byte) error {
 	if path == "" || path == "-" {
 		_, err := stdout.Write(data)
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	// A -dry-run plans a pipe like any other target instead of running it.
+	if _, dryRun := fw.(planWriter); isPipeOutput(path) && !dryRun {
+		return writePipe(ctx, path, stdout, stderr, data)
+	}
+	return fw.writeFile(path, data)
+}
+
+// loadHeader resolves -header: the contents of the named file when it exists,
+// otherwise the value itself as inline text.
+func loadHeader(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(value)
+	switch {
+	case err == nil:
+		value = string(data)
+	case !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("read -header: %w", err)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	return value + "\n\n", nil
+}
+
+// generatedMarker heads every Markdown file written with -mark-generated.
+const generatedMarker = "<!-- Code generated by go-docmd; DO NOT EDIT. -->"
+
+// withHeader prepends the -header text, and with -mark-generated the
+// generated marker, to a generated document.
+func withHeader(data []byte, opts options) []byte {
+	if opts.refLinks {
+		data = referenceLinks(data)
+	}
+	if opts.headerText != "" {
+		data = append([]byte(opts.headerText), data...)
+	}
+	if opts.format == formatConfluence {
+		data = markdownToConfluence(data)
+	}
+	if opts.markGenerated {
+		data = append([]byte(generatedMarker+"\n\n"), data...)
+	}
+	return data
+}
+
+// writeFileAtomic writes data to a temporary file in the target directory and
+// renames it over path, so readers never observe a partially written file and
+// a failed write leaves any previous content intact. As with os.WriteFile, an
+// existing file keeps its mode, a new one is created with perm less the
+// umask, and a symlink is written through to the file it names.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := createTempFile(path, perm)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() {
+		_ = os.Remove(tmpPath)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
 		return err
 	}
-	return os.WriteFile(path, data, 0o644)
+	if statErr == nil {
+		// The umask may have cleared bits the existing file has.
+		if err := os.Chmod(tmpPath, perm); err != nil {
+			cleanup()
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Windows may refuse to replace an existing file; move the old one
+		// aside first and put it back if the second rename fails too.
+		if runtime.GOOS != "windows" {
+			cleanup()
+			return err
+		}
+		backup := tmpPath + ".old"
+		if bakErr := os.Rename(path, backup); bakErr != nil {
+			cleanup()
+			return err
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			_ = os.Rename(backup, path)
+			cleanup()
+			return err
+		}
+		_ = os.Remove(backup)
+	}
+	return nil
+}
+
+// createTempFile creates a new file beside path for writeFileAtomic. Unlike
+// os.CreateTemp, which always uses 0600, it passes perm to the open so the
+// umask applies to it.
+func createTempFile(path string, perm os.FileMode) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	for try := 0; ; try++ {
+		f, err := os.OpenFile(prefix+strconv.FormatUint(uint64(rand.Uint32()), 10), os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) && try < 100 {
+			continue
+		}
+		return f, err
+	}
 }
 
 var legacyLongFlagSet = map[string]struct{}{
-	"all":            {},
-	"cmd":            {},
-	"short":          {},
-	"src":            {},
-	"inplace":        {},
-	"mainvars":       {},
-	"mainfuncs":      {},
-	"output":         {},
-	"case-sensitive": {},
+	"all":                       {},
+	"cmd":                       {},
+	"short":                     {},
+	"src":                       {},
+	"inplace":                   {},
+	"mainvars":                  {},
+	"mainfuncs":                 {},
+	"output":                    {},
+	"case-sensitive":            {},
+	"heading-offset":            {},
+	"lint-undocumented":         {},
+	"module-root":               {},
+	"timing":                    {},
+	"fence-lang":                {},
+	"all-matches":               {},
+	"max-go-version":            {},
+	"anchor-format":             {},
+	"enum-table":                {},
+	"index-name":                {},
+	"out-ext":                   {},
+	"exclude-unexported-fields": {},
+	"only":                      {},
+	"apischema":                 {},
+	"strict-links":              {},
+	"examples-file":             {},
+	"stability-style":           {},
+	"no-recurse":                {},
+	"header":                    {},
+	"implements":                {},
+	"param-docs":                {},
+	"no-import-line":            {},
+	"note-interfaces":           {},
+	"diff-base":                 {},
+	"goos":                      {},
+	"no-empty-sections":         {},
+	"external-doc-links":        {},
+	"stdlib-version":            {},
+	"color":                     {},
+	"no-color":                  {},
+	"method-index":              {},
+	"relative-links":            {},
+	"base-url":                  {},
+	"wrap":                      {},
+	"deps":                      {},
+	"added-since":               {},
+	"pkg-heading-format":        {},
+	"summary-only":              {},
+	"benchmarks":                {},
+	"fail-on-warning":           {},
+	"admonitions":               {},
+	"glossary":                  {},
+	"strip-copyright":           {},
+	"format":                    {},
+	"embed-examples":            {},
+	"timeout":                   {},
+	"build-notes":               {},
+	"sort-imports-grouped":      {},
+	"output-encoding":           {},
+	"on-unmappable":             {},
+	"embed-source":              {},
+	"alias":                     {},
+	"flatten-single-package":    {},
+	"default-prefix":            {},
+	"platform-matrix":           {},
+	"exclude-symbol":            {},
+	"go-version-note":           {},
+	"playground-links":          {},
+	"no-network":                {},
+	"toc-summary-len":           {},
+	"flatten-interfaces":        {},
+	"no-toc":                    {},
+	"ref-links":                 {},
+	"callers":                   {},
+	"max-methods":               {},
+	"coverage-badges":           {},
+	"use-export-data":           {},
+	"no-signatures":             {},
+	"signature-links":           {},
+	"sentence-terminators":      {},
+	"validate-links":            {},
+	"include-ignored":           {},
+	"sort-types":                {},
+	"skip-empty":                {},
+	"cli-flags":                 {},
+	"exclude-deprecated":        {},
+	"show-deprecated-list":      {},
+	"hoist-example":             {},
+	"group-packages-by-dir":     {},
+	"max-code-line":             {},
+	"allow-network":             {},
+	"compact":                   {},
+	"readme-name":               {},
+	"error-format":              {},
+	"summary-format":            {},
+	"number-headings":           {},
+	"progress":                  {},
+	"max-depth-headings":        {},
+	"dry-run":                   {},
+	"vartable":                  {},
+	"list-packages":             {},
+	"raw-doc":                   {},
+	"index":                     {},
+	"template-dir":              {},
+	"nav-json":                  {},
+	"mark-generated":            {},
+	"force":                     {},
 }
 
 func normalizeLegacyArgs(args []string) []string {
@@ -250,9 +950,13 @@ func singleArgCandidates(arg string) []invocation {
 	return candidates
 }
 
+// parseCompound splits arg into package/symbol candidates at each dot that
+// could end the package path. Only dots after the last path separator are
+// considered; which split names a real package is left to resolution.
 func parseCompound(arg string) []invocation {
 	var result []invocation
-	for i := 0; i < len(arg); i++ {
+	start := strings.LastIndexAny(arg, `/\`) + 1
+	for i := start; i < len(arg); i++ {
 		if arg[i] != '.' {
 			continue
 		}
@@ -271,6 +975,17 @@ func parseCompound(arg string) []invocation {
 	return result
 }
 
+// extendsResolved reports whether pkgExpr is one of the resolved package
+// expressions continued past a dot.
+func extendsResolved(pkgExpr string, resolved []string) bool {
+	for _, r := range resolved {
+		if strings.HasPrefix(pkgExpr, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
 func splitSymbol(spec string) (string, string) {
 	if spec == "" {
 		return "", ""
@@ -290,31 +1005,146 @@ func startsWithUpper(s string) bool {
 	return unicode.IsUpper(r)
 }
 
+// applyDocFilters narrows pkg to the symbols a document shows and returns
+// the symbols -max-go-version omitted, the ones -exclude-deprecated removed,
+// and the -strict-links failures.
+func applyDocFilters(pkg *doc.Package, fset *token.FileSet, opts options) (omitted, deprecated, broken []string) {
+	omitted = filterDocPackage(pkg, opts)
+	if opts.excludeDeprecated {
+		deprecated = excludeDeprecated(pkg)
+	}
+	if opts.changedFiles != nil {
+		filterChangedSymbols(pkg, fset, opts.changedFiles)
+	}
+	if opts.strictLinks {
+		broken = brokenDocLinks(pkg, fset)
+	}
+	return omitted, deprecated, broken
+}
+
+// finishMarkdown applies the passes over a whole rendered document.
+func finishMarkdown(md []byte, opts options) []byte {
+	if opts.noEmptySections {
+		md = dropEmptySections(md)
+	}
+	if opts.compact {
+		md = compactMarkdown(md)
+	}
+	return md
+}
+
 func documentTarget(pkgInfo *packages.Package, symbol, method string, opts options) (docResult, bool, error) {
+	var cliFlags []cliFlag
+	if opts.cliFlags && symbol == "" && pkgInfo.Name == "main" {
+		cliFlags = packageCLIFlags(sourceFiles(pkgInfo))
+	}
+	directives := packageFuncDirectives(pkgInfo.Fset, sourceFiles(pkgInfo))
+	assembly := packageAssemblyFuncs(pkgInfo)
 	docPkg, err := buildDocPackage(pkgInfo, opts)
 	if err != nil {
 		return docResult{}, false, err
 	}
+	empty := hasNoExports(docPkg, opts)
+	omitted, deprecated, broken := applyDocFilters(docPkg, pkgInfo.Fset, opts)
+	result := docResult{Omitted: omitted, Broken: broken, Empty: empty}
+	if opts.coverageBadges && symbol == "" {
+		result.Coverage = coverageBadge(docPkg)
+	}
+	if opts.apiSchema && symbol == "" {
+		if result.Schemas, err = buildAPISchemas(pkgInfo, docPkg); err != nil {
+			return docResult{}, false, err
+		}
+	}
 	var buf bytes.Buffer
 	renderer := markdownRenderer{
-		options: opts,
-		pkg:     docPkg,
-		fileset: pkgInfo.Fset,
+		options:     opts,
+		pkg:         docPkg,
+		fileset:     pkgInfo.Fset,
+		typesInfo:   pkgInfo.TypesInfo,
+		importPath:  canonicalImportPath(pkgInfo),
+		noExports:   empty,
+		cliFlags:    cliFlags,
+		deprecated:  deprecated,
+		directives:  directives,
+		assembly:    assembly,
+		focus:       symbol,
+		focusMethod: method,
+	}
+	if pkgInfo.Module != nil {
+		renderer.goVersion = pkgInfo.Module.GoVersion
+		renderer.modulePath = pkgInfo.Module.Path
+	}
+	if opts.implements {
+		renderer.implements = opts.implementsIndex
+		if renderer.implements == nil {
+			renderer.implements = newImplementsIndex([]*packages.Package{pkgInfo}, nil, false)
+		}
+	}
+	if opts.examplesFile && symbol == "" {
+		renderer.examples = &bytes.Buffer{}
+	}
+	if opts.deps && symbol == "" {
+		renderer.imports = packageImports(pkgInfo)
+		result.Imports = renderer.imports
+	}
+	if opts.embedExamples {
+		renderer.embeds = packageEmbeds(pkgInfo)
+	}
+	if opts.alias && symbol == "" {
+		renderer.aliases = splitAliases(docPkg)
 	}
+	if opts.callers && symbol != "" {
+		renderer.syntax = pkgInfo.Syntax
+	}
+	if opts.includeIgnored && symbol == "" {
+		renderer.tools = packageTools(pkgInfo, opts)
+	}
+	if opts.embedSource && symbol == "" {
+		renderer.sources = packageSources(pkgInfo, opts)
+	}
+	if opts.benchmarks && symbol == "" {
+		renderer.benchmarks = packageBenchmarks(parseTestFiles(pkgInfo, opts))
+	}
+	if opts.addedSinceBase != nil && symbol == "" {
+		old, err := opts.addedSinceBase.exportedNames(packageDir(pkgInfo), pkgInfo.Name)
+		if err != nil {
+			return docResult{}, false, err
+		}
+		renderer.addedSince = addedSymbols(docPkg, old)
+	}
+	var handled bool
 	switch {
 	case symbol == "":
 		renderer.renderPackage(&buf)
-		return docResult{
-			Markdown: buf.Bytes(),
-			Summary:  renderer.packageSummary(),
-		}, true, nil
+		result.Summary = renderer.packageSummary()
+		handled = true
 	case method == "":
-		ok := renderer.renderSymbol(&buf, symbol)
-		return docResult{Markdown: buf.Bytes()}, ok, nil
+		handled = renderer.renderSymbol(&buf, symbol)
 	default:
-		ok := renderer.renderMethod(&buf, symbol, method)
-		return docResult{Markdown: buf.Bytes()}, ok, nil
+		handled = renderer.renderMethod(&buf, symbol, method)
+	}
+	if renderer.templateErr != nil {
+		return docResult{}, false, renderer.templateErr
+	}
+	result.Markdown = finishMarkdown(buf.Bytes(), opts)
+	if renderer.examples != nil && renderer.examples.Len() > 0 {
+		header := headingText(1, opts, "Examples for package "+docPkg.Name)
+		result.Examples = append([]byte(header), renderer.examples.Bytes()...)
+	}
+	return result, handled, nil
+}
+
+// examplesFileName is the sibling file that receives examples when
+// -examples-file is set.
+func examplesFileName(opts options) string {
+	return "EXAMPLES" + outputExt(opts)
+}
+
+func writeExamplesFile(fw docWriter, dir string, doc *treeDoc, opts options) error {
+	if len(doc.examples) == 0 {
+		return nil
 	}
+	return writeDocument(fw, filepath.Join(dir, examplesFileName(opts)), doc.examples, opts)
 }
 
 func buildDocPackage(pkgInfo *packages.Package, opts options) (*doc.Package, error) {
@@ -323,30 +1153,111 @@ func buildDocPackage(pkgInfo *packages.Package, opts options) (*doc.Package, err
 		mode |= doc.AllDecls | doc.AllMethods
 	}
 	if opts.showSource {
+		// go/doc otherwise drops function bodies, which -src prints.
 		mode |= doc.PreserveAST
 	}
-	return doc.NewFromFiles(pkgInfo.Fset, pkgInfo.Syntax, pkgInfo.PkgPath, mode)
+	files := copyFiles(sourceFiles(pkgInfo))
+	files = append(files, parseTestFiles(pkgInfo, opts)...)
+	docPkg, err := doc.NewFromFiles(pkgInfo.Fset, files, pkgInfo.PkgPath, mode)
+	if err != nil {
+		return nil, err
+	}
+	ignored, err := opts.ignoreFiles.forDir(packageDir(pkgInfo))
+	if err != nil {
+		return nil, err
+	}
+	patterns := append(append([]string(nil), opts.excludeSymbols...), ignored...)
+	excludeSymbols(docPkg, patterns, opts.caseSensitive)
+	return docPkg, nil
+}
+
+// sourceFiles returns the package's syntax trees as written by the author.
+// For cgo packages go/packages parses the cgo-generated files from the build
+// cache instead, so the original GoFiles are re-parsed; files that fail to
+// parse are skipped.
+func sourceFiles(pkgInfo *packages.Package) []*ast.File {
+	if !usesCgo(pkgInfo) {
+		return pkgInfo.Syntax
+	}
+	var files []*ast.File
+	for _, path := range pkgInfo.GoFiles {
+		file, err := parser.ParseFile(pkgInfo.Fset, path, nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
 }
 
+func usesCgo(pkgInfo *packages.Package) bool {
+	for _, file := range pkgInfo.Syntax {
+		for _, spec := range file.Imports {
+			if spec.Path.Value == `"C"` {
+				return true
+			}
+		}
+		if name := pkgInfo.Fset.File(file.Pos()); name != nil && !strings.HasSuffix(name.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTestFiles parses the package's _test.go files so doc.NewFromFiles can
+// attach their examples. Files that fail to parse are skipped with a warning.
+func parseTestFiles(pkgInfo *packages.Package, opts options) []*ast.File {
+	dir := packageDir(pkgInfo)
+	if dir == "" {
+		return nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	var files []*ast.File
+	for _, path := range matches {
+		file, err := parser.ParseFile(pkgInfo.Fset, path, nil, parser.ParseComments)
+		if err != nil {
+			opts.warnings.warnf("%s: skipping unparsable test file: %v", pkgInfo.PkgPath, err)
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// packageLoadMode includes NeedDeps because type checking a cgo package
+// imports packages (syscall, unsafe) that only the dependency graph supplies
+// types for; NeedImports alone leaves them untyped and the load fails.
+const packageLoadMode = packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedFiles |
+	packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo |
+	packages.NeedTypesSizes | packages.NeedModule | packages.NeedImports | packages.NeedDeps
+
 func loadPackage(ctx context.Context, pattern string) (*packages.Package, error) {
 	cfg := &packages.Config{
-		Context: ctx,
-		Mode: packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedFiles |
-			packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo |
-			packages.NeedTypesSizes | packages.NeedModule | packages.NeedImports,
+		Mode: loadMode(ctx),
+	}
+	pkgs, err := loadPackages(ctx, cfg, pattern)
+	if err == nil && len(pkgs) > 0 && len(pkgs[0].Errors) == 0 {
+		return pkgs[0], nil
+	}
+	if isLoadTimeout(err) {
+		return nil, err
+	}
+	if ctx.Err() == nil {
+		if pkg, ok := loadLooseDirectory(pattern); ok {
+			return pkg, nil
+		}
 	}
-	pkgs, err := packages.Load(cfg, pattern)
 	if err != nil {
 		return nil, err
 	}
 	if len(pkgs) == 0 {
 		return nil, fmt.Errorf("no Go packages matched %q", pattern)
 	}
-	pkg := pkgs[0]
-	if len(pkg.Errors) > 0 {
-		return nil, fmt.Errorf("%s", pkg.Errors[0])
-	}
-	return pkg, nil
+	return nil, fmt.Errorf("%s", pkgs[0].Errors[0])
 }
 
 func resolvePackage(ctx context.Context, expr string) (*packages.Package, error) {
@@ -358,14 +1269,67 @@ func resolvePackage(ctx context.Context, expr string) (*packages.Package, error)
 		if candidate == "" {
 			continue
 		}
-		if pkg, err := loadPackage(ctx, candidate); err == nil {
+		pkg, err := loadPackage(ctx, candidate)
+		if err == nil {
 			return pkg, nil
 		}
+		if isLoadTimeout(err) {
+			return nil, err
+		}
 	}
 	if match := matchStdSuffix(expr); match != "" {
 		return loadPackage(ctx, match)
 	}
-	return nil, fmt.Errorf("could not resolve package path for %q", expr)
+	if isRemoteImportPath(expr) {
+		if remoteFetchAllowed(ctx) {
+			return loadRemotePackage(ctx, expr)
+		}
+		return nil, &remoteError{withKind(kindPackageNotFound, expr, fmt.Errorf("could not resolve package path for %q (pass -allow-network to fetch it through the module proxy)", expr))}
+	}
+	return nil, withKind(kindPackageNotFound, expr, fmt.Errorf("could not resolve package path for %q", expr))
+}
+
+// resolvePackages is like resolvePackage but returns every standard library
+// package whose path ends in expr instead of only the first.
+func resolvePackages(ctx context.Context, expr string) ([]*packages.Package, error) {
+	if expr == "" {
+		expr = "."
+	}
+	if strings.Contains(expr, "...") {
+		// A pattern matches each of its packages, not just the first.
+		pkgs, err := loadPackages(ctx, &packages.Config{Mode: loadMode(ctx)}, expr)
+		if isLoadTimeout(err) {
+			return nil, err
+		}
+		var result []*packages.Package
+		for _, pkg := range pkgs {
+			if len(pkg.Errors) == 0 {
+				result = append(result, pkg)
+			}
+		}
+		if err == nil && len(result) > 0 {
+			return result, nil
+		}
+	}
+	pkg, err := loadPackage(ctx, expr)
+	if err == nil {
+		return []*packages.Package{pkg}, nil
+	}
+	if isLoadTimeout(err) {
+		return nil, err
+	}
+	var result []*packages.Package
+	for _, match := range matchStdSuffixes(expr) {
+		pkg, err := loadPackage(ctx, match)
+		if err != nil {
+			continue
+		}
+		result = append(result, pkg)
+	}
+	if len(result) == 0 {
+		return nil, withKind(kindPackageNotFound, expr, fmt.Errorf("could not resolve package path for %q", expr))
+	}
+	return result, nil
 }
 
 var (
@@ -408,8 +1372,25 @@ func matchStdSuffix(arg string) string {
 	return best
 }
 
+func matchStdSuffixes(arg string) []string {
+	if arg == "" {
+		return nil
+	}
+	stdOnce.Do(loadStdPackages)
+	if stdErr != nil {
+		return nil
+	}
+	var matches []string
+	for _, path := range stdPackages {
+		if path == arg || strings.HasSuffix(path, "/"+arg) {
+			matches = append(matches, path)
+		}
+	}
+	return matches
+}
+
 func wantsDirectoryOutput(path string) bool {
-	if path == "" || path == "-" {
+	if path == "" || path == "-" || isPipeOutput(path) {
 		return false
 	}
 	info, err := os.Stat(path)
@@ -425,60 +1406,266 @@ func wantsDirectoryOutput(path string) bool {
 	return filepath.Ext(path) == ""
 }
 
-func documentPackageTree(ctx context.Context, root string, opts options) error {
-	docs, baseDir, err := collectPackageDocs(ctx, root, opts)
+// documentSymbolSet writes one Markdown file per symbol matching pattern into
+// the -o directory, plus an index.md linking them.
+func documentSymbolSet(ctx context.Context, pkgExpr, pattern string, opts options, fw docWriter) error {
+	if strings.Contains(pattern, ".") {
+		return errors.New("symbol globs in directory output cannot select methods or fields")
+	}
+	pkgInfo, err := resolvePackage(ctx, pkgExpr)
+	if err != nil {
+		return err
+	}
+	docPkg, err := buildDocPackage(pkgInfo, opts)
+	if err != nil {
+		return err
+	}
+	filterDocPackage(docPkg, opts)
+	if opts.changedFiles != nil {
+		filterChangedSymbols(docPkg, pkgInfo.Fset, opts.changedFiles)
+	}
+	lister := markdownRenderer{options: opts, pkg: docPkg, fileset: pkgInfo.Fset}
+	matches := lister.matchingSymbols(pattern)
+	if len(matches) == 0 {
+		return fmt.Errorf("no symbols matching %q in %s", pattern, pkgInfo.PkgPath)
+	}
+	// Each file renders exactly one symbol, so match names case-sensitively.
+	exact := opts
+	exact.caseSensitive = true
+	renderer := markdownRenderer{options: exact, pkg: docPkg, fileset: pkgInfo.Fset, typesInfo: pkgInfo.TypesInfo}
+	var index bytes.Buffer
+	index.WriteString(headingText(1, opts, "Symbols in "+pkgInfo.PkgPath))
+	for _, match := range matches {
+		var buf bytes.Buffer
+		renderer.focus = match.name
+		renderer.renderSymbol(&buf, match.name)
+		if renderer.templateErr != nil {
+			return renderer.templateErr
+		}
+		file := match.name + outputExt(opts)
+		if err := writeDocument(fw, filepath.Join(opts.outputPath, file), buf.Bytes(), opts); err != nil {
+			return err
+		}
+		if summary := lister.summaryText(match.doc); summary != "" {
+			fmt.Fprintf(&index, "- [%s](%s) — %s\n", match.name, file, summary)
+		} else {
+			fmt.Fprintf(&index, "- [%s](%s)\n", match.name, file)
+		}
+	}
+	index.WriteString("\n")
+	return writeDocument(fw, filepath.Join(opts.outputPath, "index"+outputExt(opts)), index.Bytes(), opts)
+}
+
+// wantsCombinedOutput reports whether a tree pattern was paired with a single
+// output file, in which case every package is concatenated into that file.
+func wantsCombinedOutput(path string, positionals []string) bool {
+	if path == "" || path == "-" || isPipeOutput(path) || len(positionals) != 1 {
+		return false
+	}
+	return strings.Contains(positionals[0], "...") && !wantsDirectoryOutput(path)
+}
+
+func documentPackageTree(ctx context.Context, root string, opts options, fw docWriter, stderr io.Writer) error {
+	var timing *treeTiming
+	if opts.timing {
+		timing = newTreeTiming()
+	}
+	var links *linkCheckWriter
+	if opts.validateLinks {
+		if isArchiveOutput(opts.outputPath) {
+			return errors.New("-validate-links cannot check archive output")
+		}
+		links = newLinkCheckWriter(fw, opts)
+		fw = links
+	}
+	if opts.showProgress {
+		opts.progress = newTreeProgress(stderr)
+	}
+	docs, baseDir, err := collectPackageDocs(ctx, root, opts, timing)
 	if err != nil {
 		return err
 	}
 	if len(docs) == 0 {
 		return fmt.Errorf("no packages matched %q", root)
 	}
-	if opts.inplace {
+	if opts.flattenSinglePackage && len(docs) == 1 && !opts.summaryOnly && !hasOutputPlaceholder(opts.outputPath) {
+		// A lone package is written at the output root, as a single-package
+		// run would write it, however deep the pattern found it.
+		docs[0].relDir = ""
+		baseDir = docs[0].pkgDir
+	}
+	writeStart := time.Now()
+	switch {
+	case opts.inplace:
 		if baseDir == "" {
 			return errors.New("cannot determine base directory for in-place output")
 		}
-		return writePackageDocsInPlace(baseDir, docs)
-	}
-	if opts.outputPath == "" {
+		err = writePackageDocsInPlace(fw, baseDir, docs, opts)
+	case opts.outputPath == "":
 		return errors.New("directory output requires -o pointing to a directory")
+	case hasOutputPlaceholder(opts.outputPath):
+		err = writePackageDocsToPattern(fw, opts.outputPath, docs, opts)
+	case isArchiveOutput(opts.outputPath):
+		err = writePackageDocsToArchive(fw, opts.outputPath, docs, opts)
+	case !wantsDirectoryOutput(opts.outputPath):
+		err = writeCombinedPackageDocs(fw, opts.outputPath, docs, opts)
+	default:
+		err = writePackageDocsToDir(fw, opts.outputPath, docs, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.apiSchema && !opts.summaryOnly && !isArchiveOutput(opts.outputPath) {
+		for _, doc := range docs {
+			if err := writeAPISchemas(fw, schemaBaseDir(doc, opts), doc.schemas); err != nil {
+				return err
+			}
+		}
+	}
+	timing.recordWrite(time.Since(writeStart))
+	timing.report(stderr, len(docs))
+	if links != nil {
+		if err := reportDanglingLinks(opts.warnings, links.danglingLinks()); err != nil {
+			return err
+		}
+	}
+	var broken []string
+	for _, doc := range docs {
+		reportOmitted(doc.pkgPath, doc.omitted, opts)
+		broken = append(broken, doc.broken...)
+	}
+	return reportBrokenLinks(opts.warnings, broken)
+}
+
+// schemaBaseDir returns the directory whose schemas/ folder receives the
+// package's API schemas, mirroring where its Markdown was written.
+func schemaBaseDir(doc treeDoc, opts options) string {
+	switch {
+	case opts.inplace:
+		return doc.pkgDir
+	case hasOutputPlaceholder(opts.outputPath):
+		return filepath.Dir(expandOutputPattern(opts.outputPath, &doc))
+	case !wantsDirectoryOutput(opts.outputPath):
+		return filepath.Join(filepath.Dir(opts.outputPath), filepath.FromSlash(doc.relDir))
+	default:
+		return filepath.Join(opts.outputPath, filepath.FromSlash(doc.relDir))
 	}
-	return writePackageDocsToDir(opts.outputPath, docs)
 }
 
-func collectPackageDocs(ctx context.Context, root string, opts options) ([]treeDoc, string, error) {
-	pkgs, err := loadPackageTree(ctx, root)
+func collectPackageDocs(ctx context.Context, root string, opts options, timing *treeTiming) ([]treeDoc, string, error) {
+	loadStart := time.Now()
+	pkgs, err := loadPackageTree(ctx, root, !opts.noRecurse)
 	if err != nil {
 		return nil, "", err
 	}
+	timing.recordLoad(time.Since(loadStart))
 	if len(pkgs) == 0 {
 		return nil, "", nil
 	}
 	baseDir := resolveBaseDir(root)
+	if opts.moduleRoot {
+		if dir := moduleRootDir(pkgs); dir != "" {
+			baseDir = dir
+		}
+	}
+	if baseDir == "" {
+		baseDir = commonPackageDir(pkgs)
+	}
+	var relDirs map[string]string
+	if !opts.inplace {
+		relDirs = workspaceRelDirs(pkgs)
+	}
+	if relDirs == nil {
+		relDirs = make(map[string]string, len(pkgs))
+		for _, pkgInfo := range pkgs {
+			relDirs[pkgInfo.PkgPath] = deriveRelativeDir(pkgInfo, baseDir, absolutePath(packageDir(pkgInfo)))
+		}
+	}
+	if opts.implements {
+		linkFiles := opts.inplace || wantsDirectoryOutput(opts.outputPath)
+		opts.implementsIndex = newImplementsIndex(pkgs, relDirs, linkFiles)
+	}
 	docs := make([]treeDoc, 0, len(pkgs))
+	opts.progress.start(len(pkgs))
 	for _, pkgInfo := range pkgs {
+		renderStart := time.Now()
 		docRes, handled, err := documentTarget(pkgInfo, "", "", opts)
 		if err != nil {
 			return nil, "", err
 		}
-		if !handled {
+		timing.recordRender(pkgInfo.PkgPath, time.Since(renderStart))
+		opts.progress.finished(pkgInfo.PkgPath)
+		if !handled || (opts.skipEmpty && docRes.Empty) {
 			continue
 		}
-		pkgDir := absolutePath(packageDir(pkgInfo))
-		if baseDir == "" && pkgDir != "" {
-			baseDir = pkgDir
-		}
-		relDir := deriveRelativeDir(pkgInfo, baseDir, pkgDir)
 		docs = append(docs, treeDoc{
-			relDir:   relDir,
-			pkgDir:   pkgDir,
+			relDir:   relDirs[pkgInfo.PkgPath],
+			pkgDir:   absolutePath(packageDir(pkgInfo)),
 			pkgPath:  pkgInfo.PkgPath,
+			pkgName:  pkgInfo.Name,
 			summary:  docRes.Summary,
 			markdown: docRes.Markdown,
+			omitted:  docRes.Omitted,
+			schemas:  docRes.Schemas,
+			broken:   docRes.Broken,
+			examples: docRes.Examples,
+			imports:  docRes.Imports,
+			coverage: docRes.Coverage,
 		})
 	}
 	return docs, baseDir, nil
 }
 
+// commonPackageDir picks the tree's base directory when the root argument is
+// not a directory: the lexicographically smallest package directory that
+// contains every other one, or else their deepest common ancestor. The result
+// depends only on the set of directories, so repeated runs agree.
+func commonPackageDir(pkgs []*packages.Package) string {
+	var dirs []string
+	for _, pkg := range pkgs {
+		if dir := packageDir(pkg); dir != "" {
+			dirs = append(dirs, canonicalPath(dir))
+		}
+	}
+	if len(dirs) == 0 {
+		return ""
+	}
+	sort.Strings(dirs)
+	for _, candidate := range dirs {
+		if containsAllDirs(candidate, dirs) {
+			return candidate
+		}
+	}
+	common := dirs[0]
+	for !containsAllDirs(common, dirs) {
+		parent := filepath.Dir(common)
+		if parent == common {
+			break
+		}
+		common = parent
+	}
+	return common
+}
+
+func containsAllDirs(base string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(base, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+func moduleRootDir(pkgs []*packages.Package) string {
+	for _, pkg := range pkgs {
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			return absolutePath(pkg.Module.Dir)
+		}
+	}
+	return ""
+}
+
 func absolutePath(dir string) string {
 	if dir == "" {
 		return ""
@@ -490,9 +1677,13 @@ func absolutePath(dir string) string {
 	return abs
 }
 
+// deriveRelativeDir places pkg relative to baseDir. Both directories are
+// resolved through symlinks first, so a symlinked package or base directory
+// does not look like it lies outside the tree.
 func deriveRelativeDir(pkg *packages.Package, baseDir, pkgDir string) string {
 	if baseDir != "" && pkgDir != "" {
-		if rel, err := filepath.Rel(baseDir, pkgDir); err == nil && rel != "" && !strings.HasPrefix(rel, "..") {
+		rel, err := filepath.Rel(canonicalPath(baseDir), canonicalPath(pkgDir))
+		if err == nil && rel != "" && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
 			if rel == "." {
 				return "."
 			}
@@ -508,15 +1699,12 @@ func deriveRelativeDir(pkg *packages.Package, baseDir, pkgDir string) string {
 	return pkg.Name
 }
 
-func loadPackageTree(ctx context.Context, root string) ([]*packages.Package, error) {
-	patterns := buildPatterns(root)
+func loadPackageTree(ctx context.Context, root string, recurse bool) ([]*packages.Package, error) {
+	patterns := buildPatterns(root, recurse)
 	cfg := &packages.Config{
-		Context: ctx,
-		Mode: packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedFiles |
-			packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo |
-			packages.NeedTypesSizes | packages.NeedModule | packages.NeedImports,
+		Mode: loadMode(ctx),
 	}
-	pkgs, err := packages.Load(cfg, patterns...)
+	pkgs, err := loadPackages(ctx, cfg, patterns...)
 	if err != nil {
 		return nil, err
 	}
@@ -541,14 +1729,16 @@ func loadPackageTree(ctx context.Context, root string) ([]*packages.Package, err
 	return result, nil
 }
 
-func buildPatterns(root string) []string {
+// buildPatterns returns the go/packages patterns for a tree rooted at root.
+// Unless recurse is false, a root without "..." also matches its subpackages.
+func buildPatterns(root string, recurse bool) []string {
 	root = strings.TrimSpace(root)
 	if root == "" {
 		root = "."
 	}
 	root = filepath.ToSlash(root)
 	patterns := []string{root}
-	if !strings.Contains(root, "...") {
+	if recurse && !strings.Contains(root, "...") {
 		recursive := root
 		if recursive == "." {
 			recursive = "./..."
@@ -566,23 +1756,72 @@ type treeDoc struct {
 	relDir   string
 	pkgDir   string
 	pkgPath  string
+	pkgName  string
 	summary  string
 	markdown []byte
+	omitted  []string
+	schemas  []typeSchema
+	broken   []string
+	examples []byte
+	imports  []string
+	coverage string
 }
 
 type tocEntry struct {
-	title   string
-	link    string
-	summary string
+	title    string
+	relDir   string
+	link     string
+	summary  string
+	coverage string
 }
 
-func writePackageDocsToDir(outDir string, docs []treeDoc) error {
+// applyReadmeName splits -readme-name, a whole file name such as DOCS.md,
+// into the -index-name and -out-ext it stands for.
+func applyReadmeName(opts *options) error {
+	name := strings.TrimSpace(opts.readmeName)
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid -readme-name %q (want a file name, not a path)", opts.readmeName)
+	}
+	if opts.indexNameSet || opts.outExtSet {
+		return errors.New("-readme-name cannot be combined with -index-name or -out-ext")
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		return fmt.Errorf("invalid -readme-name %q", opts.readmeName)
+	}
+	opts.indexName = base
+	if ext != "" {
+		opts.outExt = ext
+	}
+	return nil
+}
+
+// indexFileName returns the per-package file name written in tree mode,
+// README.md unless -index-name or -out-ext say otherwise.
+func indexFileName(opts options) string {
+	name := strings.TrimSpace(opts.indexName)
+	if name == "" {
+		name = "README"
+	}
+	return name + outputExt(opts)
+}
+
+// outputExt is the extension, dot included, of every file a run writes
+// into an output directory: .md unless -out-ext or -format chose another.
+func outputExt(opts options) string {
+	ext := strings.TrimPrefix(strings.TrimSpace(opts.outExt), ".")
+	if ext == "" {
+		ext = "md"
+	}
+	return "." + ext
+}
+
+func writePackageDocsToDir(fw docWriter, outDir string, docs []treeDoc, opts options) error {
 	if outDir == "" {
 		return errors.New("missing output directory")
 	}
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return err
-	}
+	indexName := indexFileName(opts)
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].relDir < docs[j].relDir
 	})
@@ -595,113 +1834,210 @@ func writePackageDocsToDir(outDir string, docs []treeDoc) error {
 		if doc.relDir != "" && doc.relDir != "." {
 			targetDir = filepath.Join(outDir, doc.relDir)
 		}
-		if err := os.MkdirAll(targetDir, 0o755); err != nil {
-			return err
+		if !opts.summaryOnly {
+			if err := writeExamplesFile(fw, targetDir, doc, opts); err != nil {
+				return err
+			}
 		}
-		filePath := filepath.Join(targetDir, "README.md")
+		filePath := filepath.Join(targetDir, indexName)
 		if doc.relDir == "" || doc.relDir == "." {
 			rootDoc = doc
 			rootPath = filePath
 			continue
 		}
-		if err := os.WriteFile(filePath, doc.markdown, 0o644); err != nil {
-			return err
+		if !opts.summaryOnly {
+			if err := writeDocument(fw, filePath, doc.markdown, opts); err != nil {
+				return err
+			}
+		}
+		link := filepath.ToSlash(filepath.Join(doc.relDir, indexName))
+		if absoluteLinks(opts) {
+			link = docURL(opts, doc.relDir)
 		}
 		entries = append(entries, tocEntry{
-			title:   linkTitle(doc),
-			link:    filepath.ToSlash(filepath.Join(doc.relDir, "README.md")),
-			summary: strings.TrimSpace(doc.summary),
+			relDir:   doc.relDir,
+			title:    linkTitle(doc),
+			link:     link,
+			summary:  strings.TrimSpace(doc.summary),
+			coverage: doc.coverage,
 		})
 	}
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].title < entries[j].title
 	})
-	toc := buildTOC(entries)
+	var toc []byte
+	if !opts.noTOC {
+		toc = buildTOC(entries, opts)
+	}
+	if opts.deps {
+		toc = append(toc, dependencySummary(docs, opts)...)
+	}
 	switch {
-	case rootDoc != nil:
-		content := appendTOCAfterDoc(rootDoc.markdown, toc)
-		if err := os.WriteFile(rootPath, content, 0o644); err != nil {
+	case rootDoc != nil && !opts.summaryOnly:
+		content := joinMarkdown(rootDoc.markdown, toc)
+		if err := writeDocument(fw, rootPath, content, opts); err != nil {
 			return err
 		}
 	case len(toc) > 0:
-		if err := os.WriteFile(filepath.Join(outDir, "README.md"), toc, 0o644); err != nil {
+		if err := writeDocument(fw, filepath.Join(outDir, indexName), toc, opts); err != nil {
 			return err
 		}
 	}
+	if opts.navJSON {
+		return writeNavJSON(fw, outDir, rootDoc, indexName, entries)
+	}
 	return nil
 }
 
-func writePackageDocsInPlace(baseDir string, docs []treeDoc) error {
+func writePackageDocsInPlace(fw docWriter, baseDir string, docs []treeDoc, opts options) error {
 	if baseDir == "" {
 		return errors.New("missing base directory for in-place output")
 	}
 	baseDir = filepath.Clean(baseDir)
 	var entries []tocEntry
 	var rootDoc *treeDoc
-	rootPath := filepath.Join(baseDir, "README.md")
+	indexName := indexFileName(opts)
+	rootPath := filepath.Join(baseDir, indexName)
 	for i := range docs {
 		doc := &docs[i]
 		pkgDir := doc.pkgDir
 		if pkgDir == "" {
 			continue
 		}
-		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
-			return err
+		if !opts.summaryOnly {
+			if err := writeExamplesFile(fw, pkgDir, doc, opts); err != nil {
+				return err
+			}
 		}
-		target := filepath.Join(pkgDir, "README.md")
+		target := filepath.Join(pkgDir, indexName)
 		if sameDir(pkgDir, baseDir) {
 			rootDoc = doc
 			rootPath = target
 			continue
 		}
-		if err := os.WriteFile(target, doc.markdown, 0o644); err != nil {
-			return err
+		if !opts.summaryOnly {
+			if err := writeDocument(fw, target, doc.markdown, opts); err != nil {
+				return err
+			}
 		}
-		relLink, err := filepath.Rel(baseDir, target)
+		relLink, err := filepath.Rel(canonicalPath(baseDir), filepath.Join(canonicalPath(pkgDir), indexName))
 		if err != nil {
 			relLink = target
 		}
+		relLink = filepath.ToSlash(relLink)
+		if absoluteLinks(opts) {
+			relLink = docURL(opts, doc.relDir)
+		}
 		entries = append(entries, tocEntry{
-			title:   linkTitle(doc),
-			link:    filepath.ToSlash(relLink),
-			summary: strings.TrimSpace(doc.summary),
+			relDir:   doc.relDir,
+			title:    linkTitle(doc),
+			link:     relLink,
+			summary:  strings.TrimSpace(doc.summary),
+			coverage: doc.coverage,
 		})
 	}
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].title < entries[j].title
 	})
-	toc := buildTOC(entries)
+	var toc []byte
+	if !opts.noTOC {
+		toc = buildTOC(entries, opts)
+	}
+	if opts.deps {
+		toc = append(toc, dependencySummary(docs, opts)...)
+	}
 	var content []byte
 	switch {
-	case rootDoc != nil:
-		content = appendTOCAfterDoc(rootDoc.markdown, toc)
+	case rootDoc != nil && !opts.summaryOnly:
+		content = joinMarkdown(rootDoc.markdown, toc)
 	case len(toc) > 0:
 		content = toc
 	}
-	if len(content) == 0 && rootDoc != nil {
+	if len(content) == 0 && rootDoc != nil && !opts.summaryOnly {
 		content = rootDoc.markdown
 	}
-	if len(content) == 0 {
-		return nil
+	if len(content) > 0 {
+		if err := writeDocument(fw, rootPath, content, opts); err != nil {
+			return err
+		}
+	}
+	if opts.navJSON {
+		return writeNavJSON(fw, baseDir, rootDoc, indexName, entries)
 	}
-	return os.WriteFile(rootPath, content, 0o644)
+	return nil
+}
+
+func writeCombinedPackageDocs(fw docWriter, path string, docs []treeDoc, opts options) error {
+	if opts.flattenSinglePackage && len(docs) == 1 {
+		return writeDocument(fw, path, docs[0].markdown, opts)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].pkgPath < docs[j].pkgPath
+	})
+	var body bytes.Buffer
+	var entries []tocEntry
+	slugs := make(map[string]int)
+	for i := range docs {
+		doc := &docs[i]
+		content := doc.markdown
+		heading, ok := leadingHeading(content)
+		if !ok {
+			heading = "package " + linkTitle(doc)
+			header := headingText(1, opts, heading)
+			content = append([]byte(header), content...)
+		}
+		slug := slugify(opts.anchorFormat, heading)
+		if n := slugs[slug]; n > 0 {
+			slugs[slug] = n + 1
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		} else {
+			slugs[slug] = 1
+		}
+		entries = append(entries, tocEntry{
+			relDir:   doc.relDir,
+			title:    doc.pkgPath,
+			link:     "#" + slug,
+			summary:  strings.TrimSpace(doc.summary),
+			coverage: doc.coverage,
+		})
+		if body.Len() > 0 && !bytes.HasSuffix(body.Bytes(), []byte("\n\n")) {
+			body.WriteString("\n")
+		}
+		body.Write(content)
+	}
+	content := joinMarkdown(buildTOC(entries, opts), body.Bytes())
+	return writeDocument(fw, path, content, opts)
+}
+
+// leadingHeading returns the text of the Markdown heading on the first line of
+// content, if there is one.
+func leadingHeading(content []byte) (string, bool) {
+	line, _, _ := strings.Cut(string(content), "\n")
+	trimmed := strings.TrimLeft(line, "#")
+	if trimmed == line || !strings.HasPrefix(trimmed, " ") {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed), true
 }
 
 func sameDir(a, b string) bool {
 	if a == "" || b == "" {
 		return false
 	}
-	return filepath.Clean(a) == filepath.Clean(b)
+	return canonicalPath(a) == canonicalPath(b)
 }
 
-func appendTOCAfterDoc(doc []byte, toc []byte) []byte {
-	if len(toc) == 0 {
-		return append([]byte{}, doc...)
+// joinMarkdown returns first followed by second, separated by a blank line,
+// as when a tree's table of contents follows its root package doc or leads
+// the combined file.
+func joinMarkdown(first, second []byte) []byte {
+	if len(second) == 0 {
+		return append([]byte{}, first...)
 	}
-	if len(doc) == 0 {
-		return append([]byte{}, toc...)
+	if len(first) == 0 {
+		return append([]byte{}, second...)
 	}
-	content := append([]byte{}, doc...)
+	content := append([]byte{}, first...)
 	if !bytes.HasSuffix(content, []byte("\n\n")) {
 		if bytes.HasSuffix(content, []byte("\n")) {
 			content = append(content, '\n')
@@ -709,7 +2045,7 @@ func appendTOCAfterDoc(doc []byte, toc []byte) []byte {
 			content = append(content, '\n', '\n')
 		}
 	}
-	content = append(content, toc...)
+	content = append(content, second...)
 	return content
 }
 
@@ -723,23 +2059,51 @@ func linkTitle(doc *treeDoc) string {
 	return "."
 }
 
-func buildTOC(entries []tocEntry) []byte {
+func buildTOC(entries []tocEntry, opts options) []byte {
 	if len(entries) == 0 {
 		return nil
 	}
 	var buf bytes.Buffer
-	buf.WriteString("## Packages\n\n")
-	for _, entry := range entries {
-		if entry.summary != "" {
-			fmt.Fprintf(&buf, "- [%s](%s) — %s\n", entry.title, entry.link, entry.summary)
-		} else {
-			fmt.Fprintf(&buf, "- [%s](%s)\n", entry.title, entry.link)
+	buf.WriteString(headingText(2, opts, "Packages"))
+	if opts.groupPackagesByDir {
+		writeGroupedTOC(&buf, entries, opts)
+	} else {
+		for _, entry := range entries {
+			buf.WriteString(tocLine("", entry.title, entry, opts))
 		}
 	}
 	buf.WriteString("\n")
 	return buf.Bytes()
 }
 
+// tocLine is one package bullet of the TOC, linked as title and indented by
+// indent.
+func tocLine(indent, title string, entry tocEntry, opts options) string {
+	summary := truncateAtWord(entry.summary, opts.tocSummaryLen)
+	if entry.coverage != "" {
+		summary = strings.TrimSpace(summary + " *(" + entry.coverage + ")*")
+	}
+	if summary != "" {
+		return fmt.Sprintf("%s- [%s](%s) — %s\n", indent, title, entry.link, summary)
+	}
+	return fmt.Sprintf("%s- [%s](%s)\n", indent, title, entry.link)
+}
+
+// truncateAtWord shortens s to at most limit runes for -toc-summary-len,
+// cutting at the last space that fits and appending "…", which counts toward
+// the limit. A limit of zero or less keeps s whole.
+func truncateAtWord(s string, limit int) string {
+	runes := []rune(s)
+	if limit <= 0 || len(runes) <= limit {
+		return s
+	}
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
+
 func resolveBaseDir(root string) string {
 	root = strings.TrimSpace(root)
 	if root == "" {
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const apiSchemaDirective = "+apischema"
+
+type typeSchema struct {
+	name string
+	data []byte
+}
+
+// buildAPISchemas returns a JSON Schema document for every type in docPkg
+// whose doc comment carries the +apischema directive.
+func buildAPISchemas(pkgInfo *packages.Package, docPkg *doc.Package) ([]typeSchema, error) {
+	if pkgInfo.Types == nil {
+		return nil, nil
+	}
+	gen := schemaGenerator{
+		fieldDocs: collectFieldDocs(sourceFiles(pkgInfo)),
+		visiting:  make(map[*types.Named]bool),
+	}
+	var schemas []typeSchema
+	for _, t := range docPkg.Types {
+		if !hasDirectiveLine(t.Doc, apiSchemaDirective) {
+			continue
+		}
+		obj, ok := pkgInfo.Types.Scope().Lookup(t.Name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		schema := gen.schemaFor(obj.Type())
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["title"] = t.Name
+		_, text := docDirective(t.Doc, apiSchemaDirective)
+		if desc := strings.TrimSpace(text); desc != "" {
+			schema["description"] = desc
+		}
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, typeSchema{name: t.Name, data: append(data, '\n')})
+	}
+	return schemas, nil
+}
+
+func writeAPISchemas(fw docWriter, dir string, schemas []typeSchema) error {
+	if len(schemas) == 0 {
+		return nil
+	}
+	dir = filepath.Join(dir, "schemas")
+	for _, schema := range schemas {
+		if err := fw.writeFile(filepath.Join(dir, schema.name+".json"), schema.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasDirectiveLine(text, name string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFieldDocs maps each struct field's position to its doc comment so
+// descriptions can be attached to the go/types fields.
+func collectFieldDocs(files []*ast.File) map[token.Pos]string {
+	docs := make(map[token.Pos]string)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok {
+				return true
+			}
+			var text string
+			switch {
+			case field.Doc != nil:
+				text = field.Doc.Text()
+			case field.Comment != nil:
+				text = field.Comment.Text()
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				return true
+			}
+			if len(field.Names) == 0 {
+				docs[field.Type.Pos()] = text
+			}
+			for _, name := range field.Names {
+				docs[name.Pos()] = text
+			}
+			return true
+		})
+	}
+	return docs
+}
+
+type schemaGenerator struct {
+	fieldDocs map[token.Pos]string
+	visiting  map[*types.Named]bool
+}
+
+func (g *schemaGenerator) schemaFor(typ types.Type) map[string]any {
+	switch t := typ.(type) {
+	case *types.Named:
+		if obj := t.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if g.visiting[t] {
+			// Recursive types are cut off rather than expanded forever.
+			return map[string]any{"type": "object", "description": "recursive reference to " + t.Obj().Name()}
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+		return g.schemaFor(t.Underlying())
+	case *types.Alias:
+		return g.schemaFor(types.Unalias(t))
+	case *types.Pointer:
+		return g.schemaFor(t.Elem())
+	case *types.Basic:
+		return basicSchema(t)
+	case *types.Slice:
+		if b, ok := t.Elem().(*types.Basic); ok && b.Kind() == types.Byte {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case *types.Array:
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem()), "maxItems": t.Len(), "minItems": t.Len()}
+	case *types.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case *types.Struct:
+		return g.structSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(st *types.Struct) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	g.addStructFields(st, properties, &required)
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addStructFields(st *types.Struct, properties map[string]any, required *[]string) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Embedded() && name == "" {
+			// encoding/json promotes the fields of untagged embedded structs.
+			if embedded, ok := derefType(field.Type()).Underlying().(*types.Struct); ok {
+				g.addStructFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		prop := g.schemaFor(field.Type())
+		if desc := g.fieldDocs[field.Pos()]; desc != "" {
+			prop["description"] = desc
+		}
+		properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func derefType(typ types.Type) types.Type {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return typ
+}
+
+func basicSchema(b *types.Basic) map[string]any {
+	info := b.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return map[string]any{"type": "boolean"}
+	case info&types.IsInteger != 0:
+		return map[string]any{"type": "integer"}
+	case info&types.IsFloat != 0:
+		return map[string]any{"type": "number"}
+	case info&types.IsString != 0:
+		return map[string]any{"type": "string"}
+	default:
+		return map[string]any{}
+	}
+}
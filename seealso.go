@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"strings"
+)
+
+const seeAlsoDirective = "See also:"
+
+// renderSeeAlso writes the names from a `See also:` line as links to their
+// headings, in this document or on pkg.go.dev. Names that are not package
+// symbols render as code spans.
+func (r *markdownRenderer) renderSeeAlso(w io.Writer, names string) {
+	var refs []string
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+		name = strings.Trim(name, "[]`")
+		if name == "" {
+			continue
+		}
+		if target, ok := r.symbolLink(name); ok {
+			refs = append(refs, fmt.Sprintf("[%s](%s)", name, target))
+		} else {
+			refs = append(refs, "`"+name+"`")
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "**See also:** %s\n\n", strings.Join(refs, ", "))
+}
+
+// symbolLink returns where a link to the package symbol name should point:
+// its heading when this document renders it, otherwise its pkg.go.dev page,
+// since a single-symbol document lacks the other symbols' headings.
+func (r *markdownRenderer) symbolLink(name string) (string, bool) {
+	anchor, ok := r.symbolAnchor(name)
+	if !ok {
+		return "", false
+	}
+	if r.rendersSymbol(name) {
+		return "#" + anchor, true
+	}
+	path := r.displayImportPath()
+	if path == "" {
+		return "", false
+	}
+	return "https://pkg.go.dev/" + path + "#" + name, true
+}
+
+// rendersSymbol reports whether this document has a heading for name: every
+// symbol does in package output, while a single-symbol document holds only
+// its symbol, its value group, and for a type its members.
+func (r *markdownRenderer) rendersSymbol(name string) bool {
+	if r.focus == "" {
+		return true
+	}
+	recv, method, isMethod := strings.Cut(name, ".")
+	if r.focusMethod != "" {
+		return isMethod && r.matchName(recv, r.focus) && r.matchName(method, r.focusMethod)
+	}
+	if isMethod {
+		return r.matchName(recv, r.focus)
+	}
+	if r.matchName(name, r.focus) {
+		return true
+	}
+	var groups []*doc.Value
+	groups = append(groups, r.pkg.Consts...)
+	groups = append(groups, r.pkg.Vars...)
+	for _, t := range r.pkg.Types {
+		groups = append(groups, t.Consts...)
+		groups = append(groups, t.Vars...)
+		if !r.matchName(t.Name, r.focus) {
+			continue
+		}
+		for _, f := range t.Funcs {
+			if f.Name == name {
+				return true
+			}
+		}
+		for _, v := range append(t.Consts, t.Vars...) {
+			if contains(v.Names, name) {
+				return true
+			}
+		}
+	}
+	for _, v := range groups {
+		if r.valueHasName(v, r.focus) && contains(v.Names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbolAnchor returns the anchor of the heading the renderer emits for the
+// package symbol name, which may be a Type.Method selector. Members of grouped
+// value blocks resolve to their own anchor rather than the shared heading.
+func (r *markdownRenderer) symbolAnchor(name string) (string, bool) {
+	if recv, method, ok := strings.Cut(name, "."); ok {
+		for _, t := range r.pkg.Types {
+			if t.Name != recv {
+				continue
+			}
+			for _, m := range t.Methods {
+				if m.Name == method {
+					return slugify(r.options.anchorFormat, funcHeading(t.Name, m.Decl)), true
+				}
+			}
+		}
+		return "", false
+	}
+	for _, t := range r.pkg.Types {
+		if t.Name == name {
+			return slugify(r.options.anchorFormat, "type "+t.Name), true
+		}
+		for _, f := range t.Funcs {
+			if f.Name == name {
+				return slugify(r.options.anchorFormat, f.Name), true
+			}
+		}
+		for _, v := range append(t.Consts, t.Vars...) {
+			if contains(v.Names, name) {
+				return slugify(r.options.anchorFormat, name), true
+			}
+		}
+	}
+	for _, f := range r.pkg.Funcs {
+		if f.Name == name {
+			return slugify(r.options.anchorFormat, f.Name), true
+		}
+	}
+	for _, v := range append(r.pkg.Consts, r.pkg.Vars...) {
+		if contains(v.Names, name) {
+			return slugify(r.options.anchorFormat, name), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultSentenceTerminators are the stops go/doc's synopsis recognizes: the
+// period and the CJK full stops 。 and ．.
+const defaultSentenceTerminators = ".。．"
+
+// firstSentence returns text up to and including its first sentence
+// terminator from terminators. ASCII terminators end a sentence before
+// whitespace, or before an uppercase letter when the word they end starts
+// with one, so "X does A.Y does B." splits while "io.Reader", "e.g." and
+// "v1.2" stay whole; a terminator inside a [doc link] never ends one. Others,
+// such as the CJK full stop, need no space after them. Text without a
+// terminator is returned whole.
+func firstSentence(text, terminators string) string {
+	if terminators == "" {
+		terminators = defaultSentenceTerminators
+	}
+	links, word := 0, 0
+	for i, r := range text {
+		switch {
+		case r == '[':
+			links++
+		case r == ']' && links > 0:
+			links--
+		case unicode.IsSpace(r):
+			word = i + utf8.RuneLen(r)
+		}
+		if !strings.ContainsRune(terminators, r) {
+			continue
+		}
+		end := i + utf8.RuneLen(r)
+		if r >= utf8.RuneSelf || end == len(text) {
+			return strings.TrimSpace(text[:end])
+		}
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsSpace(next) || links == 0 && unicode.IsUpper(next) && startsUpper(text[word:i]) {
+			return strings.TrimSpace(text[:end])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// startsUpper reports whether word begins with an uppercase letter.
+func startsUpper(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// renderSignatureLinks writes, for -signature-links, a References line under
+// a function's signature linking the package's own types and constants it
+// mentions, since the code block itself cannot hold links. Names resolve
+// through type information; without it nothing is written. Names not rendered
+// in this document link to pkg.go.dev instead.
+func (r *markdownRenderer) renderSignatureLinks(w io.Writer, decl *ast.FuncDecl) {
+	if !r.options.signatureLinks || r.options.noSignatures || r.typesInfo == nil || decl == nil || decl.Type == nil {
+		return
+	}
+	self := r.typesInfo.Defs[decl.Name]
+	if self == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	var refs []string
+	ast.Inspect(decl.Type, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		switch obj := r.typesInfo.Uses[id].(type) {
+		case *types.TypeName, *types.Const:
+			if obj.Pkg() != self.Pkg() || seen[obj.Name()] {
+				return true
+			}
+			if target, ok := r.symbolLink(obj.Name()); ok {
+				seen[obj.Name()] = true
+				refs = append(refs, fmt.Sprintf("[%s](%s)", obj.Name(), target))
+			}
+		}
+		return true
+	})
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "**References:** %s\n\n", strings.Join(refs, ", "))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	anchorGitHub    = "github"
+	anchorGitLab    = "gitlab"
+	anchorBitbucket = "bitbucket"
+)
+
+// slugify converts a heading into the anchor fragment the given host
+// generates for it. execute rejects formats other than the three hosts; the
+// empty format of a zero options value uses GitHub's rules.
+func slugify(format, heading string) string {
+	heading = strings.TrimSpace(heading)
+	switch format {
+	case anchorGitLab:
+		return collapseHyphens(githubSlug(heading))
+	case anchorBitbucket:
+		slug := collapseHyphens(githubSlug(heading))
+		return "markdown-header-" + strings.Trim(slug, "-")
+	default:
+		return githubSlug(heading)
+	}
+}
+
+// githubSlug lowercases the heading, drops punctuation other than hyphens
+// and underscores, and turns each space into a hyphen.
+func githubSlug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+func collapseHyphens(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return s
+}
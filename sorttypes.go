@@ -0,0 +1,61 @@
+package main
+
+import (
+	"go/doc"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// -sort-types orders. Without one, types keep go/doc's order, which sorts
+// them by name with case significant.
+const (
+	sortTypesName    = "name"
+	sortTypesSource  = "source"
+	sortTypesMethods = "methods"
+)
+
+func validSortTypes(order string) bool {
+	switch order {
+	case "", sortTypesName, sortTypesSource, sortTypesMethods:
+		return true
+	}
+	return false
+}
+
+// sortTypes returns types in the -sort-types order: alphabetically (ignoring
+// case unless -c is set), in declaration order by file and position, or by
+// method count with the most methods first. Ties keep go/doc's order.
+func (r *markdownRenderer) sortTypes(types []*doc.Type) []*doc.Type {
+	var less func(a, b *doc.Type) bool
+	switch r.options.sortTypes {
+	case sortTypesName:
+		if r.options.caseSensitive {
+			less = func(a, b *doc.Type) bool { return a.Name < b.Name }
+		} else {
+			less = func(a, b *doc.Type) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+		}
+	case sortTypesSource:
+		if r.fileset == nil {
+			return types
+		}
+		less = func(a, b *doc.Type) bool { return positionLess(r.fileset, a.Decl.Pos(), b.Decl.Pos()) }
+	case sortTypesMethods:
+		less = func(a, b *doc.Type) bool { return len(a.Methods) > len(b.Methods) }
+	default:
+		return types
+	}
+	sorted := append([]*doc.Type(nil), types...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted
+}
+
+// positionLess orders two positions by file name and then offset, so the
+// order does not depend on the order files were added to the file set.
+func positionLess(fset *token.FileSet, a, b token.Pos) bool {
+	pa, pb := fset.Position(a), fset.Position(b)
+	if pa.Filename != pb.Filename {
+		return pa.Filename < pb.Filename
+	}
+	return pa.Offset < pb.Offset
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// embedSourceLimit caps the bytes of source -embed-source inlines per
+// package; files past it are left out with a warning.
+const embedSourceLimit = 64 << 10
+
+// packageSources reads the package's Go files for -embed-source.
+func packageSources(pkgInfo *packages.Package, opts options) []embeddedFile {
+	var files []embeddedFile
+	total := 0
+	for i, path := range pkgInfo.GoFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			opts.warnings.warnf("%s: -embed-source cannot read %s: %v", pkgInfo.PkgPath, filepath.Base(path), err)
+			continue
+		}
+		if total+len(data) > embedSourceLimit {
+			opts.warnings.warnf("%s: -embed-source left out %d of %d file(s) past the %d KiB limit", pkgInfo.PkgPath, len(pkgInfo.GoFiles)-i, len(pkgInfo.GoFiles), embedSourceLimit>>10)
+			break
+		}
+		total += len(data)
+		files = append(files, embeddedFile{name: filepath.Base(path), data: data})
+	}
+	return files
+}
+
+// renderSource writes the -embed-source block: every Go file of the package
+// in a fenced block, inside a collapsed <details> element.
+func (r *markdownRenderer) renderSource(w io.Writer) {
+	if len(r.sources) == 0 {
+		return
+	}
+	fmt.Fprint(w, "<details><summary>Source</summary>\n\n")
+	for _, file := range r.sources {
+		fmt.Fprintf(w, "`%s`:\n\n%s\n\n", file.name, fencedBlock("go", string(file.data)))
+	}
+	fmt.Fprint(w, "</details>\n\n")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"strings"
+)
+
+// specComment is a value spec's own doc comment, or its trailing line comment.
+func specComment(vs *ast.ValueSpec) string {
+	switch {
+	case vs.Doc != nil:
+		return vs.Doc.Text()
+	case vs.Comment != nil:
+		return vs.Comment.Text()
+	}
+	return ""
+}
+
+// renderSpecComments lists the entries of a parenthesized const or var block
+// that carry their own comment, after the block's doc comment, so the notes
+// read as rendered text rather than only as comments inside the code block.
+func (r *markdownRenderer) renderSpecComments(w io.Writer, decl *ast.GenDecl) {
+	if decl == nil || !decl.Lparen.IsValid() {
+		return
+	}
+	var lines []string
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		text := strings.Join(strings.Fields(r.docText(specComment(vs))), " ")
+		if text == "" {
+			continue
+		}
+		var names []string
+		for _, ident := range vs.Names {
+			if ident.Name != "_" {
+				names = append(names, ident.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		lines = append(lines, bulletLine(strings.Join(names, ", "), text))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
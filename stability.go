@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const stabilityDirective = "+stability:"
+
+const (
+	stabilityText  = "text"
+	stabilityBadge = "badge"
+	stabilityNone  = "none"
+)
+
+// stabilityColors maps the recognized stability levels to shields.io badge
+// colors. Other values still render, in grey.
+var stabilityColors = map[string]string{
+	"experimental": "orange",
+	"stable":       "brightgreen",
+	"deprecated":   "red",
+}
+
+func validStabilityStyle(style string) bool {
+	switch style {
+	case stabilityText, stabilityBadge, stabilityNone:
+		return true
+	}
+	return false
+}
+
+// renderStability writes the package's +stability: level in the configured
+// style. Packages without the directive render nothing.
+func (r *markdownRenderer) renderStability(w io.Writer) {
+	level, _ := docDirective(r.pkg.Doc, stabilityDirective)
+	if level == "" {
+		return
+	}
+	switch r.options.stabilityStyle {
+	case stabilityNone:
+		return
+	case stabilityBadge:
+		color, ok := stabilityColors[strings.ToLower(level)]
+		if !ok {
+			color = "lightgrey"
+		}
+		fmt.Fprintf(w, "![Stability: %s](https://img.shields.io/badge/stability-%s-%s)\n\n", level, shieldsEscape(level), color)
+	default:
+		fmt.Fprintf(w, "**Stability: %s**\n\n", level)
+	}
+}
+
+// shieldsEscape encodes a badge label segment: dashes and underscores are
+// doubled and spaces become underscores.
+func shieldsEscape(s string) string {
+	s = strings.ReplaceAll(s, "-", "--")
+	s = strings.ReplaceAll(s, "_", "__")
+	return strings.ReplaceAll(s, " ", "_")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// -summary-format layouts of the package summary.
+const (
+	summaryBullets = "bullets"
+	summaryTable   = "table"
+)
+
+// summaryEntry is one line of the package summary: a symbol's signature or
+// title, the kind of declaration it came from, and its first sentence.
+type summaryEntry struct {
+	symbol  string
+	kind    string
+	summary string
+}
+
+func (e summaryEntry) bullet() string {
+	return bulletLine(e.symbol, e.summary)
+}
+
+// tableRow is the -summary-format=table sibling of bullet. The kind has its
+// own column, so a type is listed by name alone.
+func (e summaryEntry) tableRow() string {
+	symbol := strings.TrimPrefix(e.symbol, "type ")
+	return fmt.Sprintf("| `%s` | %s | %s |", escapeTableCell(symbol), e.kind, escapeTableCell(e.summary))
+}
+
+func renderSummaryTable(w io.Writer, entries []summaryEntry) {
+	fmt.Fprintln(w, "| Symbol | Kind | Description |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, entry := range entries {
+		fmt.Fprintln(w, entry.tableRow())
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/doc"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// renderTemplates holds the optional per-kind overrides loaded from
+// -template-dir. A nil template means the built-in rendering is used for that
+// kind.
+type renderTemplates struct {
+	pkg   *template.Template
+	typ   *template.Template
+	fn    *template.Template
+	value *template.Template
+}
+
+type packageTemplateData struct {
+	Heading    string
+	Name       string
+	ImportPath string
+	Doc        string
+	// Body is the built-in rendering of everything after the package doc.
+	Body string
+}
+
+type typeTemplateData struct {
+	Heading  string
+	Name     string
+	Decl     string
+	Doc      string
+	Examples string
+	// Members is the built-in rendering of the type's constants, variables,
+	// functions, and methods, which themselves honor value.tmpl and
+	// func.tmpl.
+	Members string
+}
+
+type funcTemplateData struct {
+	Heading   string
+	Name      string
+	Receiver  string
+	Signature string
+	Source    string
+	Doc       string
+	Examples  string
+}
+
+type valueTemplateData struct {
+	Heading string
+	Names   []string
+	Decl    string
+	Doc     string
+}
+
+// loadTemplateDir parses package.tmpl, type.tmpl, func.tmpl, and value.tmpl
+// from dir. Each file is optional, but the directory must exist.
+func loadTemplateDir(dir string) (*renderTemplates, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read -template-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("-template-dir %s is not a directory", dir)
+	}
+	var tmpls renderTemplates
+	for _, file := range []struct {
+		name string
+		dst  **template.Template
+	}{
+		{"package.tmpl", &tmpls.pkg},
+		{"type.tmpl", &tmpls.typ},
+		{"func.tmpl", &tmpls.fn},
+		{"value.tmpl", &tmpls.value},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file.name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(file.name).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse -template-dir: %w", err)
+		}
+		*file.dst = tmpl
+	}
+	return &tmpls, nil
+}
+
+// activeTemplates returns the -template-dir overrides, or nil when there are
+// none or -short asks for one-line summaries instead.
+func (r *markdownRenderer) activeTemplates() *renderTemplates {
+	if r.options.short {
+		return nil
+	}
+	return r.options.templates
+}
+
+// executeTemplate writes tmpl applied to data. The first failure is kept in
+// templateErr, since rendering itself does not return errors.
+func (r *markdownRenderer) executeTemplate(w io.Writer, tmpl *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if r.templateErr == nil {
+			r.templateErr = fmt.Errorf("execute -template-dir: %w", err)
+		}
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+func (r *markdownRenderer) renderPackageTemplate(w io.Writer, tmpl *template.Template) {
+	data := packageTemplateData{Name: r.pkg.Name, ImportPath: r.displayImportPath(), Doc: r.docMarkdown(r.pkg.Doc)}
+	if r.pkg.Name != "main" {
+		data.Heading = r.headingLine(w, 1, r.packageTitle())
+	}
+	var body bytes.Buffer
+	r.renderPackageBody(&body)
+	data.Body = body.String()
+	r.executeTemplate(w, tmpl, data)
+}
+
+func (r *markdownRenderer) renderTypeTemplate(w io.Writer, tmpl *template.Template, t *doc.Type) {
+	_, text := docDirective(t.Doc, seeAlsoDirective)
+	heading := r.headingLine(w, 2, "type "+t.Name)
+	var examples, members bytes.Buffer
+	r.renderExamples(&examples, 3, t.Name, t.Examples)
+	r.renderTypeMembers(&members, t)
+	r.executeTemplate(w, tmpl, typeTemplateData{
+		Heading:  heading,
+		Name:     t.Name,
+		Decl:     r.formatNode(t.Decl),
+		Doc:      r.docMarkdown(text),
+		Examples: examples.String(),
+		Members:  members.String(),
+	})
+}
+
+func (r *markdownRenderer) renderFuncTemplate(w io.Writer, tmpl *template.Template, f *doc.Func, receiver string) {
+	name := f.Name
+	if receiver != "" {
+		name = receiver + "." + f.Name
+	}
+	_, text := docDirective(f.Doc, seeAlsoDirective)
+	data := funcTemplateData{
+		Heading:   r.headingLine(w, 4, funcHeading(receiver, f.Decl)),
+		Name:      f.Name,
+		Receiver:  receiver,
+		Signature: r.signature(f.Decl),
+		Doc:       r.docMarkdown(text),
+	}
+	if r.options.showSource {
+		data.Source = r.declSource(f.Decl)
+	}
+	var examples bytes.Buffer
+	r.renderExamples(&examples, 5, name, f.Examples)
+	data.Examples = examples.String()
+	r.executeTemplate(w, tmpl, data)
+}
+
+func (r *markdownRenderer) renderValueTemplate(w io.Writer, tmpl *template.Template, v *doc.Value) {
+	r.executeTemplate(w, tmpl, valueTemplateData{
+		Heading: r.headingLine(w, 4, r.valueTitle(v)),
+		Names:   v.Names,
+		Decl:    r.formatNode(v.Decl),
+		Doc:     r.docMarkdown(v.Doc),
+	})
+}
@@ -0,0 +1,19 @@
+// Package aliases exercises -alias.
+package aliases
+
+// Celsius is a temperature in degrees Celsius.
+type Celsius float64
+
+// Temp is the old name of Celsius, kept for compatibility.
+type Temp = Celsius
+
+type (
+	// Degrees is another name for Celsius.
+	Degrees = Celsius
+	Count   = int // Count is a plain int.
+)
+
+// NewTemp returns a Temp holding v.
+func NewTemp(v float64) Temp {
+	return Temp(v)
+}
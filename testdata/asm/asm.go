@@ -0,0 +1,10 @@
+// Package asm has a function implemented in assembly.
+package asm
+
+// Add returns x + y.
+func Add(x, y int) int
+
+// Sub returns x - y.
+func Sub(x, y int) int {
+	return x - y
+}
@@ -0,0 +1,35 @@
+// Package callers has functions that call one another.
+package callers
+
+// Normalize trims a name.
+func Normalize(name string) string {
+	return name
+}
+
+// Store keeps names.
+type Store struct {
+	names []string
+}
+
+// Add normalizes and stores name.
+func (s *Store) Add(name string) {
+	s.names = append(s.names, Normalize(name))
+}
+
+// Lookup is a helper that never calls Normalize.
+func Lookup(s *Store, i int) string {
+	return s.names[i]
+}
+
+func normalizeAll(names []string) {
+	for i, name := range names {
+		names[i] = Normalize(name)
+	}
+}
+
+type cache struct{}
+
+// Warm is exported but belongs to an unexported type.
+func (cache) Warm() {
+	Normalize("")
+}
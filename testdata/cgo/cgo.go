@@ -0,0 +1,24 @@
+//go:build cgo
+
+// Package cgo verifies that go-docmd documents cgo packages.
+package cgo
+
+/*
+#include <stdlib.h>
+
+static int twice(int v) { return v * 2; }
+*/
+import "C"
+
+// Twice doubles v using a C helper.
+func Twice(v int) int {
+	return int(C.twice(C.int(v)))
+}
+
+// Buffer wraps memory allocated by C.
+type Buffer struct {
+	// Size is the allocation size in bytes.
+	Size int
+
+	ptr *C.char
+}
@@ -0,0 +1,23 @@
+// Command cliflags registers flags through a local stand-in for the flag
+// and pflag APIs, so the fixture loads without imports.
+package main
+
+type flagSet struct{}
+
+func (flagSet) String(name, value, usage string) *string                       { return &value }
+func (flagSet) IntVar(p *int, name string, value int, usage string)            {}
+func (flagSet) BoolVarP(p *bool, name, short string, value bool, usage string) {}
+func (flagSet) Func(name, usage string, fn func(string) error)                 {}
+
+var flags flagSet
+
+var out = flags.String("out", "docs", "write files under `dir`")
+
+func main() {
+	var depth int
+	var verbose bool
+	flags.IntVar(&depth, "depth", 3, "heading depth "+
+		"(1-6)")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "log | progress")
+	flags.Func("tag", "add a tag", func(string) error { return nil })
+}
@@ -0,0 +1,27 @@
+// Package config exposes package-level tunables for -vartable tests.
+package config
+
+// Timeout is how long to wait, in seconds.
+var Timeout = 30
+
+// Service settings.
+var (
+	// Name identifies the service.
+	Name string = "svc"
+
+	Verbose, Debug bool // toggles extra logging
+)
+
+// Reset restores the default settings.
+//
+// See also: Debug.
+func Reset() {}
+
+// Options configures the service.
+type Options struct {
+	// Level is the minimum log level.
+	// default: "info"
+	Level string
+
+	Port int // default: 8080
+}
@@ -0,0 +1,13 @@
+// Package constraints declares type-set interfaces for generic code.
+package constraints
+
+// Number is satisfied by integer and floating-point kinds.
+type Number interface {
+	~int | ~int64 | float64
+}
+
+// StringerInt is an integer kind that can also describe itself.
+type StringerInt interface {
+	~int | ~uint
+	String() string
+}
@@ -0,0 +1,29 @@
+// Package deprecated mixes current and deprecated API.
+package deprecated
+
+// Client is the current client.
+type Client struct{}
+
+// Do sends a request.
+func (Client) Do() {}
+
+// DoLegacy sends a request the old way.
+//
+// Deprecated: Use Do.
+func (Client) DoLegacy() {}
+
+// OldClient is the previous client.
+//
+// Deprecated: Use Client.
+type OldClient struct{}
+
+// NewOldClient returns an OldClient.
+func NewOldClient() OldClient { return OldClient{} }
+
+// Limit is the request limit.
+const Limit = 10
+
+// MaxRetries was the retry limit.
+//
+// Deprecated: Retries are no longer limited.
+const MaxRetries = 3
@@ -0,0 +1,11 @@
+// Package directives has functions with compiler directives.
+package directives
+
+// Add adds two numbers without being inlined.
+//
+//go:noinline
+//go:nosplit
+func Add(a, b int) int { return a + b }
+
+// Sub subtracts b from a.
+func Sub(a, b int) int { return a - b }
@@ -0,0 +1,21 @@
+// Package embed exercises fields promoted through embedded structs.
+package embed
+
+// Base carries metadata shared by every record.
+type Base struct {
+	// ID uniquely identifies the record.
+	ID string
+	meta
+}
+
+type meta struct {
+	// Created is the creation time in Unix seconds.
+	Created int64
+}
+
+// Record is a titled entry that embeds Base.
+type Record struct {
+	Base
+	// Title names the record.
+	Title string
+}
@@ -0,0 +1,21 @@
+package example
+
+import "testing"
+
+// BenchmarkGreet measures building a greeting.
+// It reuses one Greeter across iterations.
+func BenchmarkGreet(b *testing.B) {
+	g := NewGreeter("gopher")
+	for i := 0; i < b.N; i++ {
+		_ = g.Greet()
+	}
+}
+
+func BenchmarkNewGreeter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = NewGreeter("gopher")
+	}
+}
+
+// Benchmarkish is not a benchmark: the name continues in lower case.
+func Benchmarkish(b *testing.B) {}
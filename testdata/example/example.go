@@ -17,6 +17,9 @@ const (
 type Greeter struct {
 	// Name is included to verify field documentation.
 	Name string
+
+	// greetings counts how many messages were produced.
+	greetings int
 }
 
 // NewGreeter constructs a Greeter.
@@ -28,3 +31,75 @@ func NewGreeter(name string) *Greeter {
 func (g *Greeter) Greet() string {
 	return "hello " + g.Name
 }
+
+// Close releases the greeter.
+//
+// docgroup: Lifecycle
+func (g *Greeter) Close() error {
+	return nil
+}
+
+// Farewell returns a parting message.
+//
+// availableSince: go1.22
+func (g *Greeter) Farewell() string {
+	return "bye " + g.Name
+}
+
+// Handler formats a greeting for name.
+type Handler func(name string) string
+
+// Registry indexes greeters by name.
+// Lookups behave as follows:
+// | Key | Result |
+// | --- | :---: |
+// | known | the greeter |
+// | a\|b | nil |
+// Missing keys never panic.
+type Registry map[string]*Greeter
+
+// Mood selects the tone of a greeting.
+type Mood int
+
+// Supported moods.
+const (
+	// Cheerful greetings use exclamation marks.
+	Cheerful Mood = iota
+	Neutral       // Neutral greetings are plain.
+	// Grumpy greetings are terse.
+	Grumpy
+)
+
+// ParseMood converts a name into a Mood, reporting whether it was known.
+func ParseMood(name string) (Mood, bool) {
+	switch name {
+	case "cheerful":
+		return Cheerful, true
+	case "grumpy":
+		return Grumpy, true
+	}
+	return Neutral, name == "neutral"
+}
+
+// CountMoods reports how many greeters use each mood.
+func CountMoods(greeters []*Greeter) (counts map[string]int, dominant Mood) {
+	return map[string]int{}, Neutral
+}
+
+// CreateGreeterRequest is the payload for creating a greeter.
+//
+// +apischema
+type CreateGreeterRequest struct {
+	// Name identifies the greeter.
+	Name string `json:"name"`
+	// Mood sets the greeting tone.
+	Mood   Mood              `json:"mood,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Options tweaks delivery.
+	Options struct {
+		Loud bool `json:"loud"`
+	} `json:"options"`
+	Secret string `json:"-"`
+	draft  bool
+}
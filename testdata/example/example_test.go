@@ -0,0 +1,22 @@
+package example_test
+
+import (
+	"fmt"
+
+	"github.com/agentflare-ai/go-docmd/testdata/example"
+)
+
+func ExampleGreeter_Greet() {
+	g := example.NewGreeter("gopher")
+	fmt.Println(g.Greet())
+	// Output: hello gopher
+}
+
+func ExampleNewGreeter_unordered() {
+	for _, name := range []string{"a", "b"} {
+		fmt.Println(example.NewGreeter(name).Name)
+	}
+	// Unordered output:
+	// a
+	// b
+}
@@ -0,0 +1,28 @@
+// Package generic declares generic types with methods.
+package generic
+
+// List is an ordered collection.
+type List[T any] struct {
+	items []T
+}
+
+// Append adds v to the end of l.
+func (l *List[T]) Append(v T) {
+	l.items = append(l.items, v)
+}
+
+// Len reports the number of items.
+func (l List[T]) Len() int {
+	return len(l.items)
+}
+
+// Pair holds a key and a value.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// First returns the key.
+func (p Pair[K, V]) First() K {
+	return p.Key
+}
@@ -0,0 +1,8 @@
+// Package i18n はドキュメントの要約を検証します。二文目は要約に含まれません。
+package i18n
+
+// Bonjour renvoie la salutation « bonjour » de fr.Locale. Elle reste polie.
+func Bonjour() string { return "bonjour" }
+
+// Attention ! Cette fonction efface tout. À utiliser avec prudence.
+func Attention() {}
@@ -0,0 +1,20 @@
+// Package ignored exercises .docmdignore and -exclude-symbol.
+package ignored
+
+// Client talks to the service.
+type Client struct{}
+
+// Get fetches a value.
+func (Client) Get() {}
+
+// Reset clears the client state.
+func (Client) Reset() {}
+
+// Generated is a large generated type.
+type Generated struct{}
+
+// GeneratedHelper is generated too.
+func GeneratedHelper() {}
+
+// Version is the protocol version.
+const Version = 2
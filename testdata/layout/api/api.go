@@ -0,0 +1,2 @@
+// Package api serves requests.
+package api
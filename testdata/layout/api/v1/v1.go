@@ -0,0 +1,2 @@
+// Package v1 is the first API version.
+package v1
@@ -0,0 +1,2 @@
+// Package layout is the root of a multi-level tree.
+package layout
@@ -0,0 +1,2 @@
+// Package sql stores data in a database.
+package sql
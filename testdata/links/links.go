@@ -0,0 +1,11 @@
+// Package links exercises the -strict-links check.
+//
+// Valid references: [Widget], [Widget.Spin], [io.Reader], and
+// [the docs](https://example.com/docs).
+package links
+
+// Widget refers to a missing method [Widget.Spinn].
+type Widget struct{}
+
+// Spin rotates the widget; see also [Gadget].
+func (Widget) Spin() {}
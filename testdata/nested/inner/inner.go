@@ -0,0 +1,5 @@
+// Package inner is the only package below testdata/nested.
+package inner
+
+// Ping reports whether the package is reachable.
+func Ping() bool { return true }
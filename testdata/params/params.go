@@ -0,0 +1,24 @@
+// Package params exercises the -param-docs tables.
+package params
+
+// Join concatenates parts with sep between each element.
+//
+// parts: the strings to join, in order.
+// sep: the separator placed between elements.
+// joined: the concatenated result.
+func Join(parts []string, sep string) (joined string) {
+	for i, p := range parts {
+		if i > 0 {
+			joined += sep
+		}
+		joined += p
+	}
+	return joined
+}
+
+// Split breaks s around each instance of sep.
+//
+// See also: Join, strings.Split.
+func Split(s, sep string) []string {
+	return nil
+}
@@ -0,0 +1,7 @@
+// Package platform exercises -goos variant merging.
+package platform
+
+// Name reports the platform name.
+func Name() string {
+	return name
+}
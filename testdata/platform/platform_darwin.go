@@ -0,0 +1,6 @@
+package platform
+
+const name = "darwin"
+
+// Kqueue is available on Darwin.
+func Kqueue() {}
@@ -0,0 +1,6 @@
+package platform
+
+const name = "linux"
+
+// Epoll is only available on Linux.
+func Epoll() {}
@@ -0,0 +1,6 @@
+package platform
+
+const name = "windows"
+
+// IOCP is only available on Windows.
+func IOCP() {}
@@ -0,0 +1,21 @@
+// Package quickstart greets people. Most callers only need New and Greet,
+// but the prose about them runs long enough that the usage sits far down.
+//
+// Names are trimmed before greeting.
+//
+//	g := quickstart.New("Hello")
+//	fmt.Println(g.Greet("Gopher"))
+//
+// A second block is not hoisted:
+//
+//	quickstart.New("Hi").Greet("")
+package quickstart
+
+// Greeter greets people.
+type Greeter struct{ prefix string }
+
+// New returns a Greeter using prefix.
+func New(prefix string) Greeter { return Greeter{prefix: prefix} }
+
+// Greet greets name.
+func (g Greeter) Greet(name string) string { return g.prefix + ", " + name }
@@ -0,0 +1,13 @@
+// Package circle provides a Shape from another package.
+package circle
+
+// Circle is a round shape.
+type Circle struct {
+	// Radius is the distance from the center to the edge.
+	Radius float64
+}
+
+// Area returns the circle's area.
+func (c *Circle) Area() float64 {
+	return 3 * c.Radius * c.Radius
+}
@@ -0,0 +1,18 @@
+// Package shapes exercises the -implements relationships.
+package shapes
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64
+}
+
+// Square is a Shape with equal sides.
+type Square struct {
+	// Side is the length of each side.
+	Side float64
+}
+
+// Area returns the square's area.
+func (s Square) Area() float64 {
+	return s.Side * s.Side
+}
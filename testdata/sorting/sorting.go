@@ -0,0 +1,28 @@
+// Package sorting declares types whose name, source, and method-count orders
+// all differ.
+package sorting
+
+// Zeta is declared first and has one method.
+type Zeta struct{}
+
+// One is Zeta's only method.
+func (Zeta) One() {}
+
+// Mid is declared second and has three methods.
+type Mid struct{}
+
+// A is a method of Mid.
+func (Mid) A() {}
+
+// B is a method of Mid.
+func (Mid) B() {}
+
+// C is a method of Mid.
+func (Mid) C() {}
+
+// alpha is unexported, declared last, and has two methods.
+type alpha struct{}
+
+func (alpha) a() {}
+
+func (alpha) b() {}
@@ -0,0 +1,4 @@
+// Package hidden has only unexported declarations.
+package hidden
+
+const secret = 1
@@ -0,0 +1,5 @@
+// Package sparse exports one symbol and has a subpackage that exports none.
+package sparse
+
+// Visible is exported.
+const Visible = 1
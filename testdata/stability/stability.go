@@ -0,0 +1,7 @@
+// Package stability exercises the package stability directive.
+//
+// +stability: experimental
+package stability
+
+// Version reports the package version.
+const Version = "0.1.0"
@@ -0,0 +1,25 @@
+// Package streams declares interfaces that embed one another.
+package streams
+
+// Reader reads bytes.
+type Reader interface {
+	Read(p []byte) (n int, err error)
+}
+
+// Writer writes bytes.
+type Writer interface {
+	Write(p []byte) (n int, err error)
+}
+
+// ReadWriter groups Read and Write.
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
+// ReadWriteCloser adds Close to a ReadWriter.
+type ReadWriteCloser interface {
+	ReadWriter
+	// Close releases the stream.
+	Close() error
+}
@@ -0,0 +1,7 @@
+//go:build ignore
+
+// Gen rewrites the Version constant in tools.go from the latest release
+// tag.
+package main
+
+func main() {}
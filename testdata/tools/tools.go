@@ -0,0 +1,7 @@
+//go:generate go run gen.go
+
+// Package tools has a generator beside it.
+package tools
+
+// Version is written by gen.go.
+const Version = "v1"
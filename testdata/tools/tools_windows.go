@@ -0,0 +1,4 @@
+package tools
+
+// Drive is the default drive letter.
+const Drive = "C"
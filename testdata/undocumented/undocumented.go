@@ -0,0 +1,11 @@
+// Package undocumented exercises the -lint-undocumented mode.
+package undocumented
+
+// Documented has a doc comment.
+func Documented() {}
+
+func Bare() {}
+
+type Widget struct{}
+
+func (Widget) Spin() {}
@@ -0,0 +1,6 @@
+// Package vanity is imported under a canonical path that differs from its
+// directory.
+package vanity // import "example.com/vanity"
+
+// Version names the release.
+const Version = "1.0"
@@ -0,0 +1,24 @@
+package widgets_test
+
+import (
+	"fmt"
+
+	"github.com/agentflare-ai/go-docmd/testdata/widgets"
+)
+
+func Example() {
+	fmt.Println("widgets")
+	// Output: widgets
+}
+
+func ExampleWidget() {
+	fmt.Println(widgets.Widget{Size: 1}.Size)
+	// Output: 1
+}
+
+func ExampleWidget_Resize() {
+	var w widgets.Widget
+	w.Resize(2)
+	fmt.Println(w.Size)
+	// Output: 2
+}
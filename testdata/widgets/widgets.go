@@ -0,0 +1,11 @@
+// Package widgets has examples for the package, a type, and a method.
+package widgets
+
+// Widget is a resizable widget.
+type Widget struct{ Size int }
+
+// Resize sets the widget's size.
+func (w *Widget) Resize(size int) { w.Size = size }
+
+// Gadget has no examples.
+type Gadget struct{}
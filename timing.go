@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const slowestPackageCount = 5
+
+type packageTiming struct {
+	pkgPath  string
+	duration time.Duration
+}
+
+// treeTiming accumulates durations for tree generation. A nil *treeTiming is
+// valid and records nothing, so call sites don't need to check -timing.
+type treeTiming struct {
+	start    time.Time
+	load     time.Duration
+	render   time.Duration
+	write    time.Duration
+	packages []packageTiming
+}
+
+func newTreeTiming() *treeTiming {
+	return &treeTiming{start: time.Now()}
+}
+
+func (t *treeTiming) recordLoad(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.load += d
+}
+
+func (t *treeTiming) recordRender(pkgPath string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.render += d
+	t.packages = append(t.packages, packageTiming{pkgPath: pkgPath, duration: d})
+}
+
+func (t *treeTiming) recordWrite(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.write += d
+}
+
+func (t *treeTiming) report(w io.Writer, count int) {
+	if t == nil || w == nil {
+		return
+	}
+	fmt.Fprintf(w, "timing: %d packages in %s\n", count, roundDuration(time.Since(t.start)))
+	fmt.Fprintf(w, "  load:   %s\n", roundDuration(t.load))
+	fmt.Fprintf(w, "  render: %s\n", roundDuration(t.render))
+	fmt.Fprintf(w, "  write:  %s\n", roundDuration(t.write))
+	if len(t.packages) == 0 {
+		return
+	}
+	slowest := append([]packageTiming{}, t.packages...)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].duration > slowest[j].duration
+	})
+	if len(slowest) > slowestPackageCount {
+		slowest = slowest[:slowestPackageCount]
+	}
+	fmt.Fprintln(w, "slowest packages by render time:")
+	for _, p := range slowest {
+		fmt.Fprintf(w, "  %-10s %s\n", roundDuration(p.duration), p.pkgPath)
+	}
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
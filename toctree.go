@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tocNode is one directory level of a -group-packages-by-dir TOC. entry is
+// nil for a directory that holds no package of its own.
+type tocNode struct {
+	name     string
+	entry    *tocEntry
+	children []*tocNode
+}
+
+func (n *tocNode) child(name string) *tocNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &tocNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+// writeGroupedTOC writes the TOC entries as nested bullets following the
+// segments of their relative directories, each package titled by its last
+// segment. Entries without a directory, such as the root package of a
+// combined document, stay at the top level under their full title.
+func writeGroupedTOC(buf *bytes.Buffer, entries []tocEntry, opts options) {
+	root := &tocNode{}
+	for i := range entries {
+		segments := []string{entries[i].title}
+		if dir := filepath.ToSlash(entries[i].relDir); dir != "" && dir != "." {
+			segments = strings.Split(dir, "/")
+		}
+		node := root
+		for _, segment := range segments {
+			node = node.child(segment)
+		}
+		node.entry = &entries[i]
+	}
+	var write func(n *tocNode, indent string)
+	write = func(n *tocNode, indent string) {
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].name < n.children[j].name
+		})
+		for _, c := range n.children {
+			if c.entry != nil {
+				buf.WriteString(tocLine(indent, c.name, *c.entry, opts))
+			} else {
+				buf.WriteString(indent + "- " + c.name + "/\n")
+			}
+			write(c, indent+"  ")
+		}
+	}
+	write(root, "")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// toolFile is a `//go:build ignore` program, typically a generator run with
+// `go run`, that lives beside a package.
+type toolFile struct {
+	name string
+	doc  string
+}
+
+// packageTools finds the package's build-ignored files for -include-ignored
+// and reads their doc comments. Files excluded only for another platform
+// are not tools and are skipped.
+func packageTools(pkgInfo *packages.Package, opts options) []toolFile {
+	var tools []toolFile
+	for _, path := range pkgInfo.IgnoredFiles {
+		if filepath.Ext(path) != ".go" || !ignoreConstrained(fileBuildConstraint(path)) {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments|parser.PackageClauseOnly)
+		if err != nil {
+			opts.warnings.warnf("%s: -include-ignored cannot parse %s: %v", pkgInfo.PkgPath, filepath.Base(path), err)
+			continue
+		}
+		tool := toolFile{name: filepath.Base(path)}
+		if file.Doc != nil {
+			tool.doc = file.Doc.Text()
+		}
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].name < tools[j].name })
+	return tools
+}
+
+// ignoreConstrained reports whether expr holds only when the "ignore" tag
+// is set, as `//go:build ignore` does.
+func ignoreConstrained(expr constraint.Expr) bool {
+	if expr == nil {
+		return false
+	}
+	withIgnore := expr.Eval(func(tag string) bool { return tag == "ignore" })
+	without := expr.Eval(func(string) bool { return false })
+	return withIgnore && !without
+}
+
+// renderTools writes the -include-ignored section: each build-ignored
+// program with the command that runs it and its doc comment.
+func (r *markdownRenderer) renderTools(w io.Writer) {
+	if len(r.tools) == 0 {
+		return
+	}
+	r.heading(w, 2, "Tools")
+	for _, tool := range r.tools {
+		r.heading(w, 3, "%s", tool.name)
+		fmt.Fprintf(w, "%s\n\n", fencedBlock("sh", "go run "+tool.name))
+		if doc := r.docMarkdown(tool.doc); doc != "" {
+			fmt.Fprintln(w, doc)
+			fmt.Fprintln(w)
+		}
+	}
+}
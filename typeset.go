@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+)
+
+// renderTypeSet lists the permitted types of a constraint interface whose
+// body has union or ~T elements. A single element becomes one bullet per
+// term; several elements must all be satisfied, so each is listed as a whole.
+func (r *markdownRenderer) renderTypeSet(w io.Writer, spec *ast.TypeSpec) {
+	if spec == nil {
+		return
+	}
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok || iface.Methods == nil {
+		return
+	}
+	var elems [][]ast.Expr
+	for _, field := range iface.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		terms := unionTerms(field.Type)
+		if len(terms) == 1 {
+			if _, tilde := terms[0].(*ast.UnaryExpr); !tilde {
+				// A lone embedded interface or type name, not a type set.
+				continue
+			}
+		}
+		elems = append(elems, terms)
+	}
+	if len(elems) == 0 {
+		return
+	}
+	r.heading(w, 3, "Type Set")
+	if len(elems) == 1 {
+		for _, term := range elems[0] {
+			fmt.Fprintf(w, "- %s\n", r.typeSetTerm(term))
+		}
+		fmt.Fprintln(w)
+		return
+	}
+	fmt.Fprintln(w, "A type must satisfy every element:")
+	fmt.Fprintln(w)
+	for _, terms := range elems {
+		parts := make([]string, len(terms))
+		for i, term := range terms {
+			parts[i] = r.formatNode(term)
+		}
+		fmt.Fprintf(w, "- `%s`\n", strings.Join(parts, " | "))
+	}
+	fmt.Fprintln(w)
+}
+
+// unionTerms flattens a | b | c into its terms.
+func unionTerms(expr ast.Expr) []ast.Expr {
+	if bin, ok := expr.(*ast.BinaryExpr); ok && bin.Op == token.OR {
+		return append(unionTerms(bin.X), unionTerms(bin.Y)...)
+	}
+	return []ast.Expr{expr}
+}
+
+func (r *markdownRenderer) typeSetTerm(term ast.Expr) string {
+	if u, ok := term.(*ast.UnaryExpr); ok && u.Op == token.TILDE {
+		return fmt.Sprintf("`%s` — any type whose underlying type is `%s`", r.formatNode(term), r.formatNode(u.X))
+	}
+	return fmt.Sprintf("`%s` — exactly `%s`", r.formatNode(term), r.formatNode(term))
+}
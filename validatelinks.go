@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	inlineLinkTarget = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+	refLinkTarget    = regexp.MustCompile(`^\[[^\]]+\]:\s*(\S+)`)
+)
+
+// linkCheckWriter records the Markdown files a tree run writes so
+// -validate-links can check their relative links once every file exists,
+// including under -dry-run where nothing reaches the disk.
+type linkCheckWriter struct {
+	docWriter
+	ext     string
+	written map[string][]byte
+}
+
+func newLinkCheckWriter(fw docWriter, opts options) *linkCheckWriter {
+	return &linkCheckWriter{docWriter: fw, ext: filepath.Ext(indexFileName(opts)), written: make(map[string][]byte)}
+}
+
+func (c *linkCheckWriter) writeFile(path string, data []byte) error {
+	c.written[filepath.Clean(path)] = data
+	return c.docWriter.writeFile(path, data)
+}
+
+// danglingLinks lists "file: link" for every relative link in a written
+// Markdown file whose target is neither on disk nor written by this run.
+func (c *linkCheckWriter) danglingLinks() []string {
+	var dangling []string
+	for path, data := range c.written {
+		if filepath.Ext(path) != c.ext {
+			continue
+		}
+		for _, target := range relativeLinkTargets(data) {
+			resolved := filepath.Join(filepath.Dir(path), filepath.FromSlash(target))
+			if _, ok := c.written[resolved]; ok {
+				continue
+			}
+			if _, err := os.Stat(resolved); err == nil {
+				continue
+			}
+			dangling = append(dangling, fmt.Sprintf("%s: dangling link to %s", displayFilename(path), target))
+		}
+	}
+	sort.Strings(dangling)
+	return dangling
+}
+
+// relativeLinkTargets returns the file part of each inline or reference
+// link in md that points into the tree, skipping code blocks, URLs, and
+// in-page anchors.
+func relativeLinkTargets(md []byte) []string {
+	var targets []string
+	var fenced bool
+	for _, line := range strings.Split(string(md), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		var found []string
+		for _, m := range inlineLinkTarget.FindAllStringSubmatch(line, -1) {
+			found = append(found, m[1])
+		}
+		if m := refLinkTarget.FindStringSubmatch(line); m != nil {
+			found = append(found, m[1])
+		}
+		for _, target := range found {
+			if i := strings.IndexAny(target, "#?"); i >= 0 {
+				target = target[:i]
+			}
+			if target == "" || strings.Contains(target, ":") || strings.HasPrefix(target, "/") {
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func reportDanglingLinks(warnings *warningLog, dangling []string) error {
+	if len(dangling) == 0 {
+		return nil
+	}
+	for _, msg := range dangling {
+		warnings.warnf("%s", msg)
+	}
+	return fmt.Errorf("%d dangling link(s) in generated files", len(dangling))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/types"
+	"io"
+)
+
+// isVarGroup reports whether every value in values is a var declaration.
+func isVarGroup(values []*doc.Value) bool {
+	for _, v := range values {
+		if v.Decl == nil || v.Decl.Tok != token.VAR {
+			return false
+		}
+	}
+	return len(values) > 0
+}
+
+// renderVarTable renders package-level variables as one Name/Type/Description
+// table. A spec's own comment describes it; otherwise the enclosing group's
+// doc does. Each name carries its own anchor, as in a grouped block.
+func (r *markdownRenderer) renderVarTable(w io.Writer, values []*doc.Value) {
+	fmt.Fprintln(w, "| Name | Type | Description |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, v := range values {
+		for _, spec := range v.Decl.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			desc := v.Doc
+			switch {
+			case vs.Doc != nil:
+				desc = vs.Doc.Text()
+			case vs.Comment != nil:
+				desc = vs.Comment.Text()
+			}
+			desc = escapeTableCell(r.summaryText(desc))
+			for _, ident := range vs.Names {
+				if ident.Name == "_" {
+					continue
+				}
+				fmt.Fprintf(w, "| %s`%s` | `%s` | %s |\n", r.valueAnchors([]string{ident.Name}), ident.Name, escapeTableCell(r.varType(vs, ident)), desc)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// varType returns the declared type of ident, falling back to the type the
+// checker inferred from its initializer.
+func (r *markdownRenderer) varType(vs *ast.ValueSpec, ident *ast.Ident) string {
+	if vs.Type != nil {
+		return r.formatNode(vs.Type)
+	}
+	if r.typesInfo == nil {
+		return "?"
+	}
+	obj, ok := r.typesInfo.Defs[ident].(*types.Var)
+	if !ok {
+		return "?"
+	}
+	return types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg()))
+}
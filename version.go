@@ -1,5 +1,46 @@
 package main
 
+import (
+	"runtime"
+	"runtime/debug"
+)
+
 // Version is the current version of go-docmd.
 // This is set at build time via -ldflags.
 var Version = "dev"
+
+// versionInfo describes the running binary for `go-docmd version`.
+type versionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Commit    string `json:"commit,omitempty"`
+}
+
+// resolveVersion returns Version, falling back to the module version recorded
+// in the build info for binaries built with `go install`.
+func resolveVersion() string {
+	if Version != "dev" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			return v
+		}
+	}
+	return Version
+}
+
+func currentVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   resolveVersion(),
+		GoVersion: runtime.Version(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// warningLog collects the diagnostics a run produces so they print together
+// once rendering finishes and, with -fail-on-warning, fail the run. Repeated
+// messages are kept once. A nil log discards everything.
+type warningLog struct {
+	messages []string
+	seen     map[string]bool
+}
+
+func (l *warningLog) warnf(format string, args ...any) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if l.seen[msg] {
+		return
+	}
+	if l.seen == nil {
+		l.seen = make(map[string]bool)
+	}
+	l.seen[msg] = true
+	l.messages = append(l.messages, msg)
+}
+
+// flush prints the collected warnings to w and, when strict is set and there
+// were any, returns an error counting them.
+func (l *warningLog) flush(w io.Writer, strict bool) error {
+	if l == nil || len(l.messages) == 0 {
+		return nil
+	}
+	if w != nil {
+		for _, msg := range l.messages {
+			fmt.Fprintln(w, msg)
+		}
+	}
+	if strict {
+		return withKind(kindWarnings, "", fmt.Errorf("%d warning(s) with -fail-on-warning", len(l.messages)))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// workspaceRelDirs returns output directories for packages drawn from more
+// than one module, as in a go.work workspace. Each package is placed under a
+// segment named for its module, followed by its path within that module, so
+// relative links stay valid across module boundaries. It returns nil when
+// every package belongs to the same module.
+func workspaceRelDirs(pkgs []*packages.Package) map[string]string {
+	modules := make(map[string]*packages.Module)
+	for _, pkg := range pkgs {
+		if pkg.Module == nil || pkg.Module.Dir == "" {
+			return nil
+		}
+		modules[pkg.Module.Path] = pkg.Module
+	}
+	if len(modules) < 2 {
+		return nil
+	}
+	// Name modules by their last path element unless two share one.
+	segments := make(map[string]string, len(modules))
+	seen := make(map[string]bool, len(modules))
+	unique := true
+	for modPath := range modules {
+		base := path.Base(modPath)
+		if seen[base] {
+			unique = false
+		}
+		seen[base] = true
+		segments[modPath] = base
+	}
+	if !unique {
+		for modPath := range modules {
+			segments[modPath] = modPath
+		}
+	}
+	relDirs := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		segment := segments[pkg.Module.Path]
+		rel, err := filepath.Rel(canonicalPath(pkg.Module.Dir), canonicalPath(packageDir(pkg)))
+		if err != nil || rel == "." {
+			relDirs[pkg.PkgPath] = segment
+			continue
+		}
+		relDirs[pkg.PkgPath] = path.Join(segment, filepath.ToSlash(rel))
+	}
+	return relDirs
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapProse reflows prose paragraphs and list items in md to at most width
+// columns. Headings, tables, HTML, indented and fenced code, and paragraphs
+// with hard line breaks are left untouched, and code spans and links are
+// never split. A width of zero or less disables wrapping.
+func wrapProse(md string, width int) string {
+	if width <= 0 {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			out = append(out, line)
+			i++
+			continue
+		}
+		if inFence || !wrappable(line) {
+			out = append(out, line)
+			i++
+			continue
+		}
+		// Gather the paragraph or list item: following lines that continue it
+		// without starting a new block.
+		end := i + 1
+		for end < len(lines) && continuesParagraph(lines[end]) {
+			end++
+		}
+		block := lines[i:end]
+		if hasHardBreak(block) {
+			out = append(out, block...)
+		} else {
+			out = append(out, wrapBlock(block, width)...)
+		}
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrappable reports whether line starts a paragraph or list item.
+func wrappable(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return false
+	}
+	if isListLine(line) {
+		return true
+	}
+	if leadingWhitespace(line) > 0 {
+		return false
+	}
+	switch trimmed[0] {
+	case '#', '|', '<', '>':
+		return false
+	}
+	return true
+}
+
+func continuesParagraph(line string) bool {
+	return wrappable(line) && !isListLine(line)
+}
+
+func hasHardBreak(block []string) bool {
+	for _, line := range block {
+		if strings.HasSuffix(line, "\\") || strings.HasSuffix(line, "  ") {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapBlock rewraps one paragraph or list item, keeping a list marker on the
+// first line and indenting continuation lines to align with its text.
+func wrapBlock(block []string, width int) []string {
+	first := block[0]
+	indent := first[:leadingWhitespace(first)]
+	text := strings.TrimSpace(first)
+	hang := indent
+	if isListLine(first) {
+		marker, rest, _ := strings.Cut(text, " ")
+		indent += marker + " "
+		hang += strings.Repeat(" ", utf8.RuneCountInString(marker)+1)
+		text = rest
+	}
+	for _, line := range block[1:] {
+		text += " " + strings.TrimSpace(line)
+	}
+	var out []string
+	current := indent
+	empty := true
+	for _, word := range wrapTokens(text) {
+		if !empty && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			out = append(out, current)
+			current = hang
+			empty = true
+		}
+		if !empty {
+			current += " "
+		}
+		current += word
+		empty = false
+	}
+	return append(out, current)
+}
+
+// wrapTokens splits text at spaces that fall outside code spans and link
+// text, so those constructs stay on one line.
+func wrapTokens(text string) []string {
+	var tokens []string
+	var b strings.Builder
+	var fence string
+	depth := 0
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '`':
+			run := 1
+			for i+run < len(text) && text[i+run] == '`' {
+				run++
+			}
+			ticks := text[i : i+run]
+			switch fence {
+			case "":
+				fence = ticks
+			case ticks:
+				fence = ""
+			}
+			b.WriteString(ticks)
+			i += run - 1
+			continue
+		case fence != "":
+		case c == '[' || c == '(' && i > 0 && text[i-1] == ']':
+			depth++
+		case (c == ']' || c == ')') && depth > 0:
+			depth--
+		case c == ' ' && depth == 0:
+			flush()
+			continue
+		}
+		b.WriteByte(c)
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// docWriter receives every file the tree writers produce, so a real run and a
+// -dry-run share the same walk and differ only in what happens to each file.
+type docWriter interface {
+	writeFile(path string, data []byte) error
+}
+
+// diskWriter writes files atomically, creating parent directories as needed.
+// With protect set, a file carrying the generated marker never replaces an
+// existing file that lacks it.
+type diskWriter struct {
+	protect bool
+}
+
+func (d diskWriter) writeFile(path string, data []byte) error {
+	if d.protect && bytes.HasPrefix(data, []byte(generatedMarker)) {
+		existing, err := os.ReadFile(path)
+		if err == nil && !bytes.Contains(existing, []byte(generatedMarker)) {
+			return fmt.Errorf("refusing to overwrite %s: it was not generated by go-docmd (use -force)", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o644)
+}
+
+// planWriter reports each target path as create or overwrite, and each -o
+// pipe as the command it would run, without touching the filesystem.
+type planWriter struct {
+	w io.Writer
+}
+
+func (p planWriter) writeFile(path string, data []byte) error {
+	if isPipeOutput(path) {
+		_, err := fmt.Fprintf(p.w, "pipe %s\n", strings.TrimSpace(strings.TrimPrefix(path, "|")))
+		return err
+	}
+	action := "create"
+	if _, err := os.Stat(path); err == nil {
+		action = "overwrite"
+	}
+	_, err := fmt.Fprintf(p.w, "%s %s\n", action, path)
+	return err
+}
+
+// docWriter returns the writer selected by -dry-run.
+func (app *cliApp) docWriter(opts options) docWriter {
+	if opts.dryRun {
+		return planWriter{w: app.stdout}
+	}
+	return diskWriter{protect: opts.markGenerated && !opts.force}
+}